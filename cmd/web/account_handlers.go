@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"sketchdb.cozycole.net/internal/domain/accounts"
+)
+
+// accountEmailPage is shared by the pages that ask for an email to send
+// an account link to
+type accountEmailPage struct {
+	Title       string
+	Description string
+	Action      string
+	Submit      string
+}
+
+var (
+	resendActivationPage = accountEmailPage{
+		Title:       "Resend Activation Email",
+		Description: "Enter the email you signed up with and we'll send a new activation link.",
+		Action:      "/activate/resend",
+		Submit:      "Send Activation Email",
+	}
+	passwordResetRequestPage = accountEmailPage{
+		Title:       "Reset Password",
+		Description: "Enter the email for your account and we'll send a link to choose a new password.",
+		Action:      "/password-reset",
+		Submit:      "Send Reset Link",
+	}
+)
+
+// activateUser shows a confirm button for the emailed link, activating
+// only on POST stops link scanners from using up the token
+func (app *application) activateUser(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	data.Forms.Activation = &activationForm{Token: r.URL.Query().Get("token")}
+	app.render(r, w, http.StatusOK, "activate.gohtml", "base", data)
+}
+
+func (app *application) activateUserPost(w http.ResponseWriter, r *http.Request) {
+	var form activationForm
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	_, err = app.services.Accounts.Activate(form.Token)
+	if err != nil {
+		if errors.Is(err, accounts.ErrInvalidToken) {
+			form.AddNonFieldError("This activation link is invalid or has expired.")
+			data := app.newTemplateData(r)
+			data.Forms.Activation = &form
+			app.render(r, w, http.StatusUnprocessableEntity, "activate.gohtml", "base", data)
+		} else {
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Your account has been activated!")
+
+	if app.isAutheticated(r) {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	} else {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+	}
+}
+
+func (app *application) resendActivation(w http.ResponseWriter, r *http.Request) {
+	app.renderAccountEmail(w, r, http.StatusOK, resendActivationPage, &accountEmailForm{})
+}
+
+func (app *application) resendActivationPost(w http.ResponseWriter, r *http.Request) {
+	var form accountEmailForm
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if app.validateAccountEmailForm(&form); !form.Valid() {
+		app.renderAccountEmail(w, r, http.StatusUnprocessableEntity, resendActivationPage, &form)
+		return
+	}
+
+	err = app.services.Accounts.RequestActivation(form.Email)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "If that email belongs to an inactive account, an activation link is on its way.")
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (app *application) passwordReset(w http.ResponseWriter, r *http.Request) {
+	app.renderAccountEmail(w, r, http.StatusOK, passwordResetRequestPage, &accountEmailForm{})
+}
+
+func (app *application) passwordResetPost(w http.ResponseWriter, r *http.Request) {
+	var form accountEmailForm
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if app.validateAccountEmailForm(&form); !form.Valid() {
+		app.renderAccountEmail(w, r, http.StatusUnprocessableEntity, passwordResetRequestPage, &form)
+		return
+	}
+
+	err = app.services.Accounts.RequestPasswordReset(form.Email)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "If that email belongs to an account, a password reset link is on its way.")
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (app *application) passwordResetConfirm(w http.ResponseWriter, r *http.Request) {
+	form := &passwordResetForm{Token: r.URL.Query().Get("token")}
+
+	err := app.services.Accounts.CheckPasswordReset(form.Token)
+	if err != nil && !errors.Is(err, accounts.ErrInvalidToken) {
+		app.serverError(r, w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if err != nil {
+		form.AddNonFieldError("This password reset link is invalid or has expired.")
+		status = http.StatusUnprocessableEntity
+	}
+
+	data := app.newTemplateData(r)
+	data.Forms.PasswordReset = form
+	app.render(r, w, status, "password-reset.gohtml", "base", data)
+}
+
+func (app *application) passwordResetConfirmPost(w http.ResponseWriter, r *http.Request) {
+	var form passwordResetForm
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if app.validatePasswordResetForm(&form); !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Forms.PasswordReset = &form
+		app.render(r, w, http.StatusUnprocessableEntity, "password-reset.gohtml", "base", data)
+		return
+	}
+
+	user, err := app.services.Accounts.ResetPassword(form.Token, form.Password)
+	if err != nil {
+		if errors.Is(err, accounts.ErrInvalidToken) {
+			form.AddNonFieldError("This password reset link is invalid or has expired.")
+			data := app.newTemplateData(r)
+			data.Forms.PasswordReset = &form
+			app.render(r, w, http.StatusUnprocessableEntity, "password-reset.gohtml", "base", data)
+		} else {
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	// whoever had the old password could still be logged in
+	err = app.destroyUserSessions(r.Context(), *user.ID)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	err = app.sessionManager.RenewToken(r.Context())
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+	app.sessionManager.Remove(r.Context(), "authenticatedUserID")
+
+	app.sessionManager.Put(r.Context(), "flash", "Your password has been reset. Please log in.")
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (app *application) renderAccountEmail(w http.ResponseWriter, r *http.Request, status int, page accountEmailPage, form *accountEmailForm) {
+	data := app.newTemplateData(r)
+	data.Page = page
+	data.Forms.AccountEmail = form
+	app.render(r, w, status, "account-email.gohtml", "base", data)
+}
+
+// destroyUserSessions ends every stored session logged in as the user
+func (app *application) destroyUserSessions(ctx context.Context, userId int) error {
+	return app.sessionManager.Iterate(ctx, func(ctx context.Context) error {
+		if app.sessionManager.GetInt(ctx, "authenticatedUserID") != userId {
+			return nil
+		}
+		return app.sessionManager.Destroy(ctx)
+	})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+func readAuditFilter(r *http.Request) *models.Filter {
+	r.ParseForm()
+
+	page, err := strconv.Atoi(r.Form.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(r.Form.Get("pageSize"))
+	if err != nil || pageSize < 1 {
+		pageSize = audit.DefaultPageSize
+	}
+
+	return &models.Filter{Page: page, PageSize: pageSize}
+}
+
+// entityAuditAPI lists an entity's revisions, newest first
+func (app *application) entityAuditAPI(w http.ResponseWriter, r *http.Request) {
+	entityType := r.PathValue("entityType")
+	if !models.IsAuditEntity(entityType) {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	entityId, err := strconv.Atoi(r.PathValue("entityId"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("entity id is invalid"))
+		return
+	}
+
+	entries, metadata, err := app.services.Audit.EntityHistory(entityType, entityId, readAuditFilter(r))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"history": entries, "meta": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// userAuditAPI lists the writes made by a user, newest first
+func (app *application) userAuditAPI(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.PathValue("userId"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("user id is invalid"))
+		return
+	}
+
+	entries, metadata, err := app.services.Audit.ActorHistory(userId, readAuditFilter(r))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"history": entries, "meta": metadata}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) revertSketchAPI(w http.ResponseWriter, r *http.Request) {
+	sketchId, entryId, ok := app.readRevertParams(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	sketch, err := app.services.Sketches.RevertSketch(user, sketchId, entryId)
+	if app.writeFailed(r, err) {
+		app.revertErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"sketch": sketch}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) revertPersonAPI(w http.ResponseWriter, r *http.Request) {
+	personId, entryId, ok := app.readRevertParams(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	person, err := app.services.People.RevertPerson(user, personId, entryId)
+	if app.writeFailed(r, err) {
+		app.revertErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"person": person}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) readRevertParams(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("id param not defined"))
+		return 0, 0, false
+	}
+
+	entryId, err := strconv.Atoi(r.PathValue("entryId"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("revision id is invalid"))
+		return 0, 0, false
+	}
+
+	return id, entryId, true
+}
+
+func (app *application) revertErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, models.ErrNoRecord), errors.Is(err, models.ErrNoSketch):
+		app.notFoundResponse(w, r)
+	case errors.Is(err, audit.ErrWrongEntity), errors.Is(err, audit.ErrNoSnapshot):
+		app.errorResponse(w, r, http.StatusUnprocessableEntity, err.Error())
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sketchdb.cozycole.net/internal/domain/browse"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type browseSectionInput struct {
+	Title    string         `json:"title"`
+	Filter   *models.Filter `json:"filter"`
+	Position *int           `json:"position"`
+	StartsAt *time.Time     `json:"startsAt"`
+	EndsAt   *time.Time     `json:"endsAt"`
+}
+
+func (input *browseSectionInput) validate() map[string]string {
+	errs := map[string]string{}
+	input.Title = strings.TrimSpace(input.Title)
+	if input.Title == "" {
+		errs["title"] = "title must be specified"
+	}
+
+	if input.Filter == nil {
+		errs["filter"] = "filter must be specified"
+	} else if input.Filter.SortBy != "" && !models.IsSortOption(input.Filter.SortBy) {
+		errs["filter"] = fmt.Sprintf("unknown sort %q", input.Filter.SortBy)
+	} else if input.Filter.PageSize > browse.MaxPageSize {
+		errs["filter"] = fmt.Sprintf("page size cannot be more than %d", browse.MaxPageSize)
+	}
+
+	if input.Position != nil && *input.Position < 0 {
+		errs["position"] = "position cannot be negative"
+	}
+
+	if input.StartsAt != nil && input.EndsAt != nil && !input.EndsAt.After(*input.StartsAt) {
+		errs["endsAt"] = "end date must be after the start date"
+	}
+
+	return errs
+}
+
+func (app *application) listBrowseSectionsAPI(w http.ResponseWriter, r *http.Request) {
+	sections, err := app.services.Browse.ListSections()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"sections": sections}, nil)
+}
+
+func (app *application) getBrowseSectionAPI(w http.ResponseWriter, r *http.Request) {
+	section, ok := app.readBrowseSection(w, r)
+	if !ok {
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"section": section}, nil)
+}
+
+func (app *application) createBrowseSectionAPI(w http.ResponseWriter, r *http.Request) {
+	var input browseSectionInput
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if errs := input.validate(); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	section := &models.BrowseSection{
+		Title:    &input.Title,
+		Filter:   input.Filter,
+		Position: input.Position,
+		StartsAt: input.StartsAt,
+		EndsAt:   input.EndsAt,
+	}
+
+	err = app.services.Browse.CreateSection(section)
+	if err != nil {
+		app.browseSectionError(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusCreated, envelope{"section": section}, nil)
+}
+
+func (app *application) updateBrowseSectionAPI(w http.ResponseWriter, r *http.Request) {
+	section, ok := app.readBrowseSection(w, r)
+	if !ok {
+		return
+	}
+
+	var input browseSectionInput
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if errs := input.validate(); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	section.Title = &input.Title
+	section.Filter = input.Filter
+	section.StartsAt = input.StartsAt
+	section.EndsAt = input.EndsAt
+	if input.Position != nil {
+		section.Position = input.Position
+	}
+
+	err = app.services.Browse.UpdateSection(section)
+	if err != nil {
+		app.browseSectionError(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"section": section}, nil)
+}
+
+func (app *application) deleteBrowseSectionAPI(w http.ResponseWriter, r *http.Request) {
+	sectionId, err := strconv.Atoi(r.PathValue("sectionId"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("section id param not defined"))
+		return
+	}
+
+	err = app.services.Browse.DeleteSection(sectionId)
+	if err != nil {
+		app.browseSectionError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) updateBrowseSectionOrderAPI(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		SectionIds []int `json:"sectionIds"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err = app.services.Browse.ReorderSections(input.SectionIds)
+	if err != nil {
+		app.browseSectionError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) readBrowseSection(w http.ResponseWriter, r *http.Request) (*models.BrowseSection, bool) {
+	sectionId, err := strconv.Atoi(r.PathValue("sectionId"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("section id param not defined"))
+		return nil, false
+	}
+
+	section, err := app.services.Browse.GetSection(sectionId)
+	if err != nil {
+		app.browseSectionError(w, r, err)
+		return nil, false
+	}
+
+	return section, true
+}
+
+func (app *application) browseSectionError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, models.ErrNoRecord):
+		app.notFoundResponse(w, r)
+	case errors.Is(err, browse.ErrInvalidFilter), errors.Is(err, browse.ErrEmptyFilter):
+		app.failedValidationResponse(w, r, map[string]string{"filter": err.Error()})
+	case errors.Is(err, browse.ErrInvalidSectionOrder):
+		app.failedValidationResponse(w, r, map[string]string{"sectionIds": err.Error()})
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
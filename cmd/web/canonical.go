@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// Entity pages are served at /{entityType}/{id}/{slug}[/...], the id
+// identifies the entity and the slug only makes the url readable. Slugs
+// change when an entity is renamed, so a request with any slug other than
+// the current one is redirected to the canonical url.
+
+// redirectToCanonical permanently redirects an entity page requested with
+// a stale or wrong slug to the same page with the entity's current slug.
+// It reports whether it redirected. htmx requests swap part of a page that
+// was already loaded from the canonical url, so they aren't redirected.
+func (app *application) redirectToCanonical(w http.ResponseWriter, r *http.Request, slug *string) bool {
+	if r.Header.Get("HX-Request") == "true" {
+		return false
+	}
+
+	segments := strings.Split(r.URL.Path, "/")
+	if len(segments) < 4 || segments[3] == safeDeref(slug) || safeDeref(slug) == "" {
+		return false
+	}
+
+	segments[3] = safeDeref(slug)
+	app.movedPermanently(w, r, strings.Join(segments, "/"))
+	return true
+}
+
+// redirectMoved handles an entity page whose id wasn't found. Ids of
+// merged people and characters redirect to the row they were merged into,
+// otherwise the slug is looked up in the slug history. Anything else isn't
+// found.
+func (app *application) redirectMoved(w http.ResponseWriter, r *http.Request, entityType string, id int) {
+	toId, err := app.movedTo(r, entityType, id)
+	if err == nil {
+		var slug string
+		slug, err = app.slugs.CurrentSlug(entityType, toId)
+		if err == nil {
+			segments := strings.Split(r.URL.Path, "/")
+			segments[2] = fmt.Sprint(toId)
+			if len(segments) > 3 {
+				segments[3] = slug
+			}
+			app.movedPermanently(w, r, strings.Join(segments, "/"))
+			return
+		}
+	}
+
+	if errors.Is(err, models.ErrNoRecord) {
+		app.notFound(w)
+	} else {
+		app.serverError(r, w, err)
+	}
+}
+
+func (app *application) movedTo(r *http.Request, entityType string, id int) (int, error) {
+	var toId int
+	var err error
+	switch entityType {
+	case "person":
+		toId, err = app.people.GetRedirect(id)
+	case "character":
+		toId, err = app.characters.GetRedirect(id)
+	default:
+		err = models.ErrNoRecord
+	}
+
+	if !errors.Is(err, models.ErrNoRecord) {
+		return toId, err
+	}
+
+	slug := r.PathValue("slug")
+	if slug == "" {
+		return 0, models.ErrNoRecord
+	}
+	return app.slugs.FindBySlug(entityType, slug)
+}
+
+func (app *application) movedPermanently(w http.ResponseWriter, r *http.Request, path string) {
+	if r.URL.RawQuery != "" {
+		path += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, path, http.StatusMovedPermanently)
+}
+
+// canonicalURL is the absolute url of the requested page without its
+// query string, for the page's <link rel="canonical">
+func (app *application) canonicalURL(r *http.Request) string {
+	return app.settings.origin + r.URL.Path
+}
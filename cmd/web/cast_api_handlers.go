@@ -58,8 +58,9 @@ func (app *application) updateCastOrderAPI(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = app.services.Casts.ReorderCast(sketchId, input.CastPositions)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	err = app.services.Casts.ReorderCast(user, sketchId, input.CastPositions)
+	if app.writeFailed(r, err) {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
@@ -94,8 +95,9 @@ func (app *application) createCastAPI(w http.ResponseWriter, r *http.Request) {
 	thumbnail, _ := fileHeaderToBytes(form.CharacterThumbnail)
 	profile, _ := fileHeaderToBytes(form.CharacterProfile)
 
-	newCast, err := app.services.Casts.CreateCastMember(&castMember, thumbnail, profile, form.CropThumbnailBorder)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	newCast, err := app.services.Casts.CreateCastMember(user, &castMember, thumbnail, profile, form.CropThumbnailBorder)
+	if app.writeFailed(r, err) {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
@@ -132,10 +134,11 @@ func (app *application) updateCastAPI(w http.ResponseWriter, r *http.Request) {
 
 	thumbnail, _ := fileHeaderToBytes(form.CharacterThumbnail)
 	profile, _ := fileHeaderToBytes(form.CharacterProfile)
-	app.infoLog.Printf("%+v", castMember)
+	app.logger.Debug("updating cast member", "cast", castMember)
 
-	updatedCast, err := app.services.Casts.UpdateCastMember(&castMember, thumbnail, profile, form.CropThumbnailBorder)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	updatedCast, err := app.services.Casts.UpdateCastMember(user, &castMember, thumbnail, profile, form.CropThumbnailBorder)
+	if app.writeFailed(r, err) {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
@@ -154,9 +157,11 @@ func (app *application) deleteCastAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.services.Casts.DeleteCastmember(castId)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	err = app.services.Casts.DeleteCastmember(user, castId)
+	if app.writeFailed(r, err) {
 		app.serverErrorResponse(w, r, err)
+		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
@@ -190,7 +195,7 @@ func (app *application) listCastAPI(w http.ResponseWriter, r *http.Request) {
 	filterQuery := strings.Join(strings.Fields(query), " | ")
 
 	sketchIds := extractUrlParamIDs(r.URL.Query()["sketch"])
-	app.infoLog.Printf("SKETCHES: %v", sketchIds)
+	app.logger.Debug("cast sketch filter", "sketches", sketchIds)
 
 	castList, err := app.services.Casts.ListCasts(
 		&models.Filter{
@@ -48,7 +48,7 @@ func (app *application) categoryAdd(w http.ResponseWriter, r *http.Request) {
 	err := app.decodePostForm(r, &form)
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -129,7 +129,7 @@ func (app *application) categoryUpdate(w http.ResponseWriter, r *http.Request) {
 	err = app.decodePostForm(r, &form)
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
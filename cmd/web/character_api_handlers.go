@@ -59,3 +59,44 @@ func (app *application) listCharactersAPI(w http.ResponseWriter, r *http.Request
 		app.serverError(r, w, err)
 	}
 }
+
+// mergeCharacterAPI merges the character in the path into the character
+// intoId. With ?dry_run=true nothing is changed and the response counts
+// the rows that would be re-pointed.
+func (app *application) mergeCharacterAPI(w http.ResponseWriter, r *http.Request) {
+	fromId, input, ok := app.readMergeInput(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	report, err := app.services.Characters.MergeCharacters(user, fromId, input.IntoID, input.options(r))
+	if app.writeFailed(r, err) {
+		app.mergeErrorResponse(w, r, err, report != nil)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"merge": report}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deleteCharacterAPI(w http.ResponseWriter, r *http.Request) {
+	id, opts, ok := app.readDeleteInput(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	report, err := app.services.Characters.DeleteCharacter(user, id, opts)
+	if app.writeFailed(r, err) {
+		app.deleteErrorResponse(w, r, err, report)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"delete": report}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
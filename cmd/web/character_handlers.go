@@ -21,13 +21,17 @@ func (app *application) characterView(w http.ResponseWriter, r *http.Request) {
 	character, err := app.characters.GetById(characterdId)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.redirectMoved(w, r, "character", characterdId)
 		} else {
 			app.serverError(r, w, err)
 		}
 		return
 	}
 
+	if app.redirectToCanonical(w, r, character.Slug) {
+		return
+	}
+
 	popularSketches, _, err := app.sketches.Get(
 		&models.Filter{
 			Page:         1,
@@ -38,6 +42,7 @@ func (app *application) characterView(w http.ResponseWriter, r *http.Request) {
 	)
 
 	data := app.newTemplateData(r)
+	data.CanonicalURL = app.canonicalURL(r)
 	page, err := views.CharacterPageView(
 		character,
 		popularSketches,
@@ -121,8 +126,9 @@ func (app *application) addCharacter(w http.ResponseWriter, r *http.Request) {
 	}
 	character.Image = &thumbName
 
-	id, err := app.characters.Insert(&character)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	id, err := app.services.Characters.CreateCharacter(user, &character)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		app.characters.Delete(id)
 		return
@@ -143,7 +149,7 @@ func (app *application) updateCharacterPage(w http.ResponseWriter, r *http.Reque
 	characterId, err := strconv.Atoi(id)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -175,7 +181,7 @@ func (app *application) updateCharacter(w http.ResponseWriter, r *http.Request)
 	characterId, err := strconv.Atoi(id)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -230,8 +236,9 @@ func (app *application) updateCharacter(w http.ResponseWriter, r *http.Request)
 	updatedCharacter.Image = &profileImgName
 	slug := models.CreateSlugName(form.Name)
 	updatedCharacter.Slug = &slug
-	err = app.characters.Update(&updatedCharacter)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	err = app.services.Characters.UpdateCharacter(user, &updatedCharacter)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		return
 	}
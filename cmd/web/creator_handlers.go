@@ -21,13 +21,17 @@ func (app *application) creatorView(w http.ResponseWriter, r *http.Request) {
 	creator, err := app.creators.GetById(creatorId)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.redirectMoved(w, r, "creator", creatorId)
 		} else {
 			app.serverError(r, w, err)
 		}
 		return
 	}
 
+	if app.redirectToCanonical(w, r, creator.Slug) {
+		return
+	}
+
 	popularSketches, _, err := app.sketches.Get(
 		&models.Filter{
 			Page:       1,
@@ -49,6 +53,7 @@ func (app *application) creatorView(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := app.newTemplateData(r)
+	data.CanonicalURL = app.canonicalURL(r)
 	page, err := views.CreatorPageView(
 		creator,
 		popularSketches,
@@ -110,8 +115,9 @@ func (app *application) addCreator(w http.ResponseWriter, r *http.Request) {
 	}
 	creator.ProfileImage = &thumbName
 
-	id, err := app.creators.Insert(&creator)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	id, err := app.services.Creators.CreateCreator(user, &creator)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		return
 	}
@@ -131,7 +137,7 @@ func (app *application) updateCreatorPage(w http.ResponseWriter, r *http.Request
 	creatorId, err := strconv.Atoi(id)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -163,7 +169,7 @@ func (app *application) updateCreator(w http.ResponseWriter, r *http.Request) {
 	creatorId, err := strconv.Atoi(id)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -219,8 +225,9 @@ func (app *application) updateCreator(w http.ResponseWriter, r *http.Request) {
 	slug := models.CreateSlugName(form.Name)
 	updatedCreator.Slug = &slug
 
-	err = app.creators.Update(&updatedCreator)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	err = app.services.Creators.UpdateCreator(user, &updatedCreator)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		return
 	}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"sketchdb.cozycole.net/internal/domain/shared"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// readDeleteInput reads the id of the row to delete and what to do with
+// its dependents from ?reassign_to=, ?detach= and ?dry_run=
+func (app *application) readDeleteInput(w http.ResponseWriter, r *http.Request) (int, shared.DeleteOptions, bool) {
+	var opts shared.DeleteOptions
+
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return 0, opts, false
+	}
+
+	qs := r.URL.Query()
+	if reassignTo := qs.Get("reassign_to"); reassignTo != "" {
+		opts.ReassignTo, err = strconv.Atoi(reassignTo)
+		if err != nil || opts.ReassignTo < 1 {
+			app.failedValidationResponse(w, r, map[string]string{"reassign_to": "must be a positive integer"})
+			return 0, opts, false
+		}
+	}
+	opts.Detach = qs.Get("detach") == "true"
+	opts.DryRun = qs.Get("dry_run") == "true"
+
+	return id, opts, true
+}
+
+// deleteErrorResponse reports a failed delete. A row with dependents gets
+// a 409 listing them. Any other failure with a report happened after the
+// row was deleted, while removing its images.
+func (app *application) deleteErrorResponse(w http.ResponseWriter, r *http.Request, err error, report *shared.DeleteReport) {
+	switch {
+	case errors.Is(err, shared.ErrHasDependents):
+		app.errorResponse(w, r, http.StatusConflict, map[string]any{
+			"message":    err.Error(),
+			"dependents": report.Dependents,
+		})
+	case report != nil:
+		app.serverErrorResponse(w, r, fmt.Errorf("deleted but not cleaned up: %w", err))
+	case errors.Is(err, models.ErrNoRecord):
+		app.notFoundResponse(w, r)
+	case errors.Is(err, shared.ErrNoReassignTarget),
+		errors.Is(err, shared.ErrSelfReassign),
+		errors.Is(err, shared.ErrCantReassign):
+		app.failedValidationResponse(w, r, map[string]string{"reassign_to": err.Error()})
+	case errors.Is(err, shared.ErrReassignConflict):
+		app.badRequestResponse(w, r, err)
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -23,16 +23,21 @@ func (app *application) viewEpisode(w http.ResponseWriter, r *http.Request) {
 	episode, err := app.shows.GetEpisode(episodeId)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.redirectMoved(w, r, "episode", episodeId)
 		} else {
 			app.serverError(r, w, err)
 		}
 		return
 	}
 
+	if app.redirectToCanonical(w, r, episode.Slug) {
+		return
+	}
+
 	data := app.newTemplateData(r)
+	data.CanonicalURL = app.canonicalURL(r)
 	page, err := views.EpisodePageView(episode, app.baseImgUrl)
-	app.infoLog.Printf("%+v\n", page)
+	app.logger.Debug("episode page", "page", page)
 	if err != nil {
 		app.serverError(r, w, err)
 		return
@@ -47,7 +52,7 @@ func (app *application) addEpisodeForm(w http.ResponseWriter, r *http.Request) {
 	seasonId, err := strconv.Atoi(seasonIdParam)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -77,7 +82,7 @@ func (app *application) addEpisode(w http.ResponseWriter, r *http.Request) {
 	seasonId, err := strconv.Atoi(seasonIdParam)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -156,7 +161,7 @@ func (app *application) updateEpisodeForm(w http.ResponseWriter, r *http.Request
 	epId, err := strconv.Atoi(epIdParam)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -190,7 +195,7 @@ func (app *application) updateEpisode(w http.ResponseWriter, r *http.Request) {
 	epId, err := strconv.Atoi(epIdParam)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -249,7 +254,7 @@ func (app *application) updateEpisode(w http.ResponseWriter, r *http.Request) {
 
 	episode.ID = &epId
 	episode.Thumbnail = &thumbnailName
-	app.infoLog.Printf("%+v\n", episode)
+	app.logger.Debug("updating episode", "episode", episode)
 	err = app.shows.UpdateEpisode(&episode)
 	if err != nil {
 		app.serverError(r, w, err)
@@ -282,7 +287,7 @@ func (app *application) deleteEpisode(w http.ResponseWriter, r *http.Request) {
 	epId, err := strconv.Atoi(epIdParam)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
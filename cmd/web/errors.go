@@ -1,18 +1,25 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime/debug"
+
+	"sketchdb.cozycole.net/internal/domain/audit"
 )
 
-// The serverError helper writes an error message and stack trace to the errorLog,
+// The serverError helper logs the error with the request ID and stack trace,
 // then sends a generic 500 Internal Server Error response to the user.
 func (app *application) serverError(r *http.Request, w http.ResponseWriter, err error) {
-	trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
-	// 2 is inputed to look at the second frame for determining the Llongfile/Lshortfile and line number
-	// for the logged output (since we don't want to log the line number here, but wherever it is called)
-	app.errorLog.Output(2, trace)
+	stack := string(debug.Stack())
+	app.logger.Error(err.Error(),
+		"request_id", requestID(r),
+		"method", r.Method,
+		"uri", r.URL.RequestURI(),
+		"stack", stack,
+	)
+	trace := fmt.Sprintf("%s\n%s", err.Error(), stack)
 
 	isHxRequest := r.Header.Get("HX-Request") == "true"
 	if isHxRequest {
@@ -55,11 +62,22 @@ func (app *application) unauthorized(w http.ResponseWriter) {
 // ### JSON API helpers ###
 
 func (app *application) logError(r *http.Request, err error) {
-	var (
-		method = r.Method
-		uri    = r.URL.RequestURI()
+	app.logger.Error(err.Error(),
+		"request_id", requestID(r),
+		"method", r.Method,
+		"uri", r.URL.RequestURI(),
+		"stack", string(debug.Stack()),
 	)
-	app.errorLog.Printf("%s method=%s uri=%s", err.Error(), method, uri)
+}
+
+// writeFailed reports whether a service write failed. A write that was
+// made but couldn't be added to the audit log is logged and carries on.
+func (app *application) writeFailed(r *http.Request, err error) bool {
+	if errors.Is(err, audit.ErrNotRecorded) {
+		app.logError(r, err)
+		return false
+	}
+	return err != nil
 }
 
 func (app *application) errorResponse(w http.ResponseWriter, r *http.Request, status int, message any) {
@@ -113,6 +131,22 @@ func (app *application) editConflictResponse(w http.ResponseWriter, r *http.Requ
 	app.errorResponse(w, r, http.StatusConflict, message)
 }
 
+func (app *application) invalidAuthenticationTokenResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	message := "invalid or expired authentication token"
+	app.errorResponse(w, r, http.StatusUnauthorized, message)
+}
+
+func (app *application) inactiveAccountResponse(w http.ResponseWriter, r *http.Request) {
+	message := "your account must be activated to access this resource"
+	app.errorResponse(w, r, http.StatusForbidden, message)
+}
+
+func (app *application) missingScopeResponse(w http.ResponseWriter, r *http.Request, scope string) {
+	message := fmt.Sprintf("your token needs the %q scope to access this resource", scope)
+	app.errorResponse(w, r, http.StatusForbidden, message)
+}
+
 func (app *application) rateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
 	message := "rate limit exceeded"
 	app.errorResponse(w, r, http.StatusTooManyRequests, message)
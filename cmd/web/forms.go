@@ -7,21 +7,25 @@ import (
 
 	"sketchdb.cozycole.net/internal/validator"
 
+	"sketchdb.cozycole.net/internal/external/moviedb"
 	"sketchdb.cozycole.net/internal/external/wikipedia"
 )
 
 type Forms struct {
-	Cast       *castForm
-	Category   *categoryForm
-	Creator    *creatorForm
-	Episode    *episodeForm
-	Login      *userLoginForm
-	Person     *personForm
-	Show       *showForm
-	Signup     *userSignupForm
-	Tag        *tagForm
-	Sketch     *sketchForm
-	SketchTags *sketchTagsForm
+	AccountEmail  *accountEmailForm
+	Activation    *activationForm
+	Cast          *castForm
+	Category      *categoryForm
+	Creator       *creatorForm
+	Episode       *episodeForm
+	Login         *userLoginForm
+	PasswordReset *passwordResetForm
+	Person        *personForm
+	Show          *showForm
+	Signup        *userSignupForm
+	Tag           *tagForm
+	Sketch        *sketchForm
+	SketchTags    *sketchTagsForm
 }
 
 // Changes to the form fields must be updated in their respective
@@ -90,9 +94,17 @@ func (app *application) validatePersonForm(form *personForm) {
 		form.CheckField(wikiId != "" && err == nil, "wikiurl", "Please enter a valid Wikpedia url")
 	}
 
-	// if it's an add instead of update
-	if form.ID == 0 {
-		form.CheckField(form.ProfileImage != nil, "profileImg", "Please upload an image")
+	hasTMDb := false
+	if strings.TrimSpace(form.TMDbUrl) != "" {
+		tmdbId, err := moviedb.ParseTMDbID(form.TMDbUrl)
+		hasTMDb = tmdbId != "" && err == nil
+		form.CheckField(hasTMDb, "tmdbUrl", "Please enter a valid TMDb person url")
+	}
+
+	// if it's an add instead of update, the profile image can
+	// be pulled from TMDb instead
+	if form.ID == 0 && !hasTMDb {
+		form.CheckField(form.ProfileImage != nil, "profileImg", "Please upload an image or enter a TMDb url")
 	}
 
 	if form.ProfileImage == nil {
@@ -311,6 +323,36 @@ func (app *application) validateUserLoginForm(form *userLoginForm) {
 	form.CheckField(validator.NotBlank(form.Password), "password", "Field cannot be blank")
 }
 
+// accountEmailForm requests an activation or password reset email
+type accountEmailForm struct {
+	Email               string `form:"email"`
+	validator.Validator `form:"-"`
+}
+
+func (app *application) validateAccountEmailForm(form *accountEmailForm) {
+	form.CheckField(validator.NotBlank(form.Email), "email", "Field cannot be blank")
+	form.CheckField(validator.Matches(form.Email, validator.EmailRegEx), "email", "Please enter a valid email")
+}
+
+type activationForm struct {
+	Token               string `form:"token"`
+	validator.Validator `form:"-"`
+}
+
+type passwordResetForm struct {
+	Token               string `form:"token"`
+	Password            string `form:"password"`
+	ConfirmPassword     string `form:"confirmPassword"`
+	validator.Validator `form:"-"`
+}
+
+func (app *application) validatePasswordResetForm(form *passwordResetForm) {
+	form.CheckField(validator.NotBlank(form.Password), "password", "Field cannot be blank")
+	form.CheckField(validator.MinChars(form.Password, 8), "password", "Password must be 8-20 chararacters")
+	form.CheckField(validator.MaxChars(form.Password, 20), "password", "Password Must be 8-20 chararacters")
+	form.CheckField(form.Password == form.ConfirmPassword, "confirmPassword", "Passwords do not match")
+}
+
 type categoryForm struct {
 	ID                  int    `form:"id"`
 	Name                string `form:"categoryName"`
@@ -432,7 +474,7 @@ func (app *application) validateEpisodeForm(form *episodeForm) {
 
 	season, err := app.shows.GetSeason(form.SeasonId)
 	if err != nil {
-		app.errorLog.Printf("Error getting season for episode form validation: %s", err)
+		app.logger.Error("error getting season for episode form validation", "error", err, "season_id", form.SeasonId)
 		form.AddNonFieldError("Error getting season")
 	}
 
@@ -1,12 +1,10 @@
 package main
 
 import (
-	"errors"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 
@@ -21,17 +19,19 @@ type HomePage struct {
 	RecentSketches  []*views.SketchThumbnail
 	PopularSketches []*views.SketchThumbnail
 	Actors          []*models.Person
+	Shows           *views.ShowGallery
+	Recurring       *views.RecurringGallery
 }
 
 func (app *application) home(w http.ResponseWriter, r *http.Request) {
 
-	featured, err := app.sketches.GetFeatured()
-	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+	content, err := app.services.Home.GetHomeContent()
+	if err != nil {
 		app.serverError(r, w, err)
 		return
 	}
 
-	featuredSketchViews, err := views.FeaturedSketchesView(featured, app.baseImgUrl)
+	featuredSketchViews, err := views.FeaturedSketchesView(content.Featured, app.baseImgUrl)
 	if err != nil {
 		app.serverError(r, w, err)
 		return
@@ -65,59 +65,48 @@ func (app *application) home(w http.ResponseWriter, r *http.Request) {
 
 	popularSketchViews, err := views.SketchThumbnailsView(popularSketches, app.baseImgUrl, "", true)
 
-	peopleIds := []int{61, 52, 42, 1, 2, 3, 39, 54, 56}
-	// peopleIds := []int{5, 6, 7, 9, 2, 3, 1, 10, 4}
-	people, err := app.people.GetPeople(peopleIds)
+	showGallery, err := views.ShowRefGalleryView(content.Shows, app.baseImgUrl)
 	if err != nil {
 		app.serverError(r, w, err)
 		return
 	}
 
-	idIndex := make(map[int]int)
-	for i, id := range peopleIds {
-		idIndex[id] = i
+	recurringGallery, err := views.RecurringGalleryView(content.Recurring, app.baseImgUrl)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
 	}
 
-	// Sort items based on the order slice
-	sort.Slice(people, func(i, j int) bool {
-		return idIndex[safeDeref(people[i].ID)] < idIndex[safeDeref(people[j].ID)]
-	})
-
 	homePageData := HomePage{
 		Featured:        featuredSketchViews,
 		RecentSketches:  recentSketchView,
 		PopularSketches: popularSketchViews,
-		Actors:          people,
+		Actors:          content.People,
+		Shows:           showGallery,
+		Recurring:       recurringGallery,
 	}
 
 	data := app.newTemplateData(r)
 	data.Page = homePageData
 
-	for _, f := range data.Featured {
-		app.infoLog.Printf("%s\n", *f.Title)
-	}
-
 	app.render(r, w, http.StatusOK, "home.gohtml", "base", data)
 }
 
 func (app *application) browse(w http.ResponseWriter, r *http.Request) {
 
-	var sections []views.BrowseSectionDefinition
-	for _, def := range views.BrowseSectionDefinitions {
-		section := views.BrowseSectionDefinition{
-			Title:  def.Title,
-			Filter: def.Filter,
-		}
-
-		sketches, _, err := app.sketches.Get(&section.Filter)
-		if err != nil {
-			app.serverError(r, w, err)
-			return
-		}
-
-		section.Sketches = sketches
+	results, err := app.services.Browse.GetBrowseSections()
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
 
-		sections = append(sections, section)
+	var sections []views.BrowseSectionDefinition
+	for _, result := range results {
+		sections = append(sections, views.BrowseSectionDefinition{
+			Title:    safeDeref(result.Section.Title),
+			Filter:   *result.Section.Filter,
+			Sketches: result.Sketches,
+		})
 	}
 
 	browsePage, err := views.BrowsePageView(sections, app.baseImgUrl)
@@ -129,7 +118,7 @@ func (app *application) browse(w http.ResponseWriter, r *http.Request) {
 	data := app.newTemplateData(r)
 	data.Page = browsePage
 
-	app.infoLog.Printf("%+v\n", browsePage)
+	app.logger.Debug("browse page", "page", browsePage)
 
 	app.render(r, w, http.StatusOK, "browse.gohtml", "base", data)
 }
@@ -204,7 +193,7 @@ func (app *application) catalogView(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("HX-Push-Url", url)
 
 	if isHxRequest && !isHistoryRestore {
-		app.infoLog.Println("TARGET: ", r.Header.Get("HX-Target"))
+		app.logger.Debug("catalog htmx request", "target", r.Header.Get("HX-Target"))
 		if r.Header.Get("HX-Target") == "catalogSection" {
 			app.render(r, w, http.StatusOK, "sketch-catalog.gohtml", "sketch-catalog", sketchCatalog)
 		} else {
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sketchdb.cozycole.net/internal/domain/home"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type homeSlotInput struct {
+	EntityID *int       `json:"entityId"`
+	Position *int       `json:"position"`
+	StartsAt *time.Time `json:"startsAt"`
+	EndsAt   *time.Time `json:"endsAt"`
+}
+
+func (input *homeSlotInput) validate() map[string]string {
+	errs := map[string]string{}
+	if input.EntityID == nil || *input.EntityID < 1 {
+		errs["entityId"] = "entity id must be specified"
+	}
+
+	if input.Position != nil && *input.Position < 0 {
+		errs["position"] = "position cannot be negative"
+	}
+
+	if input.StartsAt != nil && input.EndsAt != nil && !input.EndsAt.After(*input.StartsAt) {
+		errs["endsAt"] = "end date must be after the start date"
+	}
+
+	return errs
+}
+
+func (app *application) listHomeSlotsAPI(w http.ResponseWriter, r *http.Request) {
+	section := r.URL.Query().Get("section")
+	if section != "" && !models.IsHomeSection(section) {
+		app.badRequestResponse(w, r, fmt.Errorf("unknown section %q", section))
+		return
+	}
+
+	slots, err := app.services.Home.ListSlots(section)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"slots": slots}, nil)
+}
+
+func (app *application) createHomeSlotAPI(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Section string `json:"section"`
+		homeSlotInput
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	errs := input.validate()
+	if !models.IsHomeSection(input.Section) {
+		errs["section"] = "section must be one of " + strings.Join(models.HomeSections, ", ")
+	}
+
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	slot := &models.HomeSlot{
+		Section:  &input.Section,
+		EntityID: input.EntityID,
+		Position: input.Position,
+		StartsAt: input.StartsAt,
+		EndsAt:   input.EndsAt,
+	}
+
+	err = app.services.Home.AddSlot(slot)
+	if err != nil {
+		if errors.Is(err, home.ErrEntityNotFound) {
+			app.failedValidationResponse(w, r, map[string]string{"entityId": "entity does not exist"})
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusCreated, envelope{"slot": slot}, nil)
+}
+
+func (app *application) updateHomeSlotAPI(w http.ResponseWriter, r *http.Request) {
+	slotId, err := strconv.Atoi(r.PathValue("slotId"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("slot id param not defined"))
+		return
+	}
+
+	slot, err := app.services.Home.GetSlot(slotId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	var input homeSlotInput
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if errs := input.validate(); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	slot.EntityID = input.EntityID
+	slot.StartsAt = input.StartsAt
+	slot.EndsAt = input.EndsAt
+	if input.Position != nil {
+		slot.Position = input.Position
+	}
+
+	err = app.services.Home.UpdateSlot(slot)
+	if err != nil {
+		switch {
+		case errors.Is(err, home.ErrEntityNotFound):
+			app.failedValidationResponse(w, r, map[string]string{"entityId": "entity does not exist"})
+		case errors.Is(err, models.ErrNoRecord):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"slot": slot}, nil)
+}
+
+func (app *application) deleteHomeSlotAPI(w http.ResponseWriter, r *http.Request) {
+	slotId, err := strconv.Atoi(r.PathValue("slotId"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("slot id param not defined"))
+		return
+	}
+
+	err = app.services.Home.DeleteSlot(slotId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) updateHomeSlotOrderAPI(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Section string `json:"section"`
+		SlotIds []int  `json:"slotIds"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if !models.IsHomeSection(input.Section) {
+		app.failedValidationResponse(w, r, map[string]string{
+			"section": "section must be one of " + strings.Join(models.HomeSections, ", "),
+		})
+		return
+	}
+
+	err = app.services.Home.ReorderSlots(input.Section, input.SlotIds)
+	if err != nil {
+		if errors.Is(err, home.ErrInvalidSlotOrder) {
+			app.failedValidationResponse(w, r, map[string]string{"slotIds": err.Error()})
+			return
+		}
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -33,7 +33,7 @@ const (
 func (app *application) deleteImage(prefix, imgName string) error {
 	for _, size := range []string{"small", "medium", "large"} {
 		imgSubPath := path.Join(prefix, size, imgName)
-		app.infoLog.Printf("Deleting %s\n", imgSubPath)
+		app.logger.Info("deleting image", "path", imgSubPath)
 		err := app.fileStorage.DeleteFile(imgSubPath)
 		if err != nil {
 			return err
@@ -11,6 +11,16 @@ import (
 	"sketchdb.cozycole.net/internal/utils"
 )
 
+// newTempStorage stores files under the OS temp dir, where the tests
+// read them back from
+func newTempStorage(t *testing.T) *fileStore.LocalStorage {
+	store, err := fileStore.NewLocalStorage(os.TempDir(), "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
 type Size struct {
 	Width  int
 	Height int
@@ -18,7 +28,7 @@ type Size struct {
 
 func TestSaveLargeThumbnail(t *testing.T) {
 	app := application{
-		fileStorage: &fileStore.FileStorage{RootPath: "/tmp"},
+		fileStorage: newTempStorage(t),
 	}
 
 	directoryName := "test-save-large-thumb"
@@ -102,7 +112,7 @@ func TestSaveLargeThumbnail(t *testing.T) {
 
 func TestSaveMediumThumbnail(t *testing.T) {
 	app := application{
-		fileStorage: &fileStore.FileStorage{RootPath: "/tmp"},
+		fileStorage: newTempStorage(t),
 	}
 
 	directoryName := "test-save-medium-thumb"
@@ -197,7 +207,7 @@ func TestSaveMediumThumbnail(t *testing.T) {
 
 func TestSaveLargeProfile(t *testing.T) {
 	app := application{
-		fileStorage: &fileStore.FileStorage{RootPath: "/tmp"},
+		fileStorage: newTempStorage(t),
 	}
 
 	directoryName := "test-save-large-profile"
@@ -280,7 +290,7 @@ func TestSaveLargeProfile(t *testing.T) {
 
 func TestSaveMediumProfile(t *testing.T) {
 	app := application{
-		fileStorage: &fileStore.FileStorage{RootPath: "/tmp"},
+		fileStorage: newTempStorage(t),
 	}
 
 	directoryName := "test-save-medium-profile"
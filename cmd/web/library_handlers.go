@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sketchdb.cozycole.net/cmd/web/views"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+const libraryPageSize = 24
+
+func (app *application) libraryFilter(r *http.Request, validSort func(string) bool) *models.Filter {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	sort := r.URL.Query().Get("sort")
+	if !validSort(sort) {
+		sort = "recent"
+	}
+
+	return &models.Filter{
+		Page:     page,
+		PageSize: libraryPageSize,
+		SortBy:   sort,
+	}
+}
+
+func (app *application) libraryRatings(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.libraryUser(w, r)
+	if !ok {
+		return
+	}
+
+	filter := app.libraryFilter(r, models.IsRatingSort)
+	result, err := app.services.Library.GetRatings(*user.ID, filter)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	page, err := views.RatingsLibraryView(result, app.baseImgUrl)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.renderLibrary(w, r, page)
+}
+
+func (app *application) libraryLikedSketches(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.libraryUser(w, r)
+	if !ok {
+		return
+	}
+
+	filter := app.libraryFilter(r, models.IsLibrarySort)
+	result, err := app.services.Library.GetLikedSketches(*user.ID, filter)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	page, err := views.LikedSketchesLibraryView(result, app.baseImgUrl)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.renderLibrary(w, r, page)
+}
+
+func (app *application) libraryLikedQuotes(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.libraryUser(w, r)
+	if !ok {
+		return
+	}
+
+	filter := app.libraryFilter(r, models.IsLibrarySort)
+	result, err := app.services.Library.GetLikedQuotes(*user.ID, filter)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	page, err := views.LikedQuotesLibraryView(result, app.baseImgUrl)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	app.renderLibrary(w, r, page)
+}
+
+// libraryExport downloads all of the user's ratings and likes as json
+// (the default) or csv
+func (app *application) libraryExport(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.libraryUser(w, r)
+	if !ok {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+
+	if format != "json" && format != "csv" {
+		app.badRequest(w)
+		return
+	}
+
+	export, err := app.services.Library.Export(user)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	filename := fmt.Sprintf(
+		"sketchdb-%s-%s.%s",
+		safeDeref(user.Username), export.ExportedAt.Format(time.DateOnly), format,
+	)
+
+	headers := http.Header{}
+	headers.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "json" {
+		err = app.writeJSON(w, http.StatusOK, envelope{"library": export}, headers)
+		if err != nil {
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	maps.Insert(w.Header(), maps.All(headers))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	err = export.WriteCSV(w)
+	if err != nil {
+		// headers are already sent, all that can be done is log it
+		app.logError(r, fmt.Errorf("library csv export for user %d: %w", safeDeref(user.ID), err))
+	}
+}
+
+func (app *application) libraryUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, ok := r.Context().Value(userContextKey).(*models.User)
+	if !ok || user.ID == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (app *application) renderLibrary(w http.ResponseWriter, r *http.Request, page *views.LibraryPage) {
+	data := app.newTemplateData(r)
+	data.Page = page
+	app.render(r, w, http.StatusOK, "library.gohtml", "base", data)
+}
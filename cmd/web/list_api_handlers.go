@@ -0,0 +1,332 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"sketchdb.cozycole.net/internal/domain/lists"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type listInput struct {
+	Title       string  `json:"title"`
+	Description *string `json:"description"`
+	Visibility  string  `json:"visibility"`
+}
+
+func (input *listInput) validate() map[string]string {
+	errs := map[string]string{}
+	input.Title = strings.TrimSpace(input.Title)
+	if input.Title == "" {
+		errs["title"] = "title must be specified"
+	} else if utf8.RuneCountInString(input.Title) > lists.MaxTitleLength {
+		errs["title"] = fmt.Sprintf("title cannot be more than %d characters", lists.MaxTitleLength)
+	}
+
+	if input.Visibility == "" {
+		input.Visibility = models.ListPrivate
+	} else if !models.IsListVisibility(input.Visibility) {
+		errs["visibility"] = "visibility must be public, private or unlisted"
+	}
+
+	return errs
+}
+
+func validateNote(note *string) map[string]string {
+	errs := map[string]string{}
+	if note != nil && utf8.RuneCountInString(*note) > lists.MaxNoteLength {
+		errs["note"] = fmt.Sprintf("note cannot be more than %d characters", lists.MaxNoteLength)
+	}
+	return errs
+}
+
+func (app *application) listMyListsAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.listUser(w, r)
+	if !ok {
+		return
+	}
+
+	sketchLists, err := app.services.Lists.GetUserLists(user, user)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"lists": sketchLists}, nil)
+}
+
+func (app *application) getListAPI(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.readSketchList(w, r, false)
+	if !ok {
+		return
+	}
+
+	items, err := app.services.Lists.GetItems(*list.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"list": list, "items": items}, nil)
+}
+
+func (app *application) createListAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.listUser(w, r)
+	if !ok {
+		return
+	}
+
+	var input listInput
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if errs := input.validate(); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	list := &models.SketchList{
+		Title:       &input.Title,
+		Description: input.Description,
+		Visibility:  &input.Visibility,
+	}
+
+	err = app.services.Lists.CreateList(user, list)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusCreated, envelope{"list": list}, nil)
+}
+
+func (app *application) updateListAPI(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.readSketchList(w, r, true)
+	if !ok {
+		return
+	}
+
+	var input listInput
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if errs := input.validate(); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	list.Title = &input.Title
+	list.Description = input.Description
+	list.Visibility = &input.Visibility
+
+	err = app.services.Lists.UpdateList(list)
+	if err != nil {
+		app.listError(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"list": list}, nil)
+}
+
+func (app *application) deleteListAPI(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.readSketchList(w, r, true)
+	if !ok {
+		return
+	}
+
+	err := app.services.Lists.DeleteList(*list.ID)
+	if err != nil {
+		app.listError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) addListItemAPI(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.readSketchList(w, r, true)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		SketchID int     `json:"sketchId"`
+		Note     *string `json:"note"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	errs := validateNote(input.Note)
+	if input.SketchID < 1 {
+		errs["sketchId"] = "sketch id must be specified"
+	}
+
+	if len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	item := &models.SketchListItem{
+		ListID:   list.ID,
+		SketchID: &input.SketchID,
+		Note:     input.Note,
+	}
+
+	err = app.services.Lists.AddItem(item)
+	if err != nil {
+		app.listError(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusCreated, envelope{"item": item}, nil)
+}
+
+func (app *application) updateListItemAPI(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.readSketchList(w, r, true)
+	if !ok {
+		return
+	}
+
+	sketchId, err := strconv.Atoi(r.PathValue("sketchId"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("sketch id param not defined"))
+		return
+	}
+
+	var input struct {
+		Note *string `json:"note"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if errs := validateNote(input.Note); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	err = app.services.Lists.UpdateItemNote(*list.ID, sketchId, input.Note)
+	if err != nil {
+		app.listError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) deleteListItemAPI(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.readSketchList(w, r, true)
+	if !ok {
+		return
+	}
+
+	sketchId, err := strconv.Atoi(r.PathValue("sketchId"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("sketch id param not defined"))
+		return
+	}
+
+	err = app.services.Lists.RemoveItem(*list.ID, sketchId)
+	if err != nil {
+		app.listError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) updateListItemOrderAPI(w http.ResponseWriter, r *http.Request) {
+	list, ok := app.readSketchList(w, r, true)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		SketchIds []int `json:"sketchIds"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err = app.services.Lists.ReorderItems(*list.ID, input.SketchIds)
+	if err != nil {
+		app.listError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) listUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, ok := r.Context().Value(userContextKey).(*models.User)
+	if !ok || user.ID == nil {
+		app.errorResponse(w, r, http.StatusUnauthorized, "please sign in to manage lists")
+		return nil, false
+	}
+
+	return user, true
+}
+
+// readSketchList loads the list in the path. Lists the user can't view by
+// id are reported as not found, and ownerOnly rejects anyone but the owner.
+func (app *application) readSketchList(w http.ResponseWriter, r *http.Request, ownerOnly bool) (*models.SketchList, bool) {
+	listId, err := strconv.Atoi(r.PathValue("listId"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("list id param not defined"))
+		return nil, false
+	}
+
+	list, err := app.services.Lists.GetList(listId)
+	if err != nil {
+		app.listError(w, r, err)
+		return nil, false
+	}
+
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	if !lists.CanViewById(list, user) {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	if ownerOnly && !lists.IsOwner(list, user) {
+		app.errorResponse(w, r, http.StatusForbidden, "only the owner can change a list")
+		return nil, false
+	}
+
+	return list, true
+}
+
+func (app *application) listError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, models.ErrNoRecord):
+		app.notFoundResponse(w, r)
+	case errors.Is(err, models.ErrNoSketch):
+		app.failedValidationResponse(w, r, map[string]string{"sketchId": "sketch does not exist"})
+	case errors.Is(err, models.ErrDuplicateListItem):
+		app.failedValidationResponse(w, r, map[string]string{"sketchId": "sketch is already in the list"})
+	case errors.Is(err, lists.ErrInvalidItemOrder):
+		app.failedValidationResponse(w, r, map[string]string{"sketchIds": err.Error()})
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
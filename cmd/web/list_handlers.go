@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"sketchdb.cozycole.net/cmd/web/views"
+	"sketchdb.cozycole.net/internal/domain/lists"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+func (app *application) userLists(w http.ResponseWriter, r *http.Request) {
+	user, err := app.users.GetByUsername(r.PathValue("username"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	viewer, _ := r.Context().Value(userContextKey).(*models.User)
+	sketchLists, err := app.services.Lists.GetUserLists(user, viewer)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	isOwner := viewer != nil && safeDeref(viewer.ID) == safeDeref(user.ID)
+
+	data := app.newTemplateData(r)
+	data.Page = views.UserListsView(user, sketchLists, isOwner)
+	app.render(r, w, http.StatusOK, "user-lists.gohtml", "base", data)
+}
+
+func (app *application) userListView(w http.ResponseWriter, r *http.Request) {
+	list, err := app.services.Lists.GetListBySlug(r.PathValue("slug"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFound(w)
+		} else {
+			app.serverError(r, w, err)
+		}
+		return
+	}
+
+	viewer, _ := r.Context().Value(userContextKey).(*models.User)
+	if safeDeref(list.Username) != r.PathValue("username") || !lists.CanView(list, viewer) {
+		app.notFound(w)
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	sort := r.URL.Query().Get("sort")
+	if !models.IsSortOption(sort) {
+		sort = models.ListSort
+	}
+
+	filter := &models.Filter{
+		Page:     page,
+		PageSize: app.settings.pageSize,
+		SortBy:   sort,
+	}
+
+	result, err := app.services.Lists.GetListSketches(list, filter)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	listPage, err := views.ListPageView(result, lists.IsOwner(list, viewer), app.baseImgUrl)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Page = listPage
+	app.render(r, w, http.StatusOK, "view-list.gohtml", "base", data)
+}
@@ -5,9 +5,13 @@ import (
 	"encoding/json"
 	"flag"
 	"html/template"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/alexedwards/scs/pgxstore"
@@ -20,25 +24,38 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 
+	"sketchdb.cozycole.net/internal/domain/accounts"
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/domain/browse"
 	"sketchdb.cozycole.net/internal/domain/casts"
 	"sketchdb.cozycole.net/internal/domain/characters"
 	"sketchdb.cozycole.net/internal/domain/creators"
+	"sketchdb.cozycole.net/internal/domain/home"
+	"sketchdb.cozycole.net/internal/domain/library"
+	"sketchdb.cozycole.net/internal/domain/lists"
 	"sketchdb.cozycole.net/internal/domain/people"
 	"sketchdb.cozycole.net/internal/domain/pipeline"
+	"sketchdb.cozycole.net/internal/domain/popularity"
 	"sketchdb.cozycole.net/internal/domain/quotes"
 	"sketchdb.cozycole.net/internal/domain/recurring"
 	"sketchdb.cozycole.net/internal/domain/series"
 	"sketchdb.cozycole.net/internal/domain/shows"
 	"sketchdb.cozycole.net/internal/domain/sketches"
 	"sketchdb.cozycole.net/internal/domain/tags"
+	"sketchdb.cozycole.net/internal/domain/wiki"
 
+	"sketchdb.cozycole.net/internal/external/moviedb"
+	"sketchdb.cozycole.net/internal/external/wikipedia"
 	"sketchdb.cozycole.net/internal/fileStore"
+	"sketchdb.cozycole.net/internal/mailer"
+	"sketchdb.cozycole.net/internal/metrics"
 	"sketchdb.cozycole.net/internal/models"
+	"sketchdb.cozycole.net/internal/ratelimit"
 )
 
 type application struct {
-	errorLog       *log.Logger
-	infoLog        *log.Logger
+	logger         *slog.Logger
+	metrics        *httpMetrics
 	templateCache  map[string]*template.Template
 	fileStorage    fileStore.FileStorageInterface
 	archiveStorage fileStore.FileStorageInterface
@@ -53,11 +70,13 @@ type application struct {
 	recurring      models.RecurringModelInterface
 	shows          models.ShowModelInterface
 	series         models.SeriesModelInterface
+	slugs          models.SlugHistoryModelInterface
 	tags           models.TagModelInterface
 	tokens         models.TokenModelInterface
 	users          models.UserModelInterface
 	sketches       models.SketchModelInterface
 	services       Services
+	wikiRefresher  *wiki.Refresher
 	sessionManager *scs.SessionManager
 	debugMode      bool
 	formDecoder    *form.Decoder
@@ -70,10 +89,30 @@ type settings struct {
 	maxSearchResults  int
 	localImageServer  bool
 	localImageStorage bool
+	localImagePrefix  string
+	localStoragePath  string
 	devEnv            bool
 	origin            string
+	rateLimits        rateLimitSettings
 }
 
+// rateLimitSettings are the request limits of each group of routes
+type rateLimitSettings struct {
+	enabled        bool
+	trustedProxies ratelimit.TrustedProxies
+	// login, signup and account emails, keyed by IP
+	auth ratelimit.Limit
+	// search endpoints, which hit the database hardest
+	search ratelimit.Limit
+	// the public JSON API
+	api ratelimit.Limit
+	// likes, ratings and lists
+	userWrite ratelimit.Limit
+}
+
+// presigned upload urls of local storage point here
+const localUploadPath = "/local-upload"
+
 var StaticAssets = map[string]string{
 	"css": "styles.css",
 	"js":  "main.js",
@@ -86,27 +125,51 @@ func main() {
 	localImgServer := flag.Bool("localimg", false, "serve images from local directory")
 	localImgStorage := flag.Bool("localstorage", false, "store/delete images in local directory")
 	serveStatic := flag.Bool("serve-static", false, "serve css, js and images")
+	pipelineWorkers := flag.Int("pipeline-workers", 0, "number of pipeline job workers to run (0 disables)")
+	pipelineCmd := flag.String("pipeline-cmd", "", "command executed for each pipeline job")
+	pipelineAttempts := flag.Int("pipeline-max-attempts", 5, "attempts before a pipeline job is marked failed")
+	pipelineTimeout := flag.Duration("pipeline-timeout", 2*time.Hour, "max run time of a single pipeline job")
+	archiveInterval := flag.Duration("archive-interval", 0, "interval between video archival runs (0 disables)")
+	archiveRetention := flag.Duration("archive-retention", 30*24*time.Hour, "how long archived videos are kept in hot storage")
+	ffprobePath := flag.String("ffprobe", "ffprobe", "ffprobe binary used to inspect uploaded videos")
+	mediaCleanup := flag.Duration("media-cleanup-interval", time.Minute, "interval between runs deleting queued storage objects (0 disables)")
+	uploadExpiry := flag.Duration("upload-expiry", 48*time.Hour, "inactive multipart uploads are aborted after this long (0 disables)")
+	popularityInterval := flag.Duration("popularity-interval", 6*time.Hour, "interval between popularity score recomputes (0 disables)")
+	wikiRefresh := flag.Duration("wiki-refresh", 0, "interval between wikipedia extract refreshes (0 disables)")
+	rateLimit := flag.Bool("ratelimit", true, "rate limit auth, search, API and user write requests")
+	metricsAddr := flag.String("metrics-addr", "", "network address /metrics is served on, keep it private (empty disables)")
+	trustedProxies := flag.String("trusted-proxies", "127.0.0.1,::1", "comma separated IPs/CIDRs of proxies whose X-Forwarded-For is trusted")
 
 	flag.Parse()
 
+	logLevel := slog.LevelInfo
+	if *debug || *dev {
+		logLevel = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
+	slog.SetDefault(logger)
+
+	// background workers and the http server log through a *log.Logger
+	infoLog := slog.NewLogLogger(logger.Handler(), slog.LevelInfo)
+	errorLog := slog.NewLogLogger(logger.Handler(), slog.LevelError)
+
 	err := godotenv.Load()
 	if err != nil && *dev {
-		log.Fatal("Error loading .env file")
+		logger.Error("error loading .env file", "error", err)
+		os.Exit(1)
 	}
 
-	infoLog := log.New(os.Stdout, "INFO\t", log.Ldate|log.Ltime)
-	errorLog := log.New(os.Stderr, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile)
-
 	var dbUrl, imgStoragePath, imgBaseUrl, origin string
 	var fileStorage, archiveStorage fileStore.FileStorageInterface
 	if *dev {
 		*debug = true
 		*serveStatic = true
 
-		infoLog.Println("Dev env selected, debug mode set")
+		logger.Info("dev env selected, debug mode set")
 
 		dbUrl = os.Getenv("DEV_DB_URL")
 		imgBaseUrl = os.Getenv("DEV_IMG_URL")
+		imgStoragePath = os.Getenv("DEV_IMG_DISK_STORAGE")
 		origin = os.Getenv("DEV_ORIGIN")
 
 		// set paths for serving js and css
@@ -134,14 +197,16 @@ func main() {
 		}
 
 	} else {
-		infoLog.Println("Production env selected")
+		logger.Info("production env selected")
 		dbUrl = os.Getenv("DB_URL")
 		imgBaseUrl = os.Getenv("IMG_URL")
+		imgStoragePath = os.Getenv("IMG_DISK_STORAGE")
 		origin = os.Getenv("ORIGIN")
 
 		err = loadAssets()
 		if err != nil {
-			log.Fatal("Error loading manifest found in production build")
+			logger.Error("error loading manifest found in production build", "error", err)
+			os.Exit(1)
 		}
 		client := S3Client(
 			os.Getenv("S3_ENDPOINT"),
@@ -166,16 +231,58 @@ func main() {
 	}
 
 	if dbUrl == "" {
-		errorLog.Fatal("Database URL not defined")
+		logger.Error("database URL not defined")
+		os.Exit(1)
+	}
+
+	if imgStoragePath == "" && (*localImgStorage || *localImgServer) {
+		logger.Error("storage path not defined")
+		os.Exit(1)
+	}
+
+	if *localImgStorage {
+		secret := []byte(os.Getenv("LOCAL_STORAGE_SECRET"))
+		localStorage, err := fileStore.NewLocalStorage(imgStoragePath, origin+localUploadPath, secret)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		fileStorage = localStorage
+
+		archivePath := strings.TrimRight(imgStoragePath, "/") + "-archive"
+		archiveStorage, err = fileStore.NewLocalStorage(archivePath, "", secret)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+		logger.Info("storing files locally", "path", imgStoragePath, "archive", archivePath)
+	}
+
+	var localImagePrefix string
+	if *localImgServer {
+		u, err := url.Parse(imgBaseUrl)
+		if err != nil || strings.Trim(u.Path, "/") == "" {
+			logger.Error("serving images locally requires an image URL with a path, e.g. http://localhost:8080/img")
+			os.Exit(1)
+		}
+		localImagePrefix = "/" + strings.Trim(u.Path, "/")
 	}
 
-	if imgStoragePath == "" && *localImgStorage {
-		errorLog.Fatal("Storage path not defined")
+	if *pipelineWorkers > 0 && *pipelineCmd == "" {
+		logger.Error("pipeline workers enabled but no -pipeline-cmd defined")
+		os.Exit(1)
+	}
+
+	proxies, err := ratelimit.ParseTrustedProxies(*trustedProxies)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
 
 	dbpool, err := openDB(dbUrl)
 	if err != nil {
-		errorLog.Fatal(err)
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
 	defer dbpool.Close()
 
@@ -186,13 +293,13 @@ func main() {
 
 	templateCache, err := newTemplateCache()
 	if err != nil {
-		errorLog.Fatal(err)
+		logger.Error(err.Error())
+		os.Exit(1)
 	}
 
 	formDecoder := form.NewDecoder()
 	app := &application{
-		errorLog:       errorLog,
-		infoLog:        infoLog,
+		logger:         logger,
 		templateCache:  templateCache,
 		formDecoder:    formDecoder,
 		fileStorage:    fileStorage,
@@ -208,6 +315,7 @@ func main() {
 		series:         &models.SeriesModel{DB: dbpool},
 		shows:          &models.ShowModel{DB: dbpool},
 		sketches:       &models.SketchModel{DB: dbpool},
+		slugs:          &models.SlugHistoryModel{DB: dbpool},
 		tags:           &models.TagModel{DB: dbpool},
 		tokens:         &models.TokenModel{DB: dbpool},
 		users:          &models.UserModel{DB: dbpool},
@@ -221,11 +329,167 @@ func main() {
 			maxSearchResults:  12,
 			localImageServer:  *localImgServer,
 			localImageStorage: *localImgStorage,
+			localImagePrefix:  localImagePrefix,
+			localStoragePath:  imgStoragePath,
 			origin:            origin,
 			devEnv:            *dev,
+			rateLimits: rateLimitSettings{
+				enabled:        *rateLimit,
+				trustedProxies: proxies,
+				auth:           ratelimit.PerMinute(10, 5),
+				search:         ratelimit.Limit{Rate: 5, Burst: 20},
+				api:            ratelimit.Limit{Rate: 10, Burst: 40},
+				userWrite:      ratelimit.Limit{Rate: 2, Burst: 20},
+			},
 		},
 	}
-	app.infoLog.Println("ORIGIN: ", origin)
+	logger.Info("origin set", "origin", origin)
+
+	if token := os.Getenv("TMDB_TOKEN"); token != "" {
+		app.services.People.TMDb = moviedb.NewTMDbClient(token)
+	} else {
+		logger.Info("TMDB_TOKEN not defined, TMDb lookups disabled")
+	}
+
+	mailSender := os.Getenv("SMTP_SENDER")
+	if mailSender == "" {
+		mailSender = "SketchDB <no-reply@sketchdb.cozycole.net>"
+	}
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil {
+			port = 587
+		}
+		app.services.Accounts.Mailer = &mailer.SMTPMailer{
+			Host:     host,
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			Sender:   mailSender,
+		}
+	} else {
+		app.services.Accounts.Mailer = &mailer.FileMailer{
+			Dir:    os.Getenv("MAIL_DIR"),
+			Sender: mailSender,
+			Log:    infoLog,
+		}
+		logger.Info("SMTP_HOST not defined, emails are written to MAIL_DIR or the log")
+	}
+	app.services.Accounts.Origin = origin
+
+	if path, err := exec.LookPath(*ffprobePath); err == nil {
+		app.services.Sketches.Prober.FFProbePath = path
+	} else {
+		logger.Info("ffprobe not found, probing videos with the built in parser", "path", *ffprobePath)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if *pipelineWorkers > 0 {
+		worker := &pipeline.Worker{
+			Service:      &app.services.Pipeline,
+			Processor:    &pipeline.CommandProcessor{Command: *pipelineCmd},
+			Concurrency:  *pipelineWorkers,
+			PollInterval: 5 * time.Second,
+			JobTimeout:   *pipelineTimeout,
+			MaxAttempts:  *pipelineAttempts,
+			BaseBackoff:  30 * time.Second,
+			MaxBackoff:   time.Hour,
+			InfoLog:      infoLog,
+			ErrorLog:     errorLog,
+		}
+		go worker.Run(ctx)
+	}
+
+	if *archiveInterval > 0 {
+		archiver := &sketches.Archiver{
+			Service:   &app.services.Sketches,
+			Interval:  *archiveInterval,
+			Retention: *archiveRetention,
+			BatchSize: 10,
+			InfoLog:   infoLog,
+			ErrorLog:  errorLog,
+		}
+		go archiver.Run(ctx)
+	}
+
+	if *mediaCleanup > 0 {
+		cleaner := &sketches.MediaCleaner{
+			Service:     &app.services.Sketches,
+			Interval:    *mediaCleanup,
+			BatchSize:   100,
+			Lease:       10 * time.Minute,
+			BaseBackoff: time.Minute,
+			MaxBackoff:  6 * time.Hour,
+			InfoLog:     infoLog,
+			ErrorLog:    errorLog,
+		}
+		go cleaner.Run(ctx)
+	}
+
+	if *uploadExpiry > 0 {
+		sweeper := &sketches.UploadSweeper{
+			Service:  &app.services.Sketches,
+			Interval: time.Hour,
+			MaxAge:   *uploadExpiry,
+			InfoLog:  infoLog,
+			ErrorLog: errorLog,
+		}
+		go sweeper.Run(ctx)
+	}
+
+	if *popularityInterval > 0 {
+		scheduler := &popularity.Scheduler{
+			Service:  &app.services.Popularity,
+			Interval: *popularityInterval,
+			InfoLog:  infoLog,
+			ErrorLog: errorLog,
+		}
+		go scheduler.Run(ctx)
+	}
+
+	// the refresher is also started on demand by refreshWikiAPI
+	app.wikiRefresher = &wiki.Refresher{
+		Service:  &app.services.Wiki,
+		Interval: *wikiRefresh,
+		InfoLog:  infoLog,
+		ErrorLog: errorLog,
+	}
+	if *wikiRefresh > 0 {
+		go app.wikiRefresher.Run(ctx)
+	}
+
+	if *metricsAddr != "" {
+		reg := metrics.Default
+		app.metrics = newHTTPMetrics(reg)
+		registerPoolMetrics(reg, dbpool)
+		app.registerPipelineMetrics(reg)
+		app.registerMediaCleanupMetrics(reg)
+
+		if s3, ok := fileStorage.(*fileStore.S3Storage); ok {
+			s3.Instrument("media")
+		}
+		if s3, ok := archiveStorage.(*fileStore.S3Storage); ok {
+			s3.Instrument("archive")
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", reg.Handler())
+		metricsSrv := &http.Server{
+			Addr:     *metricsAddr,
+			ErrorLog: errorLog,
+			Handler:  mux,
+		}
+
+		go func() {
+			logger.Info("starting metrics server", "addr", *metricsAddr)
+			if err := metricsSrv.ListenAndServe(); err != nil {
+				logger.Error("metrics server stopped", "error", err)
+			}
+		}()
+	}
 
 	srv := &http.Server{
 		Addr:     *addr,
@@ -233,9 +497,10 @@ func main() {
 		Handler:  app.routes("./ui/static/", *serveStatic),
 	}
 
-	infoLog.Println("Starting server on", *addr)
+	logger.Info("starting server", "addr", *addr)
 	err = srv.ListenAndServe()
-	errorLog.Fatal(err)
+	logger.Error(err.Error())
+	os.Exit(1)
 }
 
 func openDB(dsn string) (*pgxpool.Pool, error) {
@@ -265,14 +530,14 @@ func S3Client(endpoint, key, secret string) *s3.S3 {
 func loadAssets() error {
 	f, err := os.Open("./dist/manifest.json")
 	if err != nil {
-		log.Println("No asset manifest found — using default asset names")
+		slog.Warn("no asset manifest found, using default asset names", "error", err)
 		return err
 	}
 	defer f.Close()
 
 	manifest := map[string]string{}
 	if err := json.NewDecoder(f).Decode(&manifest); err != nil {
-		log.Printf("Failed to parse manifest.json: %v — using default asset names", err)
+		slog.Warn("failed to parse manifest.json, using default asset names", "error", err)
 		return err
 	}
 
@@ -285,35 +550,54 @@ func loadAssets() error {
 
 func newRepositories(dbpool *pgxpool.Pool) models.Repositories {
 	return models.Repositories{
-		Cast:       &models.CastModel{DB: dbpool},
-		Categories: &models.CategoryModel{DB: dbpool},
-		Characters: &models.CharacterModel{DB: dbpool},
-		Creators:   &models.CreatorModel{DB: dbpool},
-		Quotes:     &models.QuoteModel{DB: dbpool},
-		People:     &models.PersonModel{DB: dbpool},
-		Profile:    &models.ProfileModel{DB: dbpool},
-		Pipeline:   &models.PipelineModel{DB: dbpool},
-		Recurring:  &models.RecurringModel{DB: dbpool},
-		Shows:      &models.ShowModel{DB: dbpool},
-		Tags:       &models.TagModel{DB: dbpool},
-		Users:      &models.UserModel{DB: dbpool},
-		Sketches:   &models.SketchModel{DB: dbpool},
-		Series:     &models.SeriesModel{DB: dbpool},
+		Audit:          &models.AuditModel{DB: dbpool},
+		BrowseSections: &models.BrowseSectionModel{DB: dbpool},
+		Cast:           &models.CastModel{DB: dbpool},
+		Categories:     &models.CategoryModel{DB: dbpool},
+		Characters:     &models.CharacterModel{DB: dbpool},
+		Creators:       &models.CreatorModel{DB: dbpool},
+		Quotes:         &models.QuoteModel{DB: dbpool},
+		Library:        &models.LibraryModel{DB: dbpool},
+		MediaDeletions: &models.MediaDeletionModel{DB: dbpool},
+		People:         &models.PersonModel{DB: dbpool},
+		HomeSlots:      &models.HomeSlotModel{DB: dbpool},
+		Profile:        &models.ProfileModel{DB: dbpool},
+		Pipeline:       &models.PipelineModel{DB: dbpool},
+		Popularity:     &models.PopularityModel{DB: dbpool},
+		Recurring:      &models.RecurringModel{DB: dbpool},
+		Shows:          &models.ShowModel{DB: dbpool},
+		Tags:           &models.TagModel{DB: dbpool},
+		Tokens:         &models.TokenModel{DB: dbpool},
+		UserTokens:     &models.UserTokenModel{DB: dbpool},
+		Users:          &models.UserModel{DB: dbpool},
+		SketchLists:    &models.SketchListModel{DB: dbpool},
+		Sketches:       &models.SketchModel{DB: dbpool},
+		Series:         &models.SeriesModel{DB: dbpool},
+		VideoUploads:   &models.VideoUploadModel{DB: dbpool},
+		Wiki:           &models.WikiModel{DB: dbpool},
 	}
 }
 
 type Services struct {
+	Accounts   accounts.AccountService
+	Audit      audit.AuditService
+	Browse     browse.BrowseService
 	Casts      casts.CastService
 	Characters characters.CharacterService
 	Creators   creators.CreatorService
+	Home       home.HomeService
+	Library    library.LibraryService
+	Lists      lists.ListService
 	People     people.PersonService
 	Pipeline   pipeline.PipelineService
+	Popularity popularity.PopularityService
 	Quotes     quotes.QuoteService
 	Recurring  recurring.RecurringService
 	Series     series.SeriesService
 	Shows      shows.ShowService
 	Sketches   sketches.SketchService
 	Tags       tags.TagsService
+	Wiki       wiki.WikiService
 }
 
 func NewServices(
@@ -322,6 +606,15 @@ func NewServices(
 	archiveStore fileStore.FileStorageInterface,
 ) Services {
 	return Services{
+		Accounts: accounts.AccountService{
+			Repos: repos,
+		},
+		Audit: audit.AuditService{
+			Repos: repos,
+		},
+		Browse: browse.BrowseService{
+			Repos: repos,
+		},
 		Sketches: sketches.SketchService{
 			Repos:        repos,
 			ImgStore:     fileStore,
@@ -367,5 +660,23 @@ func NewServices(
 			Repos:    repos,
 			ImgStore: fileStore,
 		},
+		Home: home.HomeService{
+			Repos: repos,
+		},
+		Library: library.LibraryService{
+			Repos: repos,
+		},
+		Lists: lists.ListService{
+			Repos: repos,
+		},
+		Popularity: popularity.PopularityService{
+			Repos:   repos,
+			Weights: popularity.DefaultWeights,
+		},
+		Wiki: wiki.WikiService{
+			Repos:  repos,
+			Client: wikipedia.NewClient(nil),
+			MaxAge: 7 * 24 * time.Hour,
+		},
 	}
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"sketchdb.cozycole.net/internal/domain/shared"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type mergeInput struct {
+	IntoID          int  `json:"intoId"`
+	KeepMergedImage bool `json:"keepMergedImage"`
+}
+
+func (in mergeInput) options(r *http.Request) shared.MergeOptions {
+	return shared.MergeOptions{
+		KeepMergedImage: in.KeepMergedImage,
+		DryRun:          r.URL.Query().Get("dry_run") == "true",
+	}
+}
+
+func (app *application) readMergeInput(w http.ResponseWriter, r *http.Request) (int, mergeInput, bool) {
+	var input mergeInput
+
+	fromId, err := app.readIDParam(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return 0, input, false
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return 0, input, false
+	}
+
+	if input.IntoID < 1 {
+		app.failedValidationResponse(w, r, map[string]string{"intoId": "must be provided"})
+		return 0, input, false
+	}
+
+	return fromId, input, true
+}
+
+// mergeErrorResponse reports a failed merge. Once the merge is committed
+// only removing the unused image can fail, the rows have been merged so
+// that's reported as a server error with the merge kept.
+func (app *application) mergeErrorResponse(w http.ResponseWriter, r *http.Request, err error, merged bool) {
+	switch {
+	case merged:
+		app.serverErrorResponse(w, r, fmt.Errorf("merged but not cleaned up: %w", err))
+	case errors.Is(err, models.ErrNoRecord):
+		app.notFoundResponse(w, r)
+	case errors.Is(err, shared.ErrSelfMerge):
+		app.failedValidationResponse(w, r, map[string]string{"intoId": "must be a different row"})
+	default:
+		app.serverErrorResponse(w, r, err)
+	}
+}
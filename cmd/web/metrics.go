@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"sketchdb.cozycole.net/internal/metrics"
+)
+
+type httpMetrics struct {
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+	inFlight *metrics.GaugeVec
+}
+
+func newHTTPMetrics(reg *metrics.Registry) *httpMetrics {
+	return &httpMetrics{
+		requests: reg.NewCounterVec(
+			"sketchdb_http_requests_total",
+			"HTTP requests served by route pattern, method and status code.",
+			"route", "method", "status",
+		),
+		duration: reg.NewHistogramVec(
+			"sketchdb_http_request_duration_seconds",
+			"Time taken to serve HTTP requests by route pattern and method.",
+			metrics.DefaultBuckets,
+			"route", "method",
+		),
+		inFlight: reg.NewGaugeVec(
+			"sketchdb_http_requests_in_flight",
+			"HTTP requests currently being served.",
+		),
+	}
+}
+
+// recordMetrics counts and times requests by chi route pattern, so ids in
+// the path don't create a series per entity
+func (app *application) recordMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.metrics == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		app.metrics.inFlight.Inc()
+		defer app.metrics.inFlight.Dec()
+
+		next.ServeHTTP(ww, r)
+
+		route := routePattern(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		app.metrics.requests.Inc(route, r.Method, strconv.Itoa(status))
+		app.metrics.duration.Observe(time.Since(start).Seconds(), route, r.Method)
+	})
+}
+
+// routePattern returns the chi pattern that matched the request, it's
+// only complete once the request has been routed
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+}
+
+// registerPoolMetrics exports the database connection pool's stats
+func registerPoolMetrics(reg *metrics.Registry, pool *pgxpool.Pool) {
+	gauges := []struct {
+		name, help string
+		value      func(*pgxpool.Stat) float64
+	}{
+		{"sketchdb_db_pool_total_conns", "Open connections in the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }},
+		{"sketchdb_db_pool_acquired_conns", "Connections currently in use.",
+			func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }},
+		{"sketchdb_db_pool_idle_conns", "Idle connections in the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }},
+		{"sketchdb_db_pool_constructing_conns", "Connections being opened.",
+			func(s *pgxpool.Stat) float64 { return float64(s.ConstructingConns()) }},
+		{"sketchdb_db_pool_max_conns", "Maximum size of the pool.",
+			func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }},
+	}
+	for _, g := range gauges {
+		value := g.value
+		reg.NewGaugeFunc(g.name, g.help, func() float64 { return value(pool.Stat()) })
+	}
+
+	counters := []struct {
+		name, help string
+		value      func(*pgxpool.Stat) float64
+	}{
+		{"sketchdb_db_pool_acquires_total", "Successful connection acquires.",
+			func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }},
+		{"sketchdb_db_pool_acquire_seconds_total", "Total time spent acquiring connections.",
+			func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }},
+		{"sketchdb_db_pool_empty_acquires_total", "Acquires that had to wait for a connection.",
+			func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }},
+		{"sketchdb_db_pool_canceled_acquires_total", "Acquires canceled before getting a connection.",
+			func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) }},
+	}
+	for _, c := range counters {
+		value := c.value
+		reg.NewCounterFunc(c.name, c.help, func() float64 { return value(pool.Stat()) })
+	}
+}
+
+// registerPipelineMetrics exports the number of pipeline jobs in each
+// status, counted when scraped
+func (app *application) registerPipelineMetrics(reg *metrics.Registry) {
+	reg.NewGaugeCollector(
+		"sketchdb_pipeline_jobs",
+		"Video pipeline jobs by status.",
+		[]string{"status"},
+		func() ([]metrics.Sample, error) {
+			counts, err := app.services.Pipeline.JobCounts()
+			if err != nil {
+				app.logger.Error("counting pipeline jobs for metrics", "error", err)
+				return nil, err
+			}
+
+			samples := make([]metrics.Sample, 0, len(counts))
+			for status, n := range counts {
+				samples = append(samples, metrics.Sample{
+					LabelValues: []string{status},
+					Value:       float64(n),
+				})
+			}
+			return samples, nil
+		},
+	)
+}
+
+// registerMediaCleanupMetrics exports the number of storage objects
+// queued for deletion, a growing count means deletes keep failing
+func (app *application) registerMediaCleanupMetrics(reg *metrics.Registry) {
+	reg.NewGaugeCollector(
+		"sketchdb_media_deletions_pending",
+		"Storage objects queued for deletion.",
+		nil,
+		func() ([]metrics.Sample, error) {
+			n, err := app.services.Sketches.PendingMediaDeletions()
+			if err != nil {
+				app.logger.Error("counting media deletions for metrics", "error", err)
+				return nil, err
+			}
+			return []metrics.Sample{{Value: float64(n)}}, nil
+		},
+	)
+}
@@ -2,17 +2,81 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
 
 	"sketchdb.cozycole.net/internal/models"
+	"sketchdb.cozycole.net/internal/ratelimit"
 )
 
 type contextKey string
 
-const userContextKey = contextKey("user")
+const (
+	userContextKey        = contextKey("user")
+	tokenContextKey       = contextKey("token")
+	requestInfoContextKey = contextKey("requestInfo")
+)
+
+// requestInfo is shared down the middleware chain so the access log can
+// report the user that authenticate finds after the request is logged
+type requestInfo struct {
+	id     string
+	userID int
+}
+
+// assignRequestID tags the request with an ID, available from requestID,
+// that's sent back in the X-Request-ID header. An ID set by the proxy in
+// front of us is kept so logs from both can be matched up.
+func (app *application) assignRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		ctx := context.WithValue(r.Context(), requestInfoContextKey, &requestInfo{id: id})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func requestID(r *http.Request) string {
+	if info, ok := r.Context().Value(requestInfoContextKey).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
 
 func (app *application) secureHeaders(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -59,13 +123,34 @@ func (app *application) recoverPanic(next http.Handler) http.Handler {
 	})
 }
 
+// logRequest writes an access log entry once the request has been served
 func (app *application) logRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if app.debugMode {
-			app.infoLog.Printf("%s - %s %s %s", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI())
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
 		}
 
-		next.ServeHTTP(w, r)
+		attrs := []any{
+			"request_id", requestID(r),
+			"method", r.Method,
+			"route", routePattern(r),
+			"uri", r.URL.RequestURI(),
+			"status", status,
+			"bytes", ww.BytesWritten(),
+			"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"ip", app.settings.rateLimits.trustedProxies.ClientIP(r),
+		}
+		if info, ok := r.Context().Value(requestInfoContextKey).(*requestInfo); ok && info.userID != 0 {
+			attrs = append(attrs, "user_id", info.userID)
+		}
+
+		app.logger.Info("request", attrs...)
 	})
 }
 
@@ -74,10 +159,11 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 		id := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
 		if id != 0 {
 			user, err := app.users.GetById(id)
-			if err != nil {
+			if err != nil || !safeDeref(user.Activated) {
 				next.ServeHTTP(w, r)
 				return
 			}
+			setRequestUser(r, user)
 
 			ctx := context.WithValue(r.Context(), userContextKey, user)
 			r = r.WithContext(ctx)
@@ -87,15 +173,24 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 
 		authHeader := r.Header.Get("Authorization")
 		if strings.HasPrefix(authHeader, "Bearer ") {
-			token := strings.TrimPrefix(authHeader, "Bearer ")
-			user, err := app.users.GetByToken(token)
+			plaintext := strings.TrimPrefix(authHeader, "Bearer ")
+			user, token, err := app.tokens.GetUser(plaintext)
 			if err != nil {
-				app.errorLog.Print(err)
-				next.ServeHTTP(w, r)
+				if errors.Is(err, models.ErrNoRecord) {
+					app.invalidAuthenticationTokenResponse(w, r)
+				} else {
+					app.serverErrorResponse(w, r, err)
+				}
+				return
+			}
+			if !safeDeref(user.Activated) {
+				app.inactiveAccountResponse(w, r)
 				return
 			}
+			setRequestUser(r, user)
 
 			ctx := context.WithValue(r.Context(), userContextKey, user)
+			ctx = context.WithValue(ctx, tokenContextKey, token)
 			r = r.WithContext(ctx)
 			next.ServeHTTP(w, r)
 			return
@@ -105,12 +200,20 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 	})
 }
 
+func setRequestUser(r *http.Request, user *models.User) {
+	if info, ok := r.Context().Value(requestInfoContextKey).(*requestInfo); ok {
+		info.userID = safeDeref(user.ID)
+	}
+}
+
 var (
 	editorAdmin = []string{"editor", "admin"}
 	adminOnly   = []string{"admin"}
 )
 
-func (app *application) requireRoles(roles []string) func(http.Handler) http.Handler {
+// requireRoles limits the routes to users with one of the roles, requests
+// made with an API token also need the token to have scope
+func (app *application) requireRoles(roles []string, scope string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user, ok := r.Context().Value(userContextKey).(*models.User)
@@ -118,11 +221,51 @@ func (app *application) requireRoles(roles []string) func(http.Handler) http.Han
 				app.unauthorized(w)
 				return
 			}
+
+			if !tokenAllows(r, scope) {
+				app.missingScopeResponse(w, r, scope)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireScope limits requests made with an API token to tokens with
+// scope, requests from a signed in session aren't affected
+func (app *application) requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !tokenAllows(r, scope) {
+				app.missingScopeResponse(w, r, scope)
+				return
+			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// tokenAllows reports whether the request's API token, if it has one,
+// grants scope. Reads are also allowed with the read scope.
+func tokenAllows(r *http.Request, scope string) bool {
+	token, ok := r.Context().Value(tokenContextKey).(*models.Token)
+	if !ok {
+		return true
+	}
+
+	if token.HasScope(scope) {
+		return true
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return token.HasScope(models.ScopeRead)
+	}
+
+	return false
+}
+
 func (app *application) requireAuthentication(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !app.isAutheticated(r) {
@@ -138,3 +281,58 @@ func (app *application) requireAuthentication(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// rateLimit throttles requests with a token bucket for each client. Signed
+// in users and API tokens get a bucket of their own, everyone else is
+// limited by IP. Each call creates a separate set of buckets, so routes
+// that should share a limit need to share the middleware.
+func (app *application) rateLimit(limit ratelimit.Limit) func(http.Handler) http.Handler {
+	return app.limitBy(limit, app.clientKey)
+}
+
+// rateLimitByIP is rateLimit keyed only by the client IP, for routes like
+// login where the user isn't known yet
+func (app *application) rateLimitByIP(limit ratelimit.Limit) func(http.Handler) http.Handler {
+	return app.limitBy(limit, func(r *http.Request) string {
+		return "ip:" + app.settings.rateLimits.trustedProxies.ClientIP(r)
+	})
+}
+
+func (app *application) limitBy(limit ratelimit.Limit, key func(*http.Request) string) func(http.Handler) http.Handler {
+	limiter := ratelimit.New(limit)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !app.settings.rateLimits.enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if ok, wait := limiter.Allow(key(r)); !ok {
+				retryAfter := int(math.Ceil(wait.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+				if strings.HasPrefix(r.URL.Path, "/api/") {
+					app.rateLimitExceededResponse(w, r)
+				} else {
+					app.clientError(w, http.StatusTooManyRequests)
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey identifies who made a request for rate limiting
+func (app *application) clientKey(r *http.Request) string {
+	if token, ok := r.Context().Value(tokenContextKey).(*models.Token); ok {
+		return fmt.Sprintf("token:%d", token.ID)
+	}
+
+	if user, ok := r.Context().Value(userContextKey).(*models.User); ok && user.ID != nil {
+		return fmt.Sprintf("user:%d", *user.ID)
+	}
+
+	return "ip:" + app.settings.rateLimits.trustedProxies.ClientIP(r)
+}
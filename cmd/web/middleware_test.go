@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sketchdb.cozycole.net/internal/assert"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// newAuthRequest returns a request made by a user with role, and with an
+// API token having scopes unless scopes is nil
+func newAuthRequest(method, role string, scopes []string) *http.Request {
+	r := httptest.NewRequest(method, "/", nil)
+	if role == "" {
+		return r
+	}
+
+	id := 1
+	ctx := context.WithValue(r.Context(), userContextKey, &models.User{ID: &id, Role: &role})
+	if scopes != nil {
+		ctx = context.WithValue(ctx, tokenContextKey, &models.Token{ID: 1, UserID: id, Scopes: scopes})
+	}
+	return r.WithContext(ctx)
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestTokenAllows(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{
+			name:   "Session",
+			method: http.MethodPost,
+			scope:  models.ScopeSketchWrite,
+			want:   true,
+		},
+		{
+			name:   "Has Scope",
+			method: http.MethodPost,
+			scopes: []string{models.ScopeSketchWrite},
+			scope:  models.ScopeSketchWrite,
+			want:   true,
+		},
+		{
+			name:   "Missing Scope",
+			method: http.MethodPost,
+			scopes: []string{models.ScopeMediaUpload},
+			scope:  models.ScopeSketchWrite,
+			want:   false,
+		},
+		{
+			name:   "Read Scope GET",
+			method: http.MethodGet,
+			scopes: []string{models.ScopeRead},
+			scope:  models.ScopeSketchWrite,
+			want:   true,
+		},
+		{
+			name:   "Read Scope HEAD",
+			method: http.MethodHead,
+			scopes: []string{models.ScopeRead},
+			scope:  models.ScopeAdmin,
+			want:   true,
+		},
+		{
+			name:   "Read Scope POST",
+			method: http.MethodPost,
+			scopes: []string{models.ScopeRead},
+			scope:  models.ScopeSketchWrite,
+			want:   false,
+		},
+		{
+			name:   "Read Scope DELETE",
+			method: http.MethodDelete,
+			scopes: []string{models.ScopeRead},
+			scope:  models.ScopeAdmin,
+			want:   false,
+		},
+		{
+			name:   "Write Scope GET Without Read",
+			method: http.MethodGet,
+			scopes: []string{models.ScopeMediaUpload},
+			scope:  models.ScopeSketchWrite,
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newAuthRequest(tt.method, "editor", tt.scopes)
+			assert.Equal(t, tokenAllows(r, tt.scope), tt.want)
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name     string
+		method   string
+		scopes   []string
+		wantCode int
+	}{
+		{
+			name:     "Session",
+			method:   http.MethodPost,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Token With Scope",
+			method:   http.MethodPost,
+			scopes:   []string{models.ScopeUserWrite},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Token Without Scope",
+			method:   http.MethodPost,
+			scopes:   []string{models.ScopeRead},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Read Token GET",
+			method:   http.MethodGet,
+			scopes:   []string{models.ScopeRead},
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r := newAuthRequest(tt.method, "viewer", tt.scopes)
+
+			app.requireScope(models.ScopeUserWrite)(okHandler).ServeHTTP(rr, r)
+			assert.Equal(t, rr.Code, tt.wantCode)
+		})
+	}
+}
+
+func TestRequireRoles(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name     string
+		roles    []string
+		role     string
+		scopes   []string
+		wantCode int
+	}{
+		{
+			name:     "Anonymous",
+			roles:    editorAdmin,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Wrong Role",
+			roles:    editorAdmin,
+			role:     "viewer",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Editor Session",
+			roles:    editorAdmin,
+			role:     "editor",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Editor Not Admin",
+			roles:    adminOnly,
+			role:     "editor",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Token With Scope",
+			roles:    editorAdmin,
+			role:     "editor",
+			scopes:   []string{models.ScopeSketchWrite},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Token Without Scope",
+			roles:    editorAdmin,
+			role:     "admin",
+			scopes:   []string{models.ScopeUserWrite},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Scope Doesn't Grant Role",
+			roles:    editorAdmin,
+			role:     "viewer",
+			scopes:   []string{models.ScopeSketchWrite},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r := newAuthRequest(http.MethodPost, tt.role, tt.scopes)
+
+			app.requireRoles(tt.roles, models.ScopeSketchWrite)(okHandler).ServeHTTP(rr, r)
+			assert.Equal(t, rr.Code, tt.wantCode)
+		})
+	}
+}
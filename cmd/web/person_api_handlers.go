@@ -1,11 +1,14 @@
 package main
 
 import (
+	"errors"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 
+	"sketchdb.cozycole.net/internal/domain/people"
+	"sketchdb.cozycole.net/internal/external/moviedb"
 	"sketchdb.cozycole.net/internal/models"
 )
 
@@ -59,3 +62,71 @@ func (app *application) listPeopleAPI(w http.ResponseWriter, r *http.Request) {
 		app.serverError(r, w, err)
 	}
 }
+
+// lookupTMDbPersonAPI previews a TMDb person (bio, birth date, known for
+// credits) so the person form can be pre-populated before saving
+func (app *application) lookupTMDbPersonAPI(w http.ResponseWriter, r *http.Request) {
+	tmdbId := r.PathValue("tmdbId")
+	if id, err := moviedb.ParseTMDbID(tmdbId); err == nil {
+		tmdbId = id
+	}
+
+	person, err := app.services.People.LookupTMDb(tmdbId)
+	if err != nil {
+		switch {
+		case errors.Is(err, moviedb.ErrNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, people.ErrTMDbDisabled):
+			app.errorResponse(w, r, http.StatusServiceUnavailable, err.Error())
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"person": person}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// mergePersonAPI merges the person in the path into the person intoId.
+// With ?dry_run=true nothing is changed and the response counts the rows
+// that would be re-pointed.
+func (app *application) mergePersonAPI(w http.ResponseWriter, r *http.Request) {
+	fromId, input, ok := app.readMergeInput(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	report, err := app.services.People.MergePeople(user, fromId, input.IntoID, input.options(r))
+	if app.writeFailed(r, err) {
+		app.mergeErrorResponse(w, r, err, report != nil)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"merge": report}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+func (app *application) deletePersonAPI(w http.ResponseWriter, r *http.Request) {
+	id, opts, ok := app.readDeleteInput(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	report, err := app.services.People.DeletePerson(user, id, opts)
+	if app.writeFailed(r, err) {
+		app.deleteErrorResponse(w, r, err, report)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"delete": report}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"strconv"
 
 	"sketchdb.cozycole.net/cmd/web/views"
-	"sketchdb.cozycole.net/internal/external/wikipedia"
 	"sketchdb.cozycole.net/internal/models"
 )
 
@@ -22,13 +21,17 @@ func (app *application) viewPerson(w http.ResponseWriter, r *http.Request) {
 	person, err := app.people.GetById(personId)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.redirectMoved(w, r, "person", personId)
 		} else {
 			app.serverError(r, w, err)
 		}
 		return
 	}
 
+	if app.redirectToCanonical(w, r, person.Slug) {
+		return
+	}
+
 	popular, _, err := app.sketches.Get(
 		&models.Filter{
 			Page:      1,
@@ -55,6 +58,7 @@ func (app *application) viewPerson(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := app.newTemplateData(r)
+	data.CanonicalURL = app.canonicalURL(r)
 	page, err := views.PersonPageView(
 		person,
 		stats,
@@ -111,33 +115,61 @@ func (app *application) addPerson(w http.ResponseWriter, r *http.Request) {
 	slug := models.CreateSlugName(views.PrintPersonName(&person))
 	person.Slug = &slug
 
-	thumbName, err := generateThumbnailName(form.ProfileImage)
-	if err != nil {
-		app.serverError(r, w, err)
-		return
-	}
-	person.ProfileImg = &thumbName
-
 	if person.WikiPage != nil {
-		description, err := wikipedia.GetExtract(*person.WikiPage)
+		description, err := app.services.Wiki.GetExtract(*person.WikiPage)
 		if nil == err {
 			person.Description = &description
 		}
 	}
 
-	id, err := app.people.Insert(&person)
+	// the wikipedia extract takes precedence, TMDb only fills what's blank
+	tmdbPerson, err := app.services.People.PrefillFromTMDb(&person)
 	if err != nil {
-		app.serverError(r, w, err)
-		return
+		app.logError(r, err)
 	}
 
-	err = app.saveLargeProfile(*person.ProfileImg, "person", form.ProfileImage)
-	if err != nil {
+	if form.ProfileImage == nil {
+		imgName, err := app.services.People.SaveTMDbProfileImage(tmdbPerson)
+		if err != nil {
+			app.logError(r, err)
+			form.AddFieldError("profileImg", "Unable to get a profile image from TMDb, please upload one")
+			data := app.newTemplateData(r)
+			data.Page = personFormPage{
+				Title: "Add Person",
+				Form:  form,
+			}
+			app.render(r, w, http.StatusUnprocessableEntity, "add-person.gohtml", "base", data)
+			return
+		}
+		person.ProfileImg = &imgName
+	} else {
+		thumbName, err := generateThumbnailName(form.ProfileImage)
+		if err != nil {
+			app.serverError(r, w, err)
+			return
+		}
+		person.ProfileImg = &thumbName
+	}
+
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	id, err := app.services.People.CreatePerson(user, &person)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
-		app.people.Delete(id)
+		if form.ProfileImage == nil {
+			app.deleteImage("person", *person.ProfileImg)
+		}
 		return
 	}
 
+	if form.ProfileImage != nil {
+		err = app.saveLargeProfile(*person.ProfileImg, "person", form.ProfileImage)
+		if err != nil {
+			app.serverError(r, w, err)
+			app.people.Delete(id)
+			return
+		}
+	}
+
 	http.Redirect(w, r, fmt.Sprintf("/person/%d/%s", id, slug), http.StatusSeeOther)
 }
 
@@ -147,7 +179,7 @@ func (app *application) updatePersonPage(w http.ResponseWriter, r *http.Request)
 	personId, err := strconv.Atoi(id)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -179,7 +211,7 @@ func (app *application) updatePerson(w http.ResponseWriter, r *http.Request) {
 	personId, err := strconv.Atoi(id)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -236,19 +268,39 @@ func (app *application) updatePerson(w http.ResponseWriter, r *http.Request) {
 	newPerson.Slug = &slug
 
 	if newPerson.WikiPage != nil {
-		description, err := wikipedia.GetExtract(*newPerson.WikiPage)
+		description, err := app.services.Wiki.GetExtract(*newPerson.WikiPage)
 		if nil == err {
 			newPerson.Description = &description
 		}
 	}
 
-	err = app.people.Update(&newPerson)
+	tmdbPerson, err := app.services.People.PrefillFromTMDb(&newPerson)
 	if err != nil {
+		app.logError(r, err)
+	}
+
+	// only replace the current image with the TMDb one if the
+	// TMDb person changed or there's no image yet
+	tmdbChanged := safeDeref(newPerson.TMDbID) != safeDeref(oldPerson.TMDbID)
+	tmdbProfileSaved := false
+	if form.ProfileImage == nil && tmdbPerson != nil && (tmdbChanged || oldProfileImgName == "") {
+		imgName, err := app.services.People.SaveTMDbProfileImage(tmdbPerson)
+		if err != nil {
+			app.logError(r, err)
+		} else {
+			newPerson.ProfileImg = &imgName
+			tmdbProfileSaved = true
+		}
+	}
+
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	err = app.services.People.UpdatePerson(user, &newPerson)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		return
 	}
 
-	if form.ProfileImage != nil && oldPerson.ProfileImg != nil {
+	if (form.ProfileImage != nil || tmdbProfileSaved) && oldProfileImgName != "" {
 		err = app.deleteImage("person", *oldPerson.ProfileImg)
 		if err != nil {
 			app.serverError(r, w, err)
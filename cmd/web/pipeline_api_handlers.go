@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
@@ -8,6 +9,10 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"sketchdb.cozycole.net/internal/domain/pipeline"
+	"sketchdb.cozycole.net/internal/domain/sketches"
+	"sketchdb.cozycole.net/internal/models"
 )
 
 const MAX_FILE_SIZE = 262_144_000 // 250 MiB
@@ -28,15 +33,14 @@ func (app *application) generateSketchVideoS3PutUrl(w http.ResponseWriter, r *ht
 		return
 	}
 
-	videos, err := app.services.Sketches.GetVideos(sketchId)
+	exists, err := app.sketches.Exists(sketchId)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
 
-	// currently not accepting more than one video per sketch
-	if len(videos) > 0 {
-		app.badRequestResponse(w, r, fmt.Errorf("sketch already has a video"))
+	if !exists {
+		app.notFoundResponse(w, r)
 		return
 	}
 
@@ -103,7 +107,10 @@ func (app *application) sketchVideoUploaded(w http.ResponseWriter, r *http.Reque
 	}
 
 	var input struct {
-		S3Key string `json:"s3Key"`
+		S3Key  string `json:"s3Key"`
+		Source string `json:"source"`
+		Width  *int   `json:"width"`
+		Height *int   `json:"height"`
 	}
 
 	err = app.readJSON(w, r, &input)
@@ -117,18 +124,40 @@ func (app *application) sketchVideoUploaded(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	exists, err = app.fileStorage.Exists(input.S3Key)
+	if errs := validateVideoResolution(input.Width, input.Height); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	// the declared size and type can't be trusted, check the stored object
+	video, err := app.services.Sketches.InspectVideo(r.Context(), input.S3Key)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		if errors.Is(err, sketches.ErrInvalidVideo) {
+			app.fileStorage.DeleteFile(input.S3Key)
+			app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
-	if !exists {
-		app.failedValidationResponse(w, r, map[string]string{"error": "s3 key does not exist in bucket"})
+	if safeDeref(video.SizeBytes) > MAX_FILE_SIZE || !allowedMIMETypes[safeDeref(video.ContentType)] {
+		app.fileStorage.DeleteFile(input.S3Key)
+		app.failedValidationResponse(w, r, map[string]string{
+			"error": fmt.Sprintf("unsupported file (%s, %d bytes)", safeDeref(video.ContentType), safeDeref(video.SizeBytes)),
+		})
 		return
 	}
 
-	video, err := app.services.Sketches.AddVideo(sketchId, input.S3Key)
+	video.HotS3Key = &input.S3Key
+	if input.Source != "" {
+		video.Source = &input.Source
+	}
+	if video.Width == nil {
+		video.Width, video.Height = input.Width, input.Height
+	}
+
+	video, err = app.services.Sketches.AddVideo(sketchId, video)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -173,3 +202,219 @@ func (app *application) getSketchVideos(w http.ResponseWriter, r *http.Request)
 
 	app.writeJSON(w, http.StatusOK, response, nil)
 }
+
+func (app *application) restoreSketchVideoAPI(w http.ResponseWriter, r *http.Request) {
+	sketchId, videoId, err := readSketchVideoParams(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	_, err = app.services.Sketches.RestoreVideo(sketchId, videoId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, sketches.ErrVideoNotArchived), errors.Is(err, sketches.ErrVideoIsHot):
+			app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	videos, err := app.services.Sketches.GetVideos(sketchId)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"videos": videos}, nil)
+}
+
+func (app *application) updateSketchVideoAPI(w http.ResponseWriter, r *http.Request) {
+	sketchId, videoId, err := readSketchVideoParams(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		Source *string `json:"source"`
+		Width  *int    `json:"width"`
+		Height *int    `json:"height"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if errs := validateVideoResolution(input.Width, input.Height); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	video, err := app.services.Sketches.UpdateVideo(sketchId, videoId, &models.SketchVideo{
+		Source: input.Source,
+		Width:  input.Width,
+		Height: input.Height,
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"video": video}, nil)
+}
+
+func (app *application) setPrimarySketchVideoAPI(w http.ResponseWriter, r *http.Request) {
+	sketchId, videoId, err := readSketchVideoParams(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err = app.services.Sketches.SetPrimaryVideo(sketchId, videoId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	videos, err := app.services.Sketches.GetVideos(sketchId)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"videos": videos}, nil)
+}
+
+func (app *application) deleteSketchVideoAPI(w http.ResponseWriter, r *http.Request) {
+	sketchId, videoId, err := readSketchVideoParams(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	err = app.services.Sketches.DeleteVideo(sketchId, videoId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, sketches.ErrVideoProcessing):
+			app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	videos, err := app.services.Sketches.GetVideos(sketchId)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"videos": videos}, nil)
+}
+
+func (app *application) rerunSketchVideoPipelineAPI(w http.ResponseWriter, r *http.Request) {
+	sketchId, videoId, err := readSketchVideoParams(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	video, err := app.services.Sketches.GetVideo(sketchId, videoId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if video.HotS3Key == nil {
+		app.failedValidationResponse(w, r, map[string]string{
+			"error": "video is not in hot storage, restore it first",
+		})
+		return
+	}
+
+	_, err = app.services.Pipeline.RerunPipeline(videoId)
+	if err != nil {
+		if errors.Is(err, pipeline.ErrJobQueued) {
+			app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	videos, err := app.services.Sketches.GetVideos(sketchId)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"videos": videos}, nil)
+}
+
+func (app *application) probeSketchVideoAPI(w http.ResponseWriter, r *http.Request) {
+	sketchId, videoId, err := readSketchVideoParams(r)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	video, err := app.services.Sketches.ProbeVideo(r.Context(), sketchId, videoId)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, sketches.ErrVideoNotHot), errors.Is(err, sketches.ErrInvalidVideo):
+			app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"video": video}, nil)
+}
+
+func readSketchVideoParams(r *http.Request) (int, int, error) {
+	sketchId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("sketch id param not defined")
+	}
+
+	videoId, err := strconv.Atoi(r.PathValue("videoId"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("video id param not defined")
+	}
+
+	return sketchId, videoId, nil
+}
+
+func validateVideoResolution(width, height *int) map[string]string {
+	errs := map[string]string{}
+	if width != nil && *width <= 0 {
+		errs["width"] = "must be a positive number"
+	}
+	if height != nil && *height <= 0 {
+		errs["height"] = "must be a positive number"
+	}
+	return errs
+}
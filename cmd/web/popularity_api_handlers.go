@@ -0,0 +1,23 @@
+package main
+
+import (
+	"net/http"
+)
+
+// recomputePopularityAPI recomputes every popularity score. With
+// ?dry_run=true nothing is written and the response lists what would
+// change.
+func (app *application) recomputePopularityAPI(w http.ResponseWriter, r *http.Request) {
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	report, err := app.services.Popularity.Recompute(r.Context(), dryRun)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"report": report}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"sketchdb.cozycole.net/internal/domain/quotes"
 	"sketchdb.cozycole.net/internal/models"
+	"sketchdb.cozycole.net/internal/subtitles"
 )
 
 func (app *application) adminGetQuotesAPI(w http.ResponseWriter, r *http.Request) {
@@ -87,8 +93,9 @@ func (app *application) updateQuotesAPI(w http.ResponseWriter, r *http.Request)
 		quotes = append(quotes, &modelQ)
 	}
 
-	updatedQuotes, err := app.services.Quotes.UpdateQuotes(sketchId, quotes, input.DeleteIds)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	updatedQuotes, err := app.services.Quotes.UpdateQuotes(user, sketchId, quotes, input.DeleteIds)
+	if app.writeFailed(r, err) {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
@@ -103,3 +110,178 @@ func (app *application) updateQuotesAPI(w http.ResponseWriter, r *http.Request)
 		return
 	}
 }
+
+func (app *application) promoteTranscriptAPI(w http.ResponseWriter, r *http.Request) {
+	sketchIdParam := r.PathValue("id")
+	sketchId, err := strconv.Atoi(sketchIdParam)
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("id param not defined"))
+		return
+	}
+
+	var input struct {
+		StartLineID *int `json:"startLineId"`
+		EndLineID   *int `json:"endLineId"`
+		LinkCast    bool `json:"linkCast"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.StartLineID == nil {
+		app.failedValidationResponse(w, r, map[string]string{"startLineId": "must be provided"})
+		return
+	}
+
+	endLineId := *input.StartLineID
+	if input.EndLineID != nil {
+		endLineId = *input.EndLineID
+	}
+
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	quote, err := app.services.Quotes.PromoteTranscriptLines(
+		user, sketchId, *input.StartLineID, endLineId, input.LinkCast,
+	)
+	if app.writeFailed(r, err) {
+		if errors.Is(err, quotes.ErrInvalidLineRange) {
+			app.failedValidationResponse(w, r, map[string]string{"lines": err.Error()})
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"quote": quote}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+const maxTranscriptSize = 5 << 20 // 5 MiB
+
+var transcriptContentTypes = map[string]string{
+	subtitles.FormatSRT: "application/x-subrip; charset=utf-8",
+	subtitles.FormatVTT: "text/vtt; charset=utf-8",
+}
+
+// getTranscriptAPI returns the sketch transcript as JSON, or as a subtitle
+// file download when ?format=srt|vtt is passed
+func (app *application) getTranscriptAPI(w http.ResponseWriter, r *http.Request) {
+	sketchIdParam := r.PathValue("id")
+	sketchId, err := strconv.Atoi(sketchIdParam)
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("id param not defined"))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" || format == "json" {
+		data, err := app.services.Quotes.GetAdminQuotes(sketchId)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+
+		err = app.writeJSON(w, http.StatusOK, envelope{"transcript": data.TranscriptLines}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	contentType, ok := transcriptContentTypes[format]
+	if !ok {
+		app.badRequestResponse(w, r, fmt.Errorf("unsupported transcript format %q", format))
+		return
+	}
+
+	var buf bytes.Buffer
+	err = app.services.Quotes.ExportTranscript(sketchId, &buf, format)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set(
+		"Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"sketch-%d.%s\"", sketchId, format),
+	)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// uploadTranscriptAPI replaces the sketch transcript with the cues of an
+// SRT or WebVTT file. The file can be sent either as the "file" field of a
+// multipart form or as the raw request body. The format is taken from the
+// "format" query param if given, otherwise from the file name or contents.
+func (app *application) uploadTranscriptAPI(w http.ResponseWriter, r *http.Request) {
+	sketchIdParam := r.PathValue("id")
+	sketchId, err := strconv.Atoi(sketchIdParam)
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("id param not defined"))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxTranscriptSize)
+
+	var data []byte
+	var fileName string
+	if strings.Contains(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			app.badRequestResponse(w, r, fmt.Errorf("transcript file not defined: %w", err))
+			return
+		}
+		defer file.Close()
+
+		fileName = header.Filename
+		data, err = io.ReadAll(file)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+	} else {
+		data, err = io.ReadAll(r.Body)
+		if err != nil {
+			app.badRequestResponse(w, r, err)
+			return
+		}
+	}
+
+	if len(data) == 0 {
+		app.failedValidationResponse(w, r, map[string]string{"file": "transcript file is empty"})
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = subtitles.DetectFormat(fileName, data)
+	}
+
+	if _, ok := transcriptContentTypes[format]; !ok {
+		app.failedValidationResponse(w, r, map[string]string{"format": "must be one of srt or vtt"})
+		return
+	}
+
+	lines, err := app.services.Quotes.ImportTranscript(sketchId, bytes.NewReader(data), format)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoSketch):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, subtitles.ErrMalformed):
+			app.failedValidationResponse(w, r, map[string]string{"file": err.Error()})
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"transcript": lines}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -59,3 +59,22 @@ func (app *application) listRecurringAPI(w http.ResponseWriter, r *http.Request)
 		app.serverError(r, w, err)
 	}
 }
+
+func (app *application) deleteRecurringAPI(w http.ResponseWriter, r *http.Request) {
+	id, opts, ok := app.readDeleteInput(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	report, err := app.services.Recurring.DeleteRecurring(user, id, opts)
+	if app.writeFailed(r, err) {
+		app.deleteErrorResponse(w, r, err, report)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"delete": report}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
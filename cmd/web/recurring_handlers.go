@@ -21,13 +21,17 @@ func (app *application) recurringView(w http.ResponseWriter, r *http.Request) {
 	recurring, err := app.recurring.GetById(recurringId)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.redirectMoved(w, r, "recurring", recurringId)
 		} else {
 			app.serverError(r, w, err)
 		}
 		return
 	}
 
+	if app.redirectToCanonical(w, r, recurring.Slug) {
+		return
+	}
+
 	page, err := views.RecurringPageView(recurring, app.baseImgUrl)
 	if err != nil {
 		app.serverError(r, w, err)
@@ -35,6 +39,7 @@ func (app *application) recurringView(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := app.newTemplateData(r)
+	data.CanonicalURL = app.canonicalURL(r)
 	data.Page = page
 	app.render(r, w, http.StatusOK, "view-recurring.gohtml", "base", data)
 }
@@ -63,7 +68,7 @@ func (app *application) recurringAdd(w http.ResponseWriter, r *http.Request) {
 	err := app.decodePostForm(r, &form)
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -85,8 +90,9 @@ func (app *application) recurringAdd(w http.ResponseWriter, r *http.Request) {
 
 	slug := models.CreateSlugName(safeDeref(recurring.Title))
 	recurring.Slug = &slug
-	id, err := app.recurring.Insert(&recurring)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	id, err := app.services.Recurring.CreateRecurring(user, &recurring)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		return
 	}
@@ -162,7 +168,7 @@ func (app *application) recurringUpdate(w http.ResponseWriter, r *http.Request)
 	err = app.decodePostForm(r, &form)
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -200,8 +206,9 @@ func (app *application) recurringUpdate(w http.ResponseWriter, r *http.Request)
 	slug := models.CreateSlugName(safeDeref(updaterecurring.Title))
 	updaterecurring.Slug = &slug
 
-	err = app.recurring.Update(&updaterecurring)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	err = app.services.Recurring.UpdateRecurring(user, &updaterecurring)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		return
 	}
@@ -4,6 +4,9 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+
+	"sketchdb.cozycole.net/internal/fileStore"
+	"sketchdb.cozycole.net/internal/models"
 )
 
 func (app *application) routes(staticRoute string, serveStatic bool) http.Handler {
@@ -13,7 +16,7 @@ func (app *application) routes(staticRoute string, serveStatic bool) http.Handle
 		r.Group(func(r chi.Router) {
 			r.Use(app.recoverPanic)
 			fs := http.FileServer(http.Dir(staticRoute))
-			app.infoLog.Printf("Starting static file server rooted at %s\n", staticRoute)
+			app.logger.Info("starting static file server", "root", staticRoute)
 			r.Handle("/static/*", http.StripPrefix("/static/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 				w.Header().Set("Pragma", "no-cache")
@@ -23,19 +26,46 @@ func (app *application) routes(staticRoute string, serveStatic bool) http.Handle
 		})
 	}
 
+	if app.settings.localImageServer {
+		r.Group(func(r chi.Router) {
+			r.Use(app.recoverPanic)
+			prefix := app.settings.localImagePrefix
+			fs := fileStore.FileServer(app.settings.localStoragePath)
+			app.logger.Info("serving stored files", "path", app.settings.localStoragePath, "prefix", prefix)
+			r.Handle(prefix+"/*", http.StripPrefix(prefix, fs))
+		})
+	}
+
+	if localStorage, ok := app.fileStorage.(*fileStore.LocalStorage); ok {
+		r.Group(func(r chi.Router) {
+			r.Use(app.assignRequestID, app.logRequest, app.recoverPanic)
+			r.Put(localUploadPath+"/*", http.StripPrefix(localUploadPath, localStorage.UploadHandler()).ServeHTTP)
+		})
+	}
+
 	r.Group(func(r chi.Router) {
 		r.Use(
+			app.assignRequestID,
+			app.logRequest,
+			app.recordMetrics,
 			app.recoverPanic,
 			app.secureHeaders,
 			app.sessionManager.LoadAndSave,
-			app.logRequest,
 			app.authenticate,
 		)
 
+		// requests are limited per group, each middleware has its own
+		// buckets shared by the routes it's used on
+		limits := app.settings.rateLimits
+		authLimit := app.rateLimitByIP(limits.auth)
+		searchLimit := app.rateLimit(limits.search)
+		apiLimit := app.rateLimit(limits.api)
+		writeLimit := app.rateLimit(limits.userWrite)
+
 		// public site routes
 		r.HandleFunc("/", app.home)
 		r.HandleFunc("/browse", app.browse)
-		r.Get("/search", app.search)
+		r.With(searchLimit).Get("/search", app.search)
 		r.Get("/catalog/sketches", app.catalogView)
 		// r.Get("/catalog/people", app.peopleCatalog)
 		// r.Get("/catalog/characters", app.catalogView)
@@ -46,26 +76,28 @@ func (app *application) routes(staticRoute string, serveStatic bool) http.Handle
 
 		r.Get("/sketch/{id}/{slug}", app.sketchView)
 
-		r.Post("/sketch/like/{id}", app.sketchAddLike)
-		r.Delete("/sketch/like/{id}", app.sketchRemoveLike)
+		// likes and ratings need the user:write scope with an API token
+		userWrite := app.requireScope(models.ScopeUserWrite)
+		r.With(userWrite, writeLimit).Post("/sketch/like/{id}", app.sketchAddLike)
+		r.With(userWrite, writeLimit).Delete("/sketch/like/{id}", app.sketchRemoveLike)
 
-		r.Post("/sketch/{id}/rating", app.sketchUpdateRating)
-		r.Delete("/sketch/{id}/rating", app.sketchDeleteRating)
+		r.With(userWrite, writeLimit).Post("/sketch/{id}/rating", app.sketchUpdateRating)
+		r.With(userWrite, writeLimit).Delete("/sketch/{id}/rating", app.sketchDeleteRating)
 
 		r.Get("/creator/{id}/{slug}", app.creatorView)
-		r.Get("/creator/search", app.creatorSearch)
+		r.With(searchLimit).Get("/creator/search", app.creatorSearch)
 
 		r.Get("/person/{id}/{slug}", app.viewPerson)
-		r.Get("/person/search", app.personSearch)
+		r.With(searchLimit).Get("/person/search", app.personSearch)
 
 		r.Get("/character/{id}/{slug}", app.characterView)
-		r.Get("/character/search", app.characterSearch)
+		r.With(searchLimit).Get("/character/search", app.characterSearch)
 
 		r.Get("/series/{id}/{slug}", app.seriesView)
-		r.Get("/series/search", app.seriesSearch)
+		r.With(searchLimit).Get("/series/search", app.seriesSearch)
 
 		r.Get("/recurring/{id}/{slug}", app.recurringView)
-		r.Get("/recurring/search", app.recurringSearch)
+		r.With(searchLimit).Get("/recurring/search", app.recurringSearch)
 
 		r.Get("/show/{id}/{slug}", app.viewShowHome)
 		r.Get("/show/{id}/{slug}/sketches", app.viewShowSketches)
@@ -73,35 +105,56 @@ func (app *application) routes(staticRoute string, serveStatic bool) http.Handle
 		r.Get("/show/{id}/{slug}/extras", app.viewShowGroupings)
 		r.Get("/show/{id}/{slug}/cast", app.viewShowCast)
 
-		r.Get("/show/search", app.showSearch)
+		r.With(searchLimit).Get("/show/search", app.showSearch)
 		r.Get("/show/{id}/{slug}/season", app.viewSeason)
 		r.Get("/season/{id}/{slug}", app.viewSeason)
 
 		r.Get("/episode/{id}/{slug}", app.viewEpisode)
-		r.Get("/episode/search", app.episodeSearch)
+		r.With(searchLimit).Get("/episode/search", app.episodeSearch)
 
-		r.Get("/category/search", app.categorySearch)
-		r.Get("/tag/search", app.tagSearch)
+		r.With(searchLimit).Get("/category/search", app.categorySearch)
+		r.With(searchLimit).Get("/tag/search", app.tagSearch)
 
 		r.Get("/user/{username}", app.userView)
+		r.Get("/user/{username}/lists", app.userLists)
+		r.Get("/user/{username}/lists/{slug}", app.userListView)
 
 		// AUTH
 		r.Get("/signup", app.userSignup)
-		r.Post("/signup", app.userSignupPost)
+		r.With(authLimit).Post("/signup", app.userSignupPost)
 		r.Get("/login", app.userLogin)
-		r.Post("/login", app.userLoginPost)
+		r.With(authLimit).Post("/login", app.userLoginPost)
 		r.Post("/logout", app.userLogoutPost)
+		r.Get("/activate", app.activateUser)
+		r.With(authLimit).Post("/activate", app.activateUserPost)
+		r.Get("/activate/resend", app.resendActivation)
+		r.With(authLimit).Post("/activate/resend", app.resendActivationPost)
+		r.Get("/password-reset", app.passwordReset)
+		r.With(authLimit).Post("/password-reset", app.passwordResetPost)
+		r.Get("/password-reset/confirm", app.passwordResetConfirm)
+		r.With(authLimit).Post("/password-reset/confirm", app.passwordResetConfirmPost)
 
 		// GOTH
 		// r.Get("/auth/{provider}", app.authCallback)
 
 		r.HandleFunc("/ping", ping)
 
+		// signed in user routes
+		r.Group(func(r chi.Router) {
+			r.Use(app.requireAuthentication, app.requireScope(models.ScopeUserWrite))
+
+			r.Get("/library", http.RedirectHandler("/library/ratings", http.StatusSeeOther).ServeHTTP)
+			r.Get("/library/ratings", app.libraryRatings)
+			r.Get("/library/sketches", app.libraryLikedSketches)
+			r.Get("/library/quotes", app.libraryLikedQuotes)
+			r.Get("/library/export", app.libraryExport)
+		})
+
 		// public site editor / admin routes
 		r.Group(func(r chi.Router) {
 			r.Use(
 				app.requireAuthentication,
-				app.requireRoles(editorAdmin),
+				app.requireRoles(editorAdmin, models.ScopeSketchWrite),
 			)
 
 			r.Get("/admin*", app.serveCMS)
@@ -157,7 +210,7 @@ func (app *application) routes(staticRoute string, serveStatic bool) http.Handle
 
 		// admin only public site routes
 		r.Group(func(r chi.Router) {
-			r.Use(app.requireRoles(adminOnly))
+			r.Use(app.requireRoles(adminOnly, models.ScopeAdmin))
 
 			r.Delete("/season/{id}", app.deleteSeason)
 			r.Delete("/episode/{id}", app.deleteEpisode)
@@ -166,22 +219,47 @@ func (app *application) routes(staticRoute string, serveStatic bool) http.Handle
 		// api routes
 		r.Route("/api/v1", func(r chi.Router) {
 			// public api routes
-			r.Get("/cast", app.listCastAPI)
-			r.Get("/characters", app.listCharactersAPI)
-			r.Get("/creators", app.listCreatorsAPI)
-			r.Get("/episodes", app.listEpisodesAPI)
-			r.Get("/people", app.listPeopleAPI)
-			r.Get("/recurring-sketches", app.listRecurringAPI)
-			r.Get("/sketch-series", app.listSeriesAPI)
-			r.Get("/sketches", app.viewSketchesAPI)
-			r.Get("/tags", app.listTagsAPI)
-
-			r.Post("/quotes/like", app.insertQuoteLike)
-			r.Delete("/quotes/like", app.deleteQuoteLike)
+			r.Group(func(r chi.Router) {
+				r.Use(apiLimit)
+				r.Get("/cast", app.listCastAPI)
+				r.Get("/characters", app.listCharactersAPI)
+				r.Get("/creators", app.listCreatorsAPI)
+				r.Get("/episodes", app.listEpisodesAPI)
+				r.Get("/people", app.listPeopleAPI)
+				r.Get("/recurring-sketches", app.listRecurringAPI)
+				r.Get("/sketch-series", app.listSeriesAPI)
+				r.Get("/sketches", app.viewSketchesAPI)
+				r.Get("/tags", app.listTagsAPI)
+			})
+
+			// signed in user API routes
+			r.Group(func(r chi.Router) {
+				r.Use(app.requireScope(models.ScopeUserWrite), writeLimit)
+				r.Post("/quotes/like", app.insertQuoteLike)
+				r.Delete("/quotes/like", app.deleteQuoteLike)
+
+				// user sketch lists, handlers check the signed in user owns
+				// the list before changing it
+				r.Get("/lists", app.listMyListsAPI)
+				r.Post("/lists", app.createListAPI)
+				r.Get("/lists/{listId}", app.getListAPI)
+				r.Put("/lists/{listId}", app.updateListAPI)
+				r.Delete("/lists/{listId}", app.deleteListAPI)
+				r.Post("/lists/{listId}/items", app.addListItemAPI)
+				r.Put("/lists/{listId}/items/order", app.updateListItemOrderAPI)
+				r.Put("/lists/{listId}/items/{sketchId}", app.updateListItemAPI)
+				r.Delete("/lists/{listId}/items/{sketchId}", app.deleteListItemAPI)
+			})
+
+			// API tokens are managed from a signed in session, a token
+			// can't be used to mint or revoke tokens
+			r.Get("/tokens", app.listTokensAPI)
+			r.Post("/tokens", app.createTokenAPI)
+			r.Delete("/tokens/{tokenId}", app.deleteTokenAPI)
 
 			// editor / admin API routes
 			r.Group(func(r chi.Router) {
-				r.Use(app.requireRoles(editorAdmin))
+				r.Use(app.requireRoles(editorAdmin, models.ScopeSketchWrite))
 				r.Get("/admin/sketch/{id}", app.adminGetSketchAPI)
 				r.Post("/admin/sketch", app.createSketchAPI)
 				r.Put("/admin/sketch/{id}", app.updateSketchAPI)
@@ -195,17 +273,67 @@ func (app *application) routes(staticRoute string, serveStatic bool) http.Handle
 
 				r.Get("/admin/sketch/{id}/quotes", app.adminGetQuotesAPI)
 				r.Put("/admin/sketch/{id}/quotes", app.updateQuotesAPI)
+				r.Post("/admin/sketch/{id}/quotes/from-transcript", app.promoteTranscriptAPI)
+				r.Get("/admin/sketch/{id}/transcript", app.getTranscriptAPI)
+				r.Put("/admin/sketch/{id}/transcript", app.uploadTranscriptAPI)
+
+				r.Get("/admin/tmdb/person/{tmdbId}", app.lookupTMDbPersonAPI)
+
+				r.Get("/admin/home/slots", app.listHomeSlotsAPI)
+				r.Post("/admin/home/slots", app.createHomeSlotAPI)
+				r.Put("/admin/home/slots/{slotId}", app.updateHomeSlotAPI)
+				r.Delete("/admin/home/slots/{slotId}", app.deleteHomeSlotAPI)
+				r.Put("/admin/home/slots/order", app.updateHomeSlotOrderAPI)
+
+				r.Get("/admin/browse/sections", app.listBrowseSectionsAPI)
+				r.Post("/admin/browse/sections", app.createBrowseSectionAPI)
+				r.Get("/admin/browse/sections/{sectionId}", app.getBrowseSectionAPI)
+				r.Put("/admin/browse/sections/{sectionId}", app.updateBrowseSectionAPI)
+				r.Delete("/admin/browse/sections/{sectionId}", app.deleteBrowseSectionAPI)
+				r.Put("/admin/browse/sections/order", app.updateBrowseSectionOrderAPI)
+			})
 
+			// editor / admin video upload API routes
+			r.Group(func(r chi.Router) {
+				r.Use(app.requireRoles(editorAdmin, models.ScopeMediaUpload))
 				r.Get("/admin/sketch/{id}/videos", app.getSketchVideos)
 				r.Post("/admin/sketch/{id}/upload-url", app.generateSketchVideoS3PutUrl)
 				r.Post("/admin/sketch/{id}/video-uploaded", app.sketchVideoUploaded)
+				r.Patch("/admin/sketch/{id}/videos/{videoId}", app.updateSketchVideoAPI)
+				r.Delete("/admin/sketch/{id}/videos/{videoId}", app.deleteSketchVideoAPI)
+				r.Post("/admin/sketch/{id}/videos/{videoId}/primary", app.setPrimarySketchVideoAPI)
+				r.Post("/admin/sketch/{id}/videos/{videoId}/pipeline", app.rerunSketchVideoPipelineAPI)
+				r.Post("/admin/sketch/{id}/videos/{videoId}/restore", app.restoreSketchVideoAPI)
+				r.Post("/admin/sketch/{id}/videos/{videoId}/probe", app.probeSketchVideoAPI)
+				r.Get("/admin/sketch/{id}/uploads", app.getVideoUploadsAPI)
+				r.Post("/admin/sketch/{id}/uploads", app.startVideoUploadAPI)
+				r.Get("/admin/sketch/{id}/uploads/{uploadId}", app.getVideoUploadAPI)
+				r.Delete("/admin/sketch/{id}/uploads/{uploadId}", app.abortVideoUploadAPI)
+				r.Post("/admin/sketch/{id}/uploads/{uploadId}/parts", app.presignVideoUploadPartsAPI)
+				r.Post("/admin/sketch/{id}/uploads/{uploadId}/complete", app.completeVideoUploadAPI)
 			})
 
 			// admin only api routes
 			r.Group(func(r chi.Router) {
-				r.Use(app.requireRoles(adminOnly))
-				r.Get("/admin/get-token", app.createAdminToken)
+				r.Use(app.requireRoles(adminOnly, models.ScopeAdmin))
+				r.Post("/admin/wiki/refresh", app.refreshWikiAPI)
+				r.Post("/admin/popularity/recompute", app.recomputePopularityAPI)
 				r.Delete("/sketch/{id}/screenshots", app.deleteScreenshotsAPI)
+
+				r.Get("/admin/audit/users/{userId}", app.userAuditAPI)
+				r.Get("/admin/audit/{entityType}/{entityId}", app.entityAuditAPI)
+				r.Post("/admin/sketch/{id}/revert/{entryId}", app.revertSketchAPI)
+				r.Post("/admin/person/{id}/revert/{entryId}", app.revertPersonAPI)
+
+				r.Post("/admin/person/{id}/merge", app.mergePersonAPI)
+				r.Post("/admin/character/{id}/merge", app.mergeCharacterAPI)
+
+				r.Delete("/admin/person/{id}", app.deletePersonAPI)
+				r.Delete("/admin/character/{id}", app.deleteCharacterAPI)
+				r.Delete("/admin/creator/{id}", app.deleteCreatorAPI)
+				r.Delete("/admin/show/{id}", app.deleteShowAPI)
+				r.Delete("/admin/series/{id}", app.deleteSeriesAPI)
+				r.Delete("/admin/recurring/{id}", app.deleteRecurringAPI)
 			})
 		})
 	})
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"sketchdb.cozycole.net/cmd/web/views"
 	"sketchdb.cozycole.net/internal/models"
@@ -28,6 +29,13 @@ func (app *application) viewSeason(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// /show/{id}/{slug}/season is only requested by htmx, the season
+	// page's own url is /season/{id}/{slug}
+	isSeasonPage := strings.HasPrefix(r.URL.Path, "/season/")
+	if isSeasonPage && app.redirectToCanonical(w, r, season.Slug) {
+		return
+	}
+
 	show, err := app.shows.GetById(safeDeref(season.Show.ID))
 	if err != nil {
 		app.serverError(r, w, err)
@@ -35,6 +43,9 @@ func (app *application) viewSeason(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := app.newTemplateData(r)
+	if isSeasonPage {
+		data.CanonicalURL = app.canonicalURL(r)
+	}
 
 	isHxRequest := r.Header.Get("HX-Request") == "true"
 	isHistoryRestore := r.Header.Get("HX-History-Restore-Request") == "true"
@@ -61,7 +72,7 @@ func (app *application) addSeason(w http.ResponseWriter, r *http.Request) {
 	err := app.decodePostForm(r, &form)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -75,7 +86,7 @@ func (app *application) addSeason(w http.ResponseWriter, r *http.Request) {
 	showId, err := strconv.Atoi(showIdParam)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -117,7 +128,7 @@ func (app *application) deleteSeason(w http.ResponseWriter, r *http.Request) {
 	seasonId, err := strconv.Atoi(seasonIdParam)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
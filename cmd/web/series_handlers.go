@@ -21,13 +21,17 @@ func (app *application) seriesView(w http.ResponseWriter, r *http.Request) {
 	series, err := app.series.GetById(seriesId)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.redirectMoved(w, r, "series", seriesId)
 		} else {
 			app.serverError(r, w, err)
 		}
 		return
 	}
 
+	if app.redirectToCanonical(w, r, series.Slug) {
+		return
+	}
+
 	page, err := views.SeriesPageView(series, app.baseImgUrl)
 	if err != nil {
 		app.serverError(r, w, err)
@@ -35,6 +39,7 @@ func (app *application) seriesView(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := app.newTemplateData(r)
+	data.CanonicalURL = app.canonicalURL(r)
 	data.Page = page
 	app.render(r, w, http.StatusOK, "view-series.gohtml", "base", data)
 }
@@ -63,7 +68,7 @@ func (app *application) seriesAdd(w http.ResponseWriter, r *http.Request) {
 	err := app.decodePostForm(r, &form)
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -85,8 +90,9 @@ func (app *application) seriesAdd(w http.ResponseWriter, r *http.Request) {
 
 	slug := models.CreateSlugName(safeDeref(series.Title))
 	series.Slug = &slug
-	id, err := app.series.Insert(&series)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	id, err := app.services.Series.CreateSeries(user, &series)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		return
 	}
@@ -162,7 +168,7 @@ func (app *application) seriesUpdate(w http.ResponseWriter, r *http.Request) {
 	err = app.decodePostForm(r, &form)
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -200,8 +206,9 @@ func (app *application) seriesUpdate(w http.ResponseWriter, r *http.Request) {
 	slug := models.CreateSlugName(safeDeref(updateSeries.Title))
 	updateSeries.Slug = &slug
 
-	err = app.series.Update(&updateSeries)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	err = app.services.Series.UpdateSeries(user, &updateSeries)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		return
 	}
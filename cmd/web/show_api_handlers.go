@@ -34,7 +34,7 @@ func (app *application) listEpisodesAPI(w http.ResponseWriter, r *http.Request)
 	}
 	query, _ = url.QueryUnescape(query)
 
-	app.infoLog.Printf("QUERY: '%s'", query)
+	app.logger.Debug("episode query", "query", query)
 	episodeList, err := app.services.Shows.ListEpisodes(
 		&models.Filter{
 			Query:    query,
@@ -58,3 +58,22 @@ func (app *application) listEpisodesAPI(w http.ResponseWriter, r *http.Request)
 		app.serverError(r, w, err)
 	}
 }
+
+func (app *application) deleteShowAPI(w http.ResponseWriter, r *http.Request) {
+	id, opts, ok := app.readDeleteInput(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	report, err := app.services.Shows.DeleteShow(user, id, opts)
+	if app.writeFailed(r, err) {
+		app.deleteErrorResponse(w, r, err, report)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"delete": report}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"strings"
 
 	"sketchdb.cozycole.net/cmd/web/views"
-	"sketchdb.cozycole.net/internal/external/wikipedia"
 	"sketchdb.cozycole.net/internal/models"
 )
 
@@ -18,20 +17,24 @@ func (app *application) viewShowHome(w http.ResponseWriter, r *http.Request) {
 	showId, err := strconv.Atoi(id)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
 	show, err := app.shows.GetById(showId)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.redirectMoved(w, r, "show", showId)
 		} else {
 			app.serverError(r, w, err)
 		}
 		return
 	}
 
+	if app.redirectToCanonical(w, r, show.Slug) {
+		return
+	}
+
 	filter := &models.Filter{
 		PageSize: 12,
 		Page:     1,
@@ -52,6 +55,7 @@ func (app *application) viewShowHome(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := app.newTemplateData(r)
+	data.CanonicalURL = app.canonicalURL(r)
 	pageData, err := views.ShowHomePageView(show, popular, cast, app.baseImgUrl)
 	if err != nil {
 		app.serverError(r, w, err)
@@ -76,7 +80,7 @@ func (app *application) viewShowSketches(w http.ResponseWriter, r *http.Request)
 	showId, err := strconv.Atoi(id)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -94,13 +98,17 @@ func (app *application) viewShowSketches(w http.ResponseWriter, r *http.Request)
 	show, err := app.shows.GetById(showId)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.redirectMoved(w, r, "show", showId)
 		} else {
 			app.serverError(r, w, err)
 		}
 		return
 	}
 
+	if app.redirectToCanonical(w, r, show.Slug) {
+		return
+	}
+
 	filter := &models.Filter{
 		PageSize: 12,
 		Page:     currentPage,
@@ -115,6 +123,7 @@ func (app *application) viewShowSketches(w http.ResponseWriter, r *http.Request)
 	}
 
 	data := app.newTemplateData(r)
+	data.CanonicalURL = app.canonicalURL(r)
 	pageData, err := views.ShowSketchesPageView(show, results, app.baseImgUrl)
 	if err != nil {
 		app.serverError(r, w, err)
@@ -161,14 +170,19 @@ func (app *application) viewShowSeasons(w http.ResponseWriter, r *http.Request)
 	show, err := app.shows.GetById(showId)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.redirectMoved(w, r, "show", showId)
 		} else {
 			app.serverError(r, w, err)
 		}
 		return
 	}
 
+	if app.redirectToCanonical(w, r, show.Slug) {
+		return
+	}
+
 	data := app.newTemplateData(r)
+	data.CanonicalURL = app.canonicalURL(r)
 	pageData, err := views.ShowSeasonsPageView(show, app.baseImgUrl)
 	if err != nil {
 		app.serverError(r, w, err)
@@ -197,13 +211,17 @@ func (app *application) viewShowGroupings(w http.ResponseWriter, r *http.Request
 	show, err := app.shows.GetById(showId)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.redirectMoved(w, r, "show", showId)
 		} else {
 			app.serverError(r, w, err)
 		}
 		return
 	}
 
+	if app.redirectToCanonical(w, r, show.Slug) {
+		return
+	}
+
 	groupings, err := app.shows.GetGroupings(showId)
 	if err != nil {
 		app.serverError(r, w, err)
@@ -211,6 +229,7 @@ func (app *application) viewShowGroupings(w http.ResponseWriter, r *http.Request
 	}
 
 	data := app.newTemplateData(r)
+	data.CanonicalURL = app.canonicalURL(r)
 	pageData, err := views.ShowExtrasPageView(show, groupings, app.baseImgUrl)
 	if err != nil {
 		app.serverError(r, w, err)
@@ -238,13 +257,17 @@ func (app *application) viewShowCast(w http.ResponseWriter, r *http.Request) {
 	show, err := app.shows.GetById(showId)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.redirectMoved(w, r, "show", showId)
 		} else {
 			app.serverError(r, w, err)
 		}
 		return
 	}
 
+	if app.redirectToCanonical(w, r, show.Slug) {
+		return
+	}
+
 	cast, err := app.shows.GetShowCast(*show.ID)
 	if err != nil {
 		app.serverError(r, w, err)
@@ -252,6 +275,7 @@ func (app *application) viewShowCast(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := app.newTemplateData(r)
+	data.CanonicalURL = app.canonicalURL(r)
 	pageData, err := views.ShowCastPageView(show, cast, app.baseImgUrl)
 	if err != nil {
 		app.serverError(r, w, err)
@@ -292,7 +316,7 @@ func (app *application) addShow(w http.ResponseWriter, r *http.Request) {
 	err := app.decodePostForm(r, &form)
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -315,14 +339,15 @@ func (app *application) addShow(w http.ResponseWriter, r *http.Request) {
 	show.ProfileImg = &thumbName
 
 	if show.WikiPage != nil {
-		about, err := wikipedia.GetExtract(*show.WikiPage)
+		about, err := app.services.Wiki.GetExtract(*show.WikiPage)
 		if nil == err {
 			show.About = &about
 		}
 	}
 
-	id, err := app.shows.Insert(&show)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	id, err := app.services.Shows.CreateShow(user, &show)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		return
 	}
@@ -350,7 +375,7 @@ func (app *application) updateShowPage(w http.ResponseWriter, r *http.Request) {
 	showId, err := strconv.Atoi(id)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -385,7 +410,7 @@ func (app *application) updateShow(w http.ResponseWriter, r *http.Request) {
 	showId, err := strconv.Atoi(id)
 	if err != nil {
 		app.badRequest(w)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -393,7 +418,7 @@ func (app *application) updateShow(w http.ResponseWriter, r *http.Request) {
 	err = app.decodePostForm(r, &form)
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -424,8 +449,8 @@ func (app *application) updateShow(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if newShow.WikiPage != nil {
-		about, err := wikipedia.GetExtract(*newShow.WikiPage)
-		app.infoLog.Printf(about)
+		about, err := app.services.Wiki.GetExtract(*newShow.WikiPage)
+		app.logger.Debug("wiki extract", "about", about)
 		if nil == err {
 			newShow.About = &about
 		}
@@ -446,8 +471,9 @@ func (app *application) updateShow(w http.ResponseWriter, r *http.Request) {
 	}
 
 	newShow.ProfileImg = &profileImg
-	err = app.shows.Update(&newShow)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	err = app.services.Shows.UpdateShow(user, &newShow)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		return
 	}
@@ -135,8 +135,9 @@ func (app *application) createSketchAPI(w http.ResponseWriter, r *http.Request)
 	}
 
 	formSketch := convertFormToSketch(&form)
-	sketch, err := app.services.Sketches.CreateSketch(&formSketch, form.Thumbnail, form.CropThumbnailBorder)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	sketch, err := app.services.Sketches.CreateSketch(user, &formSketch, form.Thumbnail, form.CropThumbnailBorder)
+	if app.writeFailed(r, err) {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
@@ -173,9 +174,14 @@ func (app *application) updateSketchAPI(w http.ResponseWriter, r *http.Request)
 	sketch.ID = &sketchId
 	file, _ := fileHeaderToBytes(form.Thumbnail)
 
-	updatedSketch, err := app.services.Sketches.UpdateSketch(&sketch, file, form.CropThumbnailBorder)
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	updatedSketch, err := app.services.Sketches.UpdateSketch(user, &sketch, file, form.CropThumbnailBorder)
+	if app.writeFailed(r, err) {
+		if errors.Is(err, models.ErrNoSketch) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
@@ -216,19 +222,18 @@ func (app *application) deleteSketchAPI(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// deleting between postgres and s3 isn't atomic so need two step process
-	deleteInfo, err := app.services.Sketches.DeleteSketch(sketchId)
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	err = app.services.Sketches.DeleteSketch(user, sketchId)
+	if app.writeFailed(r, err) {
+		if errors.Is(err, models.ErrNoSketch) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
 
 	app.writeJSON(w, http.StatusOK, envelope{"message": "Sketch successfully deleted."}, nil)
-
-	err = app.services.Sketches.CleanupSketchMedia(deleteInfo)
-	if err != nil {
-		app.errorLog.Print(err)
-	}
 }
 
 func (app *application) deleteScreenshotsAPI(w http.ResponseWriter, r *http.Request) {
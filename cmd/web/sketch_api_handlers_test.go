@@ -18,7 +18,7 @@ func TestCreateSketchAPI(t *testing.T) {
 			Creators: &models.CreatorModel{DB: db},
 			Sketches: &models.SketchModel{DB: db},
 			Shows:    &models.ShowModel{DB: db},
-		}, app.fileStorage)
+		}, app.fileStorage, app.fileStorage)
 
 	_ = insertTestCreator(t, &models.CreatorModel{DB: db})
 
@@ -71,7 +71,7 @@ func TestUpdateSketchAPI(t *testing.T) {
 			Creators: &models.CreatorModel{DB: db},
 			Sketches: &models.SketchModel{DB: db},
 			Shows:    &models.ShowModel{DB: db},
-		}, app.fileStorage)
+		}, app.fileStorage, app.fileStorage)
 
 	_ = insertTestCreator(t, &models.CreatorModel{DB: db})
 	_ = insertTestCreator(t, &models.CreatorModel{DB: db})
@@ -21,13 +21,17 @@ func (app *application) sketchView(w http.ResponseWriter, r *http.Request) {
 	sketch, err := app.sketches.GetById(sketchId)
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
-			app.notFound(w)
+			app.redirectMoved(w, r, "sketch", sketchId)
 		} else {
 			app.serverError(r, w, err)
 		}
 		return
 	}
 
+	if app.redirectToCanonical(w, r, sketch.Slug) {
+		return
+	}
+
 	tags, err := app.tags.GetBySketch(sketchId)
 	if err != nil && !errors.Is(err, models.ErrNoRecord) {
 		app.serverError(r, w, err)
@@ -59,6 +63,7 @@ func (app *application) sketchView(w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := app.newTemplateData(r)
+	data.CanonicalURL = app.canonicalURL(r)
 	sketchPage, err := views.SketchPageView(sketch, quotes, tags, userSketchInfo, app.baseImgUrl)
 	if err != nil {
 		app.serverError(r, w, err)
@@ -80,7 +85,7 @@ func (app *application) sketchAddLike(w http.ResponseWriter, r *http.Request) {
 
 	user, ok := r.Context().Value(userContextKey).(*models.User)
 	if !ok || nil == user {
-		app.infoLog.Println("User not logged in!")
+		app.logger.Debug("user not logged in")
 		http.Redirect(w, r, "/login", http.StatusSeeOther)
 		return
 	}
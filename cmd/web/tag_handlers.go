@@ -32,7 +32,7 @@ func (app *application) tagAdd(w http.ResponseWriter, r *http.Request) {
 	err := app.decodePostForm(r, &form)
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
-		app.errorLog.Print(err)
+		app.logError(r, err)
 		return
 	}
 
@@ -56,8 +56,9 @@ func (app *application) tagAdd(w http.ResponseWriter, r *http.Request) {
 
 	slug := models.CreateSlugName(tagSlug)
 	tag.Slug = &slug
-	id, err := app.tags.Insert(&tag)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	id, err := app.services.Tags.CreateTag(user, &tag)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		return
 	}
@@ -141,8 +142,9 @@ func (app *application) tagUpdate(w http.ResponseWriter, r *http.Request) {
 	slug := models.CreateSlugName(tagSlug)
 	tag.Slug = &slug
 
-	err = app.tags.Update(&tag)
-	if err != nil {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	err = app.services.Tags.UpdateTag(user, &tag)
+	if app.writeFailed(r, err) {
 		app.serverError(r, w, err)
 		return
 	}
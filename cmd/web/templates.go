@@ -25,6 +25,7 @@ type templateData struct {
 	Categories      *[]*models.Category
 	CSRFToken       string
 	Cast            []*models.CastMember
+	CanonicalURL    string
 	CatalogType     string
 	Creator         *models.Creator
 	CurrentYear     int
@@ -3,7 +3,7 @@ package main
 import (
 	"bytes"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -22,8 +22,7 @@ func newTestApplication(t *testing.T) *application {
 
 	formDecoder := form.NewDecoder()
 	return &application{
-		errorLog:      log.New(io.Discard, "", 0),
-		infoLog:       log.New(io.Discard, "", 0),
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
 		fileStorage:   &imgmock.FileStorage{},
 		formDecoder:   formDecoder,
 		templateCache: templateCache,
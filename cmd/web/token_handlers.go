@@ -1,23 +1,165 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+const (
+	defaultTokenDays = 90
+	maxTokenDays     = 365
 )
 
-func (app *application) createAdminToken(w http.ResponseWriter, r *http.Request) {
-	// pull the authenticated user from context the same way your other handlers do
-	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+// the scopes a user's role lets them give their tokens
+var roleTokenScopes = map[string][]string{
+	"viewer": {models.ScopeRead, models.ScopeUserWrite},
+	"editor": {models.ScopeRead, models.ScopeUserWrite, models.ScopeSketchWrite, models.ScopeMediaUpload},
+	"admin":  models.TokenScopes,
+}
+
+type tokenInput struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays *int     `json:"expiresInDays"`
+}
+
+func (input *tokenInput) validate(role string) map[string]string {
+	errs := map[string]string{}
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		errs["name"] = "name must be specified"
+	} else if len(input.Name) > 100 {
+		errs["name"] = "name cannot be more than 100 characters"
+	}
+
+	if len(input.Scopes) == 0 {
+		errs["scopes"] = "at least one scope must be specified"
+	}
+
+	for _, scope := range input.Scopes {
+		if !models.IsTokenScope(scope) {
+			errs["scopes"] = fmt.Sprintf("unknown scope %q", scope)
+			break
+		}
+		if !slices.Contains(roleTokenScopes[role], scope) {
+			errs["scopes"] = fmt.Sprintf("your account can't grant the %q scope", scope)
+			break
+		}
+	}
+	slices.Sort(input.Scopes)
+	input.Scopes = slices.Compact(input.Scopes)
+
+	if input.ExpiresInDays == nil {
+		days := defaultTokenDays
+		input.ExpiresInDays = &days
+	} else if *input.ExpiresInDays < 1 || *input.ExpiresInDays > maxTokenDays {
+		errs["expiresInDays"] = fmt.Sprintf("tokens must expire in 1 to %d days", maxTokenDays)
+	}
+
+	return errs
+}
+
+func (app *application) listTokensAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.tokenOwner(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := app.tokens.GetForUser(*user.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"tokens": tokens}, nil)
+}
+
+func (app *application) createTokenAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.tokenOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var input tokenInput
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if errs := input.validate(derefString(user.Role)); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	expiresAt := time.Now().AddDate(0, 0, *input.ExpiresInDays)
+	token := &models.Token{
+		UserID:    *user.ID,
+		Name:      input.Name,
+		Scopes:    input.Scopes,
+		ExpiresAt: &expiresAt,
+	}
 
-	plaintext, err := app.tokens.Insert(userID)
+	plaintext, err := app.tokens.Insert(token)
 	if err != nil {
-		app.serverError(r, w, err)
+		app.serverErrorResponse(w, r, err)
 		return
 	}
 
 	data := envelope{
-		"token":   plaintext,
-		"message": "Store this somewhere safe — it will not be shown again.\n",
+		"token":     token,
+		"plaintext": plaintext,
+		"message":   "Store this somewhere safe — it will not be shown again.",
+	}
+
+	app.writeJSON(w, http.StatusCreated, data, nil)
+}
+
+func (app *application) deleteTokenAPI(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.tokenOwner(w, r)
+	if !ok {
+		return
+	}
+
+	tokenId, err := strconv.Atoi(r.PathValue("tokenId"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("token id param not defined"))
+		return
+	}
+
+	err = app.tokens.Delete(tokenId, *user.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tokenOwner returns the signed in user, requests authenticated with a
+// token are rejected so a leaked token can't be used to create more
+func (app *application) tokenOwner(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	user, ok := r.Context().Value(userContextKey).(*models.User)
+	if !ok || user.ID == nil {
+		app.errorResponse(w, r, http.StatusUnauthorized, "please sign in to manage API tokens")
+		return nil, false
+	}
+
+	if _, ok := r.Context().Value(tokenContextKey).(*models.Token); ok {
+		app.errorResponse(w, r, http.StatusForbidden, "API tokens can only be managed from a signed in session")
+		return nil, false
 	}
 
-	app.writeJSON(w, http.StatusOK, data, nil)
+	return user, true
 }
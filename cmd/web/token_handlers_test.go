@@ -0,0 +1,156 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sketchdb.cozycole.net/internal/assert"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+func TestTokenInputValidate(t *testing.T) {
+	intPtr := func(n int) *int { return &n }
+
+	tests := []struct {
+		name       string
+		role       string
+		input      tokenInput
+		wantErrs   []string
+		wantScopes []string
+		wantDays   int
+	}{
+		{
+			name:       "Valid",
+			role:       "viewer",
+			input:      tokenInput{Name: " script ", Scopes: []string{models.ScopeRead}},
+			wantScopes: []string{models.ScopeRead},
+			wantDays:   defaultTokenDays,
+		},
+		{
+			name:     "Missing Name",
+			role:     "viewer",
+			input:    tokenInput{Name: "  ", Scopes: []string{models.ScopeRead}},
+			wantErrs: []string{"name"},
+		},
+		{
+			name:     "Long Name",
+			role:     "viewer",
+			input:    tokenInput{Name: strings.Repeat("a", 101), Scopes: []string{models.ScopeRead}},
+			wantErrs: []string{"name"},
+		},
+		{
+			name:     "No Scopes",
+			role:     "admin",
+			input:    tokenInput{Name: "script"},
+			wantErrs: []string{"scopes"},
+		},
+		{
+			name:     "Unknown Scope",
+			role:     "admin",
+			input:    tokenInput{Name: "script", Scopes: []string{"everything"}},
+			wantErrs: []string{"scopes"},
+		},
+		{
+			name:     "Viewer Can't Grant Sketch Write",
+			role:     "viewer",
+			input:    tokenInput{Name: "script", Scopes: []string{models.ScopeSketchWrite}},
+			wantErrs: []string{"scopes"},
+		},
+		{
+			name:     "Editor Can't Grant Admin",
+			role:     "editor",
+			input:    tokenInput{Name: "script", Scopes: []string{models.ScopeRead, models.ScopeAdmin}},
+			wantErrs: []string{"scopes"},
+		},
+		{
+			name:     "Unknown Role Can't Grant Read",
+			role:     "",
+			input:    tokenInput{Name: "script", Scopes: []string{models.ScopeRead}},
+			wantErrs: []string{"scopes"},
+		},
+		{
+			name: "Editor Scopes Deduplicated",
+			role: "editor",
+			input: tokenInput{
+				Name:   "script",
+				Scopes: []string{models.ScopeSketchWrite, models.ScopeRead, models.ScopeSketchWrite},
+			},
+			wantScopes: []string{models.ScopeRead, models.ScopeSketchWrite},
+			wantDays:   defaultTokenDays,
+		},
+		{
+			name:       "Admin Grants Admin",
+			role:       "admin",
+			input:      tokenInput{Name: "script", Scopes: []string{models.ScopeAdmin}, ExpiresInDays: intPtr(maxTokenDays)},
+			wantScopes: []string{models.ScopeAdmin},
+			wantDays:   maxTokenDays,
+		},
+		{
+			name:     "Expiry Too Short",
+			role:     "viewer",
+			input:    tokenInput{Name: "script", Scopes: []string{models.ScopeRead}, ExpiresInDays: intPtr(0)},
+			wantErrs: []string{"expiresInDays"},
+		},
+		{
+			name:     "Expiry Too Long",
+			role:     "viewer",
+			input:    tokenInput{Name: "script", Scopes: []string{models.ScopeRead}, ExpiresInDays: intPtr(maxTokenDays + 1)},
+			wantErrs: []string{"expiresInDays"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := tt.input
+			errs := input.validate(tt.role)
+
+			assert.Equal(t, len(errs), len(tt.wantErrs))
+			for _, key := range tt.wantErrs {
+				if _, ok := errs[key]; !ok {
+					t.Errorf("missing %q error, got %v", key, errs)
+				}
+			}
+			if len(tt.wantErrs) > 0 {
+				return
+			}
+
+			assert.Equal(t, input.Name, strings.TrimSpace(tt.input.Name))
+			assert.DeepEqual(t, input.Scopes, tt.wantScopes)
+			assert.Equal(t, *input.ExpiresInDays, tt.wantDays)
+		})
+	}
+}
+
+func TestCreateTokenFromToken(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name     string
+		role     string
+		scopes   []string
+		wantCode int
+	}{
+		{
+			name:     "Anonymous",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Token",
+			role:     "admin",
+			scopes:   models.TokenScopes,
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r := newAuthRequest(http.MethodPost, tt.role, tt.scopes)
+
+			app.createTokenAPI(rr, r)
+			assert.Equal(t, rr.Code, tt.wantCode)
+		})
+	}
+}
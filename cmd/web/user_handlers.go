@@ -19,7 +19,6 @@ func (app *application) userSignupPost(w http.ResponseWriter, r *http.Request) {
 	var form userSignupForm
 
 	err := app.decodePostForm(r, &form)
-	app.infoLog.Println(err)
 	if err != nil {
 		app.clientError(w, http.StatusBadRequest)
 		return
@@ -63,7 +62,15 @@ func (app *application) userSignupPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	app.sessionManager.Put(r.Context(), "flash", "Successful signup! Please log in.")
+	flash := "Successful signup! Check your email for a link to activate your account, then log in."
+	err = app.services.Accounts.SendActivation(user)
+	if err != nil {
+		// the account exists either way, the email can be resent later
+		app.logError(r, err)
+		flash = "Successful signup! Please log in."
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", flash)
 
 	http.Redirect(w, r, "/login", http.StatusSeeOther)
 }
@@ -103,6 +110,21 @@ func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// accounts have to be activated from the emailed link before they can
+	// log in, which also keeps them from writing or creating API tokens
+	user, err := app.users.GetById(id)
+	if err != nil {
+		app.serverError(r, w, err)
+		return
+	}
+	if !safeDeref(user.Activated) {
+		form.AddNonFieldError("Activate your account from the link we emailed you before logging in")
+		data := app.newTemplateData(r)
+		data.Forms.Login = &form
+		app.render(r, w, http.StatusForbidden, "login.gohtml", "base", data)
+		return
+	}
+
 	err = app.sessionManager.RenewToken(r.Context())
 	if err != nil {
 		app.serverError(r, w, err)
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"sketchdb.cozycole.net/internal/domain/sketches"
+	"sketchdb.cozycole.net/internal/fileStore"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+const MAX_MULTIPART_FILE_SIZE = 20 << 30 // 20 GiB
+
+// max number of part urls handed out per request
+const maxPartURLs = 100
+
+func (app *application) startVideoUploadAPI(w http.ResponseWriter, r *http.Request) {
+	sketchIdParam := r.PathValue("id")
+	sketchId, err := strconv.Atoi(sketchIdParam)
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("sketch id param not defined"))
+		return
+	}
+
+	exists, err := app.sketches.Exists(sketchId)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	if !exists {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		FileName    string `json:"fileName"`
+		ContentType string `json:"contentType"`
+		FileSize    int64  `json:"fileSize"`
+	}
+
+	err = app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if input.FileName == "" {
+		app.failedValidationResponse(w, r, map[string]string{"error": "file name must be specified"})
+		return
+	}
+
+	if !allowedMIMETypes[input.ContentType] {
+		app.failedValidationResponse(w, r, map[string]string{"error": "unsupported file type"})
+		return
+	}
+
+	if input.FileSize <= 0 {
+		app.failedValidationResponse(w, r, map[string]string{"error": "file size must be specified"})
+		return
+	}
+
+	if input.FileSize > MAX_MULTIPART_FILE_SIZE {
+		app.failedValidationResponse(w, r, map[string]string{"error": "max file upload size 20 GiB"})
+		return
+	}
+
+	upload, err := app.services.Sketches.StartVideoUpload(
+		sketchId, input.FileName, input.ContentType, input.FileSize,
+	)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	response := envelope{
+		"upload":    upload,
+		"partCount": upload.PartCount(),
+	}
+
+	app.writeJSON(w, http.StatusCreated, response, nil)
+}
+
+func (app *application) getVideoUploadsAPI(w http.ResponseWriter, r *http.Request) {
+	sketchIdParam := r.PathValue("id")
+	sketchId, err := strconv.Atoi(sketchIdParam)
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("sketch id param not defined"))
+		return
+	}
+
+	uploads, err := app.services.Sketches.GetVideoUploads(sketchId)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"uploads": uploads}, nil)
+}
+
+// getVideoUploadAPI returns the upload along with the parts already stored
+// so an interrupted upload can be resumed
+func (app *application) getVideoUploadAPI(w http.ResponseWriter, r *http.Request) {
+	upload, ok := app.readVideoUpload(w, r)
+	if !ok {
+		return
+	}
+
+	parts, err := app.services.Sketches.GetUploadedParts(upload)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	response := envelope{
+		"upload":    upload,
+		"partCount": upload.PartCount(),
+		"parts":     parts,
+	}
+
+	app.writeJSON(w, http.StatusOK, response, nil)
+}
+
+func (app *application) presignVideoUploadPartsAPI(w http.ResponseWriter, r *http.Request) {
+	upload, ok := app.readVideoUpload(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		PartNumbers []int `json:"partNumbers"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if len(input.PartNumbers) == 0 {
+		app.failedValidationResponse(w, r, map[string]string{"error": "part numbers must be specified"})
+		return
+	}
+
+	if len(input.PartNumbers) > maxPartURLs {
+		app.failedValidationResponse(w, r, map[string]string{
+			"error": fmt.Sprintf("at most %d parts can be requested at once", maxPartURLs),
+		})
+		return
+	}
+
+	urls, err := app.services.Sketches.PresignUploadParts(upload, input.PartNumbers, time.Hour)
+	if err != nil {
+		if errors.Is(err, sketches.ErrInvalidPartNumber) {
+			app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"urls": urls}, nil)
+}
+
+func (app *application) completeVideoUploadAPI(w http.ResponseWriter, r *http.Request) {
+	upload, ok := app.readVideoUpload(w, r)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Parts  []fileStore.UploadPart `json:"parts"`
+		Source string                 `json:"source"`
+		Width  *int                   `json:"width"`
+		Height *int                   `json:"height"`
+	}
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if errs := validateVideoResolution(input.Width, input.Height); len(errs) > 0 {
+		app.failedValidationResponse(w, r, errs)
+		return
+	}
+
+	video := &models.SketchVideo{
+		Width:  input.Width,
+		Height: input.Height,
+	}
+	if input.Source != "" {
+		video.Source = &input.Source
+	}
+
+	video, err = app.services.Sketches.CompleteVideoUpload(r.Context(), upload, input.Parts, video)
+	if err != nil {
+		if errors.Is(err, sketches.ErrUploadIncomplete) || errors.Is(err, fileStore.ErrInvalidPart) ||
+			errors.Is(err, sketches.ErrInvalidVideo) {
+			app.failedValidationResponse(w, r, map[string]string{"error": err.Error()})
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	_, err = app.services.Pipeline.AddPipelineJob(safeDeref(video.ID))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	videos, err := app.services.Sketches.GetVideos(safeDeref(upload.SketchID))
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeJSON(w, http.StatusOK, envelope{"videos": videos}, nil)
+}
+
+func (app *application) abortVideoUploadAPI(w http.ResponseWriter, r *http.Request) {
+	upload, ok := app.readVideoUpload(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.services.Sketches.AbortVideoUpload(upload)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// readVideoUpload loads the upload from the {id} and {uploadId} path
+// values, writing an error response and returning false if it can't
+func (app *application) readVideoUpload(w http.ResponseWriter, r *http.Request) (*models.VideoUpload, bool) {
+	sketchId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("sketch id param not defined"))
+		return nil, false
+	}
+
+	uploadId, err := strconv.Atoi(r.PathValue("uploadId"))
+	if err != nil {
+		app.badRequestResponse(w, r, fmt.Errorf("upload id param not defined"))
+		return nil, false
+	}
+
+	upload, err := app.services.Sketches.GetVideoUpload(sketchId, uploadId)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.notFoundResponse(w, r)
+		} else {
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return upload, true
+}
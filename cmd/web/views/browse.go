@@ -10,27 +10,6 @@ type BrowseSectionDefinition struct {
 	Sketches []*models.SketchRef
 }
 
-var FEATURED_ID = 1
-var BrowseSectionDefinitions = []BrowseSectionDefinition{
-	{
-		Title: "Featured Sketches",
-		Filter: models.Filter{
-			Page:     1,
-			PageSize: 10,
-			SortBy:   "popular",
-			TagIDs:   []int{FEATURED_ID},
-		},
-	},
-	{
-		Title: "Popular",
-		Filter: models.Filter{
-			Page:     1,
-			PageSize: 10,
-			SortBy:   "popular",
-		},
-	},
-}
-
 type BrowsePage struct {
 	Sections []BrowseSection
 }
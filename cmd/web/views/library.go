@@ -0,0 +1,168 @@
+package views
+
+import (
+	"fmt"
+	"time"
+
+	"sketchdb.cozycole.net/internal/domain/library"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type LibraryPage struct {
+	Title         string
+	Tabs          []*LibraryLink
+	SortOptions   []*LibraryLink
+	Items         []*LibraryItem
+	TotalCount    int
+	Pages         []*PaginationItem
+	ExportJSONUrl string
+	ExportCSVUrl  string
+}
+
+type LibraryLink struct {
+	Label     string
+	Url       string
+	IsCurrent bool
+}
+
+// LibraryItem is a row in one of the library lists, Quote is only set for
+// liked quotes and Rating for rated sketches
+type LibraryItem struct {
+	Url         string
+	Image       string
+	Title       string
+	CreatorName string
+	Quote       string
+	Rating      int
+	Date        string
+}
+
+var libraryTabs = []struct{ Label, Url string }{
+	{"Ratings", "/library/ratings"},
+	{"Liked Sketches", "/library/sketches"},
+	{"Liked Quotes", "/library/quotes"},
+}
+
+var ratingSortOptions = []struct{ Label, Sort string }{
+	{"Recently Rated", "recent"},
+	{"Oldest", "oldest"},
+	{"Highest Rated", "rating"},
+	{"Lowest Rated", "lowest"},
+}
+
+var likeSortOptions = []struct{ Label, Sort string }{
+	{"Recently Liked", "recent"},
+	{"Oldest", "oldest"},
+}
+
+func RatingsLibraryView(result library.RatingsResult, baseImgUrl string) (*LibraryPage, error) {
+	page, err := newLibraryPage("/library/ratings", result.Metadata, result.Filter, ratingSortOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range result.Ratings {
+		item, err := libraryItemView(r.Sketch, r.RatedAt, baseImgUrl)
+		if err != nil {
+			return nil, err
+		}
+		item.Rating = safeDeref(r.Rating)
+		page.Items = append(page.Items, item)
+	}
+
+	return page, nil
+}
+
+func LikedSketchesLibraryView(result library.LikedSketchesResult, baseImgUrl string) (*LibraryPage, error) {
+	page, err := newLibraryPage("/library/sketches", result.Metadata, result.Filter, likeSortOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, l := range result.Likes {
+		item, err := libraryItemView(l.Sketch, l.LikedAt, baseImgUrl)
+		if err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, item)
+	}
+
+	return page, nil
+}
+
+func LikedQuotesLibraryView(result library.LikedQuotesResult, baseImgUrl string) (*LibraryPage, error) {
+	page, err := newLibraryPage("/library/quotes", result.Metadata, result.Filter, likeSortOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, q := range result.Quotes {
+		item, err := libraryItemView(q.Sketch, q.LikedAt, baseImgUrl)
+		if err != nil {
+			return nil, err
+		}
+		if q.Quote != nil {
+			item.Quote = safeDeref(q.Quote.Text)
+		}
+		page.Items = append(page.Items, item)
+	}
+
+	return page, nil
+}
+
+func newLibraryPage(
+	baseUrl string,
+	metadata models.Metadata,
+	filter *models.Filter,
+	sortOptions []struct{ Label, Sort string },
+) (*LibraryPage, error) {
+	page := &LibraryPage{
+		TotalCount:    metadata.TotalRecords,
+		ExportJSONUrl: "/library/export?format=json",
+		ExportCSVUrl:  "/library/export?format=csv",
+	}
+
+	for _, tab := range libraryTabs {
+		link := &LibraryLink{Label: tab.Label, Url: tab.Url, IsCurrent: tab.Url == baseUrl}
+		if link.IsCurrent {
+			page.Title = tab.Label
+		}
+		page.Tabs = append(page.Tabs, link)
+	}
+
+	for _, option := range sortOptions {
+		url, err := BuildURL(baseUrl, 1, &models.Filter{SortBy: option.Sort})
+		if err != nil {
+			return nil, err
+		}
+
+		page.SortOptions = append(page.SortOptions, &LibraryLink{
+			Label:     option.Label,
+			Url:       url,
+			IsCurrent: option.Sort == filter.SortBy,
+		})
+	}
+
+	var err error
+	page.Pages, err = buildPagination(metadata.CurrentPage, metadata.TotalPages, baseUrl, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func libraryItemView(sketch *models.SketchRef, date *time.Time, baseImgUrl string) (*LibraryItem, error) {
+	thumbnail, err := SketchThumbnailView(sketch, baseImgUrl, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("library item: %w", err)
+	}
+
+	return &LibraryItem{
+		Url:         thumbnail.Url,
+		Image:       thumbnail.Image,
+		Title:       thumbnail.Title,
+		CreatorName: thumbnail.CreatorName,
+		Date:        humanDate(date),
+	}, nil
+}
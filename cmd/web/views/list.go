@@ -0,0 +1,139 @@
+package views
+
+import (
+	"fmt"
+	"net/url"
+
+	"sketchdb.cozycole.net/internal/domain/lists"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type UserListsPage struct {
+	Username string
+	UserUrl  string
+	IsOwner  bool
+	Lists    []*ListCard
+}
+
+type ListCard struct {
+	Title       string
+	Description string
+	Url         string
+	Visibility  string
+	ItemCount   int
+	Updated     string
+}
+
+type ListPage struct {
+	Title       string
+	Description string
+	Username    string
+	UserUrl     string
+	ListsUrl    string
+	Visibility  string
+	IsOwner     bool
+	TotalCount  int
+	SortOptions []*LibraryLink
+	Items       []*ListItem
+	Pages       []*PaginationItem
+}
+
+// ListItem is a sketch in a list along with the owner's note on it
+type ListItem struct {
+	Sketch *SketchThumbnail
+	Note   string
+}
+
+var listSortOptions = []struct{ Label, Sort string }{
+	{"List Order", models.ListSort},
+	{"Popular", "popular"},
+	{"Recently Added", "recent"},
+	{"Newest Release", "newest"},
+	{"Oldest Release", "oldest"},
+	{"A-Z", "az"},
+	{"Z-A", "za"},
+}
+
+func ListUrl(list *models.SketchList) string {
+	return fmt.Sprintf(
+		"/user/%s/lists/%s",
+		url.PathEscape(safeDeref(list.Username)),
+		url.PathEscape(safeDeref(list.Slug)),
+	)
+}
+
+func UserListsView(user *models.User, sketchLists []*models.SketchList, isOwner bool) *UserListsPage {
+	username := safeDeref(user.Username)
+	page := &UserListsPage{
+		Username: username,
+		UserUrl:  "/user/" + url.PathEscape(username),
+		IsOwner:  isOwner,
+	}
+
+	for _, list := range sketchLists {
+		page.Lists = append(page.Lists, &ListCard{
+			Title:       safeDeref(list.Title),
+			Description: safeDeref(list.Description),
+			Url:         ListUrl(list),
+			Visibility:  safeDeref(list.Visibility),
+			ItemCount:   safeDeref(list.ItemCount),
+			Updated:     humanDate(list.UpdatedAt),
+		})
+	}
+
+	return page
+}
+
+func ListPageView(result lists.ListSketchesResult, isOwner bool, baseImgUrl string) (*ListPage, error) {
+	list := result.List
+	username := safeDeref(list.Username)
+	listUrl := ListUrl(list)
+
+	page := &ListPage{
+		Title:       safeDeref(list.Title),
+		Description: safeDeref(list.Description),
+		Username:    username,
+		UserUrl:     "/user/" + url.PathEscape(username),
+		ListsUrl:    "/user/" + url.PathEscape(username) + "/lists",
+		Visibility:  safeDeref(list.Visibility),
+		IsOwner:     isOwner,
+		TotalCount:  result.Metadata.TotalRecords,
+	}
+
+	for _, option := range listSortOptions {
+		sortUrl, err := BuildURL(listUrl, 1, &models.Filter{SortBy: option.Sort})
+		if err != nil {
+			return nil, err
+		}
+
+		page.SortOptions = append(page.SortOptions, &LibraryLink{
+			Label:     option.Label,
+			Url:       sortUrl,
+			IsCurrent: option.Sort == result.Filter.SortBy,
+		})
+	}
+
+	thumbnails, err := SketchThumbnailsView(result.Sketches, baseImgUrl, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, thumbnail := range thumbnails {
+		page.Items = append(page.Items, &ListItem{
+			Sketch: thumbnail,
+			Note:   result.Notes[safeDeref(result.Sketches[i].ID)],
+		})
+	}
+
+	page.Pages, err = buildPagination(
+		result.Metadata.CurrentPage,
+		result.Metadata.TotalPages,
+		listUrl,
+		result.Filter,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
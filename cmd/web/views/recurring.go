@@ -1,6 +1,7 @@
 package views
 
 import (
+	"errors"
 	"fmt"
 
 	"sketchdb.cozycole.net/internal/models"
@@ -45,3 +46,42 @@ func RecurringPageView(recurring *models.Recurring, baseImgUrl string) (*Recurri
 	page.Sketches = sketches
 	return &page, nil
 }
+
+type RecurringGallery struct {
+	Cards []*Card
+}
+
+func RecurringGalleryView(recurring []*models.RecurringRef, baseImgUrl string) (*RecurringGallery, error) {
+	gallery := RecurringGallery{}
+
+	for _, r := range recurring {
+		card, err := RecurringCardView(r, baseImgUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		gallery.Cards = append(gallery.Cards, card)
+	}
+
+	return &gallery, nil
+}
+
+func RecurringCardView(recurring *models.RecurringRef, baseImgUrl string) (*Card, error) {
+	if recurring.ID == nil {
+		return nil, errors.New("Recurring ID not defined")
+	}
+
+	if recurring.Slug == nil {
+		return nil, errors.New("Recurring slug not defined")
+	}
+
+	card := &Card{}
+	card.Title = safeDeref(recurring.Title)
+	card.Url = fmt.Sprintf("/recurring/%d/%s", *recurring.ID, *recurring.Slug)
+	card.ImageUrl = "/static/img/missing-profile.jpg"
+	if recurring.ThumbnailName != nil {
+		card.ImageUrl = fmt.Sprintf("%s/recurring/medium/%s", baseImgUrl, *recurring.ThumbnailName)
+	}
+
+	return card, nil
+}
@@ -240,6 +240,26 @@ func ShowGalleryView(shows []*models.Show, baseImgUrl string) (*ShowGallery, err
 	return &showGallery, nil
 }
 
+func ShowRefGalleryView(shows []*models.ShowRef, baseImgUrl string) (*ShowGallery, error) {
+	showGallery := ShowGallery{}
+
+	for _, show := range shows {
+		showCard, err := ShowCardView(&models.Show{
+			ID:         show.ID,
+			Slug:       show.Slug,
+			Name:       show.Name,
+			ProfileImg: show.ProfileImg,
+		}, baseImgUrl)
+		if err != nil {
+			return nil, err
+		}
+
+		showGallery.Cards = append(showGallery.Cards, showCard)
+	}
+
+	return &showGallery, nil
+}
+
 func ShowCardView(show *models.Show, baseImgUrl string) (*Card, error) {
 	card := &Card{}
 
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+// refreshWikiAPI starts refetching every person and show wikipedia extract
+// in the background, the report is logged when it finishes
+func (app *application) refreshWikiAPI(w http.ResponseWriter, r *http.Request) {
+	// the refresh outlives the request so it can't use its context
+	if !app.wikiRefresher.Start(context.Background()) {
+		app.errorResponse(w, r, http.StatusConflict, "a wiki refresh is already running")
+		return
+	}
+
+	err := app.writeJSON(w, http.StatusAccepted, envelope{"message": "wiki refresh started"}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -28,6 +28,24 @@ const STATUS_CONFIG = {
     dot: "bg-yellow-400",
     pulse: true,
   },
+  running: {
+    label: "Processing",
+    color: "bg-orange-500/15 text-orange-800 border-orange-500/30",
+    dot: "bg-orange-400",
+    pulse: true,
+  },
+  done: {
+    label: "Pipeline Complete",
+    color: "bg-emerald-500/15 text-emerald-800 border-emerald-500/30",
+    dot: "bg-emerald-400",
+    pulse: false,
+  },
+  failed: {
+    label: "Pipeline Failed",
+    color: "bg-red-500/15 text-red-800 border-red-500/30",
+    dot: "bg-red-400",
+    pulse: false,
+  },
   processing: {
     label: "Processing",
     color: "bg-orange-500/15 text-orange-800 border-orange-500/30",
@@ -344,7 +362,12 @@ export function SketchVideoUpload({ sketchId }: SketchVideoUploadProps) {
   const shouldPoll = useCallback((data: VideosResponse | undefined) => {
     if (!data) return false;
     return data.videos.some((v) =>
-      v.jobs.some((j) => j.status === "pending" || j.status === "processing"),
+      v.jobs.some(
+        (j) =>
+          j.status === "pending" ||
+          j.status === "running" ||
+          j.status === "processing",
+      ),
     );
   }, []);
 
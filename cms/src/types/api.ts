@@ -40,13 +40,50 @@ export type SketchVideo = {
   hotS3Key: string;
   coldS3Key: string;
   archivedAt: string;
+  hotRestoredAt: string;
+  isPrimary: boolean;
+  source: string | null;
+  width: number | null;
+  height: number | null;
+  sizeBytes: number | null;
+  contentType: string | null;
+  container: string | null;
+  durationMs: number | null;
+  videoCodec: string | null;
+  audioCodec: string | null;
+  probedAt: string | null;
+  createdAt: string;
   jobs: PipelineJob[];
 };
 
+export type VideoUpload = {
+  id: number;
+  sketchId: number;
+  s3Key: string;
+  fileName: string;
+  contentType: string;
+  fileSize: number;
+  partSize: number;
+  createdAt: string;
+  updatedAt: string;
+};
+
+export type UploadPart = {
+  partNumber: number;
+  etag: string;
+  size: number;
+};
+
 export type PipelineJob = {
   id: number;
+  videoId: number;
   status: string;
   error: string;
+  attempts: number;
+  runAfter: string;
+  startedAt: string;
+  finishedAt: string;
+  createdAt: string;
 };
 
 export type CastMember = {
@@ -254,6 +291,66 @@ export type TranscriptLine = {
   id: number;
   lineNumber: number;
   text: string;
+  speaker: string;
   startMs: number;
   endMs: number;
 };
+
+export type HomeSection =
+  | "featured_sketches"
+  | "spotlight_people"
+  | "shows"
+  | "recurring";
+
+export type HomeSlot = {
+  id: number;
+  section: HomeSection;
+  entityId: number;
+  position: number;
+  startsAt: Date | null;
+  endsAt: Date | null;
+  createdAt: Date;
+};
+
+export type SketchFilter = {
+  page: number;
+  pageSize: number;
+  query: string;
+  type: string;
+  characterIds: number[] | null;
+  creatorIds: number[] | null;
+  personIds: number[] | null;
+  sketchIds: number[] | null;
+  showIds: number[] | null;
+  tagIds: number[] | null;
+  listId: number;
+  sortBy: string;
+};
+
+export type BrowseSection = {
+  id: number;
+  title: string;
+  filter: SketchFilter;
+  position: number;
+  startsAt: Date | null;
+  endsAt: Date | null;
+  createdAt: Date;
+  updatedAt: Date;
+};
+
+export type TokenScope =
+  | "read"
+  | "sketch:write"
+  | "media:upload"
+  | "user:write"
+  | "admin";
+
+export type ApiToken = {
+  id: number;
+  userId: number;
+  name: string;
+  scopes: TokenScope[];
+  createdAt: Date;
+  expiresAt: Date | null;
+  lastUsedAt: Date | null;
+};
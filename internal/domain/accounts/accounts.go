@@ -0,0 +1,148 @@
+package accounts
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"sketchdb.cozycole.net/internal/mailer"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+var ErrInvalidToken = errors.New("accounts: invalid or expired token")
+
+// SendActivation emails user a link to activate their account
+func (s *AccountService) SendActivation(user *models.User) error {
+	return s.sendToken(user, models.ScopeActivation, ActivationTTL, "/activate", "user_activation.tmpl")
+}
+
+// RequestActivation resends the activation email to the owner of email.
+// Unknown and already activated emails are ignored so the response doesn't
+// reveal which emails have accounts.
+func (s *AccountService) RequestActivation(email string) error {
+	user, err := s.Repos.Users.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return nil
+		}
+		return err
+	}
+
+	if safeDeref(user.Activated) {
+		return nil
+	}
+
+	return s.SendActivation(user)
+}
+
+// Activate activates the account the token was issued to and uses up all
+// of the user's activation tokens
+func (s *AccountService) Activate(plaintext string) (*models.User, error) {
+	user, err := s.tokenUser(models.ScopeActivation, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Repos.Users.Activate(*user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	activated := true
+	user.Activated = &activated
+
+	err = s.Repos.UserTokens.DeleteAllForUser(models.ScopeActivation, *user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RequestPasswordReset emails a reset link to the owner of email, like
+// RequestActivation unknown emails are ignored
+func (s *AccountService) RequestPasswordReset(email string) error {
+	user, err := s.Repos.Users.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return nil
+		}
+		return err
+	}
+
+	return s.sendToken(user, models.ScopePasswordReset, PasswordResetTTL, "/password-reset/confirm", "password_reset.tmpl")
+}
+
+// CheckPasswordReset reports whether a password reset token is still valid
+func (s *AccountService) CheckPasswordReset(plaintext string) error {
+	_, err := s.tokenUser(models.ScopePasswordReset, plaintext)
+	return err
+}
+
+// ResetPassword sets a new password for the user the token was issued to,
+// uses up all of the user's reset tokens and revokes their API tokens. The
+// caller has to end the user's sessions.
+func (s *AccountService) ResetPassword(plaintext, password string) (*models.User, error) {
+	user, err := s.tokenUser(models.ScopePasswordReset, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	err = user.Password.Set(password)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Repos.Users.ResetPassword(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *AccountService) tokenUser(scope, plaintext string) (*models.User, error) {
+	if plaintext == "" {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.Repos.UserTokens.GetUser(scope, plaintext)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *AccountService) sendToken(user *models.User, scope string, ttl time.Duration, path, templateFile string) error {
+	token, err := s.Repos.UserTokens.New(safeDeref(user.ID), ttl, scope)
+	if err != nil {
+		return fmt.Errorf("create %s token: %w", scope, err)
+	}
+
+	link := s.Origin + path + "?" + url.Values{"token": {token.Plaintext}}.Encode()
+	msg, err := mailer.NewMessage(safeDeref(user.Email), templateFile, map[string]any{
+		"Username":  safeDeref(user.Username),
+		"URL":       link,
+		"ExpiresIn": expiresIn(ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(msg)
+}
+
+func expiresIn(ttl time.Duration) string {
+	if ttl >= 24*time.Hour {
+		return fmt.Sprintf("%d days", int(ttl.Hours()/24))
+	}
+	if ttl >= time.Hour {
+		return fmt.Sprintf("%d hours", int(ttl.Hours()))
+	}
+	return fmt.Sprintf("%d minutes", int(ttl.Minutes()))
+}
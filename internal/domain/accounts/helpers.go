@@ -0,0 +1,9 @@
+package accounts
+
+func safeDeref[T any](ptr *T) T {
+	if ptr != nil {
+		return *ptr
+	}
+	var zero T
+	return zero
+}
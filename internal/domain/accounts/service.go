@@ -0,0 +1,21 @@
+package accounts
+
+import (
+	"time"
+
+	"sketchdb.cozycole.net/internal/mailer"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// AccountService handles the emailed token flows, Origin is the site url
+// the links in emails point to
+type AccountService struct {
+	Repos  models.Repositories
+	Mailer mailer.Mailer
+	Origin string
+}
+
+const (
+	ActivationTTL    = 3 * 24 * time.Hour
+	PasswordResetTTL = 45 * time.Minute
+)
@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"encoding/json"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+func (s *AuditService) GetEntry(id int) (*models.AuditEntry, error) {
+	return s.Repos.Audit.Get(id)
+}
+
+// EntityHistory returns a page of an entity's revisions, newest first
+func (s *AuditService) EntityHistory(entityType string, entityId int, f *models.Filter) ([]*models.AuditEntry, models.Metadata, error) {
+	return s.Repos.Audit.GetForEntity(entityType, entityId, pageFilter(f))
+}
+
+// ActorHistory returns a page of the writes made by a user, newest first
+func (s *AuditService) ActorHistory(actorId int, f *models.Filter) ([]*models.AuditEntry, models.Metadata, error) {
+	return s.Repos.Audit.GetForActor(actorId, pageFilter(f))
+}
+
+func pageFilter(f *models.Filter) *models.Filter {
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 {
+		f.PageSize = DefaultPageSize
+	}
+	f.PageSize = min(f.PageSize, MaxPageSize)
+	return f
+}
+
+// Revision returns the snapshot of an entity as it was after the write
+// recorded by entry id, for reverting the entity to it
+func Revision(repos models.Repositories, entityType string, entityId, entryId int) (json.RawMessage, error) {
+	entry, err := repos.Audit.Get(entryId)
+	if err != nil {
+		return nil, err
+	}
+
+	if safeDeref(entry.EntityType) != entityType || safeDeref(entry.EntityID) != entityId {
+		return nil, ErrWrongEntity
+	}
+
+	if entry.After == nil {
+		return nil, ErrNoSnapshot
+	}
+
+	return entry.After, nil
+}
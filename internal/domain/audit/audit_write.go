@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// Record adds an entry to the audit log for a write to an entity. before
+// and after are snapshots of the entity marshalled to JSON, either can be
+// nil for creates and deletes. An update that didn't change anything isn't
+// recorded.
+//
+// The write has already been made by the time it's recorded, so errors
+// wrap ErrNotRecorded and callers should still return the written entity.
+func Record(repos models.Repositories, actor *models.User, entityType string, entityId int, action string, before, after any) error {
+	err := record(repos, actor, entityType, entityId, action, before, after)
+	if err != nil {
+		return fmt.Errorf("%w: %s %s %d: %w", ErrNotRecorded, action, entityType, entityId, err)
+	}
+	return nil
+}
+
+func record(repos models.Repositories, actor *models.User, entityType string, entityId int, action string, before, after any) error {
+	beforeJSON, err := snapshot(before)
+	if err != nil {
+		return err
+	}
+
+	afterJSON, err := snapshot(after)
+	if err != nil {
+		return err
+	}
+
+	changes, err := Diff(beforeJSON, afterJSON)
+	if err != nil {
+		return err
+	}
+
+	if action == models.AuditUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	entry := &models.AuditEntry{
+		EntityType: &entityType,
+		EntityID:   &entityId,
+		Action:     &action,
+		Before:     beforeJSON,
+		After:      afterJSON,
+		Changes:    changesJSON,
+	}
+	if actor != nil {
+		entry.ActorID = actor.ID
+	}
+
+	return repos.Audit.Insert(entry)
+}
+
+func snapshot(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Equal(b, []byte("null")) {
+		return nil, nil
+	}
+	return b, nil
+}
+
+// Change is the before and after value of a changed field
+type Change struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// Diff compares two JSON objects field by field and returns the top level
+// fields that differ. A missing snapshot is treated as an empty object, so
+// creates and deletes list every field.
+func Diff(before, after json.RawMessage) (map[string]Change, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]Change{}
+	for key, bv := range b {
+		av, ok := a[key]
+		if !ok || !jsonEqual(bv, av) {
+			changes[key] = Change{Before: bv, After: av}
+		}
+	}
+	for key, av := range a {
+		if _, ok := b[key]; !ok {
+			changes[key] = Change{After: av}
+		}
+	}
+
+	return changes, nil
+}
+
+func fields(doc json.RawMessage) (map[string]json.RawMessage, error) {
+	m := map[string]json.RawMessage{}
+	if doc == nil {
+		return m, nil
+	}
+
+	if err := json.Unmarshal(doc, &m); err != nil {
+		return nil, fmt.Errorf("audit snapshot isn't a JSON object: %w", err)
+	}
+	return m, nil
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
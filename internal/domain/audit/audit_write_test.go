@@ -0,0 +1,240 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"sketchdb.cozycole.net/internal/assert"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// fakeAuditModel keeps the entries inserted into it
+type fakeAuditModel struct {
+	entries []*models.AuditEntry
+	err     error
+}
+
+func (m *fakeAuditModel) Get(id int) (*models.AuditEntry, error) {
+	return nil, models.ErrNoRecord
+}
+
+func (m *fakeAuditModel) GetForActor(actorId int, f *models.Filter) ([]*models.AuditEntry, models.Metadata, error) {
+	return nil, models.Metadata{}, nil
+}
+
+func (m *fakeAuditModel) GetForEntity(entityType string, entityId int, f *models.Filter) ([]*models.AuditEntry, models.Metadata, error) {
+	return nil, models.Metadata{}, nil
+}
+
+func (m *fakeAuditModel) Insert(entry *models.AuditEntry) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+// changeStrings flattens changes so they can be compared, a missing side
+// is an empty string
+func changeStrings(changes map[string]Change) map[string][2]string {
+	flat := map[string][2]string{}
+	for key, c := range changes {
+		flat[key] = [2]string{string(c.Before), string(c.After)}
+	}
+	return flat
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  string
+		after   string
+		want    map[string][2]string
+		wantErr bool
+	}{
+		{
+			name: "Both Missing",
+			want: map[string][2]string{},
+		},
+		{
+			name:  "Create",
+			after: `{"name":"Tim","age":40}`,
+			want: map[string][2]string{
+				"name": {"", `"Tim"`},
+				"age":  {"", `40`},
+			},
+		},
+		{
+			name:   "Delete",
+			before: `{"name":"Tim","age":40}`,
+			want: map[string][2]string{
+				"name": {`"Tim"`, ""},
+				"age":  {`40`, ""},
+			},
+		},
+		{
+			name:   "Unchanged",
+			before: `{"name": "Tim", "tags": [1, 2]}`,
+			after:  `{"name":"Tim","tags":[1,2]}`,
+			want:   map[string][2]string{},
+		},
+		{
+			name:   "Changed Added And Removed",
+			before: `{"name":"Tim","age":40,"alias":"T"}`,
+			after:  `{"name":"Tim R","age":40,"wiki":"x"}`,
+			want: map[string][2]string{
+				"name":  {`"Tim"`, `"Tim R"`},
+				"alias": {`"T"`, ""},
+				"wiki":  {"", `"x"`},
+			},
+		},
+		{
+			name:   "Null To Value",
+			before: `{"alias":null}`,
+			after:  `{"alias":"T"}`,
+			want: map[string][2]string{
+				"alias": {`null`, `"T"`},
+			},
+		},
+		{
+			name:    "Not An Object",
+			before:  `[1,2]`,
+			after:   `{"name":"Tim"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before, after json.RawMessage
+			if tt.before != "" {
+				before = json.RawMessage(tt.before)
+			}
+			if tt.after != "" {
+				after = json.RawMessage(tt.after)
+			}
+
+			changes, err := Diff(before, after)
+			assert.Equal(t, err != nil, tt.wantErr)
+			if tt.wantErr {
+				return
+			}
+			assert.DeepEqual(t, changeStrings(changes), tt.want)
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	actorId := 7
+	actor := &models.User{ID: &actorId}
+
+	type snap struct {
+		Name string `json:"name"`
+	}
+	var nilSnap *snap
+
+	tests := []struct {
+		name        string
+		actor       *models.User
+		action      string
+		before      any
+		after       any
+		insertErr   error
+		wantEntry   bool
+		wantChanges map[string][2]string
+		wantErr     bool
+	}{
+		{
+			name:        "Create",
+			actor:       actor,
+			action:      models.AuditCreate,
+			after:       snap{Name: "Tim"},
+			wantEntry:   true,
+			wantChanges: map[string][2]string{"name": {`null`, `"Tim"`}},
+		},
+		{
+			name:        "Delete",
+			actor:       actor,
+			action:      models.AuditDelete,
+			before:      snap{Name: "Tim"},
+			wantEntry:   true,
+			wantChanges: map[string][2]string{"name": {`"Tim"`, `null`}},
+		},
+		{
+			name:        "Update",
+			actor:       actor,
+			action:      models.AuditUpdate,
+			before:      snap{Name: "Tim"},
+			after:       snap{Name: "Tim R"},
+			wantEntry:   true,
+			wantChanges: map[string][2]string{"name": {`"Tim"`, `"Tim R"`}},
+		},
+		{
+			name:   "Unchanged Update Skipped",
+			actor:  actor,
+			action: models.AuditUpdate,
+			before: snap{Name: "Tim"},
+			after:  &snap{Name: "Tim"},
+		},
+		{
+			name:        "Unchanged Revert Recorded",
+			actor:       actor,
+			action:      models.AuditRevert,
+			before:      snap{Name: "Tim"},
+			after:       snap{Name: "Tim"},
+			wantEntry:   true,
+			wantChanges: map[string][2]string{},
+		},
+		{
+			name:        "Nil Pointer Snapshot",
+			action:      models.AuditCreate,
+			before:      nilSnap,
+			after:       snap{Name: "Tim"},
+			wantEntry:   true,
+			wantChanges: map[string][2]string{"name": {`null`, `"Tim"`}},
+		},
+		{
+			name:      "Insert Fails",
+			actor:     actor,
+			action:    models.AuditCreate,
+			after:     snap{Name: "Tim"},
+			insertErr: errors.New("db down"),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditModel := &fakeAuditModel{err: tt.insertErr}
+			repos := models.Repositories{Audit: auditModel}
+
+			err := Record(repos, tt.actor, models.AuditPerson, 3, tt.action, tt.before, tt.after)
+			assert.Equal(t, err != nil, tt.wantErr)
+			if tt.wantErr {
+				assert.Equal(t, errors.Is(err, ErrNotRecorded), true)
+				return
+			}
+
+			assert.Equal(t, len(auditModel.entries) == 1, tt.wantEntry)
+			if !tt.wantEntry {
+				return
+			}
+
+			entry := auditModel.entries[0]
+			assert.Equal(t, *entry.EntityType, models.AuditPerson)
+			assert.Equal(t, *entry.EntityID, 3)
+			assert.Equal(t, *entry.Action, tt.action)
+			assert.Equal(t, entry.ActorID == nil, tt.actor == nil)
+			assert.Equal(t, entry.Before == nil, tt.before == nil || tt.before == any(nilSnap))
+			assert.Equal(t, entry.After == nil, tt.after == nil)
+
+			// a missing side is stored as null
+			var changes map[string]Change
+			if err := json.Unmarshal(entry.Changes, &changes); err != nil {
+				t.Fatal(err)
+			}
+			assert.DeepEqual(t, changeStrings(changes), tt.wantChanges)
+		})
+	}
+}
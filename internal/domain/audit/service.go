@@ -0,0 +1,24 @@
+package audit
+
+import (
+	"errors"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type AuditService struct {
+	Repos models.Repositories
+}
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+var (
+	// ErrNotRecorded is returned when a write succeeded but couldn't be
+	// added to the audit log
+	ErrNotRecorded = errors.New("audit: write not recorded")
+	ErrWrongEntity = errors.New("audit: revision belongs to another entity")
+	ErrNoSnapshot  = errors.New("audit: revision has no snapshot to revert to")
+)
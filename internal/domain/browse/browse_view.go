@@ -0,0 +1,46 @@
+package browse
+
+import (
+	"fmt"
+	"time"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type SectionResult struct {
+	Section  *models.BrowseSection
+	Sketches []*models.SketchRef
+}
+
+// GetBrowseSections returns the currently scheduled sections along with
+// the sketches matching each one's filter
+func (s *BrowseService) GetBrowseSections() ([]*SectionResult, error) {
+	sections, err := s.Repos.BrowseSections.GetVisible(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	results := []*SectionResult{}
+	for _, section := range sections {
+		if section.Filter == nil {
+			continue
+		}
+
+		sketches, _, err := s.Repos.Sketches.Get(section.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("browse section %d: %w", safeDeref(section.ID), err)
+		}
+
+		results = append(results, &SectionResult{section, sketches})
+	}
+
+	return results, nil
+}
+
+func (s *BrowseService) GetSection(id int) (*models.BrowseSection, error) {
+	return s.Repos.BrowseSections.Get(id)
+}
+
+func (s *BrowseService) ListSections() ([]*models.BrowseSection, error) {
+	return s.Repos.BrowseSections.List()
+}
@@ -0,0 +1,87 @@
+package browse
+
+import (
+	"errors"
+	"fmt"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+var (
+	ErrInvalidFilter       = errors.New("browse: filter could not be executed")
+	ErrEmptyFilter         = errors.New("browse: filter does not match any sketches")
+	ErrInvalidSectionOrder = errors.New("browse: section order must contain every section")
+)
+
+func (s *BrowseService) CreateSection(section *models.BrowseSection) error {
+	err := s.checkFilter(section.Filter)
+	if err != nil {
+		return err
+	}
+
+	return s.Repos.BrowseSections.Insert(section)
+}
+
+func (s *BrowseService) UpdateSection(section *models.BrowseSection) error {
+	err := s.checkFilter(section.Filter)
+	if err != nil {
+		return err
+	}
+
+	return s.Repos.BrowseSections.Update(section)
+}
+
+func (s *BrowseService) DeleteSection(id int) error {
+	return s.Repos.BrowseSections.Delete(id)
+}
+
+// ReorderSections sets the section positions to the order of sectionIds
+func (s *BrowseService) ReorderSections(sectionIds []int) error {
+	sections, err := s.Repos.BrowseSections.List()
+	if err != nil {
+		return err
+	}
+
+	if len(sections) != len(sectionIds) {
+		return ErrInvalidSectionOrder
+	}
+
+	existing := map[int]bool{}
+	for _, section := range sections {
+		existing[safeDeref(section.ID)] = true
+	}
+
+	for _, id := range sectionIds {
+		if !existing[id] {
+			return ErrInvalidSectionOrder
+		}
+		delete(existing, id)
+	}
+
+	return s.Repos.BrowseSections.UpdatePositions(sectionIds)
+}
+
+// checkFilter normalizes the filter to the first page of results and runs
+// it, so a filter that errors or matches nothing is never saved
+func (s *BrowseService) checkFilter(f *models.Filter) error {
+	if f == nil {
+		return ErrInvalidFilter
+	}
+
+	f.Page = 1
+	if f.PageSize < 1 {
+		f.PageSize = DefaultPageSize
+	}
+	f.PageSize = min(f.PageSize, MaxPageSize)
+
+	sketches, _, err := s.Repos.Sketches.Get(f)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidFilter, err)
+	}
+
+	if len(sketches) == 0 {
+		return ErrEmptyFilter
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package browse
+
+import (
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type BrowseService struct {
+	Repos models.Repositories
+}
+
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 50
+)
@@ -3,12 +3,13 @@ package casts
 import (
 	"fmt"
 
+	"sketchdb.cozycole.net/internal/domain/audit"
 	"sketchdb.cozycole.net/internal/media"
 	"sketchdb.cozycole.net/internal/models"
 	"sketchdb.cozycole.net/internal/utils"
 )
 
-func (s *CastService) ReorderCast(sketchId int, castIds []int) error {
+func (s *CastService) ReorderCast(actor *models.User, sketchId int, castIds []int) error {
 	cast, err := s.Repos.Cast.GetCastMembers(sketchId)
 	if err != nil {
 		return err
@@ -19,10 +20,33 @@ func (s *CastService) ReorderCast(sketchId int, castIds []int) error {
 		return err
 	}
 
-	return s.Repos.Cast.UpdatePositions(castIds)
+	err = s.Repos.Cast.UpdatePositions(castIds)
+	if err != nil {
+		return err
+	}
+
+	reordered, err := s.Repos.Cast.GetCastMembers(sketchId)
+	if err != nil {
+		return err
+	}
+
+	// one entry per member that moved, Record skips the rest
+	before := map[int]*models.CastMember{}
+	for _, cm := range cast {
+		before[utils.SafeDeref(cm.ID)] = cm
+	}
+	for _, cm := range reordered {
+		id := utils.SafeDeref(cm.ID)
+		err = audit.Record(s.Repos, actor, models.AuditCastMember, id, models.AuditUpdate, before[id], cm)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (s *CastService) CreateCastMember(cm *models.CastMember, thumbnail []byte, profile []byte, cropBorder bool) (*models.CastMember, error) {
+func (s *CastService) CreateCastMember(actor *models.User, cm *models.CastMember, thumbnail []byte, profile []byte, cropBorder bool) (*models.CastMember, error) {
 	if cm.SketchID == nil {
 		return nil, fmt.Errorf("sketch id not defined in cast member input")
 	}
@@ -98,10 +122,15 @@ func (s *CastService) CreateCastMember(cm *models.CastMember, thumbnail []byte,
 		return nil, err
 	}
 
+	err = audit.Record(s.Repos, actor, models.AuditCastMember, *cm.ID, models.AuditCreate, nil, newMember)
+	if err != nil {
+		return newMember, err
+	}
+
 	return newMember, nil
 }
 
-func (s *CastService) UpdateCastMember(cm *models.CastMember, thumbnail []byte, profile []byte, cropBorder bool) (*models.CastMember, error) {
+func (s *CastService) UpdateCastMember(actor *models.User, cm *models.CastMember, thumbnail []byte, profile []byte, cropBorder bool) (*models.CastMember, error) {
 	if cm.ID == nil {
 		return nil, fmt.Errorf("no id specified for cast member update")
 	}
@@ -173,10 +202,15 @@ func (s *CastService) UpdateCastMember(cm *models.CastMember, thumbnail []byte,
 		return nil, err
 	}
 
+	err = audit.Record(s.Repos, actor, models.AuditCastMember, *cm.ID, models.AuditUpdate, staleCast, newMember)
+	if err != nil {
+		return newMember, err
+	}
+
 	return newMember, nil
 }
 
-func (s *CastService) DeleteCastmember(id int) error {
+func (s *CastService) DeleteCastmember(actor *models.User, id int) error {
 	castMember, err := s.Repos.Cast.GetById(id)
 	if err != nil {
 		return err
@@ -194,5 +228,5 @@ func (s *CastService) DeleteCastmember(id int) error {
 		media.DeleteImageVariants(s.ImgStore, "cast/profile", *castMember.ProfileImg)
 	}
 
-	return nil
+	return audit.Record(s.Repos, actor, models.AuditCastMember, id, models.AuditDelete, castMember, nil)
 }
@@ -0,0 +1,40 @@
+package characters
+
+import (
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+func (s *CharacterService) CreateCharacter(actor *models.User, character *models.Character) (int, error) {
+	id, err := s.Repos.Characters.Insert(character)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := s.Repos.Characters.GetById(id)
+	if err != nil {
+		return id, err
+	}
+
+	return id, audit.Record(s.Repos, actor, models.AuditCharacter, id, models.AuditCreate, nil, created)
+}
+
+func (s *CharacterService) UpdateCharacter(actor *models.User, character *models.Character) error {
+	id := safeDeref(character.ID)
+	staleCharacter, err := s.Repos.Characters.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.Repos.Characters.Update(character)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.Repos.Characters.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	return audit.Record(s.Repos, actor, models.AuditCharacter, id, models.AuditUpdate, staleCharacter, updated)
+}
@@ -0,0 +1,30 @@
+package characters
+
+import (
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/domain/shared"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// DeleteCharacter deletes a character and its image. Portrayals and browse
+// sections still referencing the character block the delete unless opts
+// reassigns them to another character or detaches them.
+func (s *CharacterService) DeleteCharacter(actor *models.User, id int, opts shared.DeleteOptions) (*shared.DeleteReport, error) {
+	character, err := s.Repos.Characters.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := shared.Delete(s.Repos.Characters, id, opts)
+	if err != nil || opts.DryRun {
+		return report, err
+	}
+
+	err = report.DeleteImage(s.ImgStore, "character", character.Image)
+	if err != nil {
+		return report, err
+	}
+
+	err = audit.Record(s.Repos, actor, models.AuditCharacter, id, models.AuditDelete, character, nil)
+	return report, err
+}
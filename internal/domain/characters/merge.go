@@ -0,0 +1,75 @@
+package characters
+
+import (
+	"errors"
+
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/domain/shared"
+	"sketchdb.cozycole.net/internal/media"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type MergeReport struct {
+	Merged       *models.Character   `json:"merged"`
+	Into         *models.Character   `json:"into"`
+	Rows         *models.MergeResult `json:"rows"`
+	DeletedImage string              `json:"deletedImage,omitempty"`
+	DryRun       bool                `json:"dryRun"`
+}
+
+// MergeCharacters merges the duplicate character fromId into intoId. Cast
+// members and browse references move to intoId, which gains fromId's name
+// and aliases as aliases. fromId is deleted along with whichever image
+// isn't kept, and its id redirects to intoId. A dry run reports what
+// would change.
+func (s *CharacterService) MergeCharacters(actor *models.User, fromId, intoId int, opts shared.MergeOptions) (*MergeReport, error) {
+	if fromId == intoId {
+		return nil, shared.ErrSelfMerge
+	}
+
+	from, err := s.Repos.Characters.GetById(fromId)
+	if err != nil {
+		return nil, err
+	}
+
+	into, err := s.Repos.Characters.GetById(intoId)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := *into
+	aliases := shared.MergeAliases(
+		safeDeref(into.Name),
+		safeDeref(into.Aliases), safeDeref(from.Name), safeDeref(from.Aliases),
+	)
+	merged.Aliases = &aliases
+
+	report := &MergeReport{Merged: from, Into: &merged, DryRun: opts.DryRun}
+	report.DeletedImage = safeDeref(from.Image)
+	if opts.KeepMergedImage && from.Image != nil {
+		merged.Image = from.Image
+		report.DeletedImage = safeDeref(into.Image)
+	}
+
+	report.Rows, err = s.Repos.Characters.Merge(fromId, &merged, opts.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	if report.DeletedImage != "" {
+		err = media.DeleteImageVariants(s.ImgStore, "character", report.DeletedImage)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	err = errors.Join(
+		audit.Record(s.Repos, actor, models.AuditCharacter, fromId, models.AuditDelete, from, nil),
+		audit.Record(s.Repos, actor, models.AuditCharacter, intoId, models.AuditUpdate, into, &merged),
+	)
+	return report, err
+}
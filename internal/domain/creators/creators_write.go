@@ -0,0 +1,40 @@
+package creators
+
+import (
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+func (s *CreatorService) CreateCreator(actor *models.User, creator *models.Creator) (int, error) {
+	id, err := s.Repos.Creators.Insert(creator)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := s.Repos.Creators.GetById(id)
+	if err != nil {
+		return id, err
+	}
+
+	return id, audit.Record(s.Repos, actor, models.AuditCreator, id, models.AuditCreate, nil, created)
+}
+
+func (s *CreatorService) UpdateCreator(actor *models.User, creator *models.Creator) error {
+	id := safeDeref(creator.ID)
+	staleCreator, err := s.Repos.Creators.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.Repos.Creators.Update(creator)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.Repos.Creators.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	return audit.Record(s.Repos, actor, models.AuditCreator, id, models.AuditUpdate, staleCreator, updated)
+}
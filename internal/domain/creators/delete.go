@@ -0,0 +1,31 @@
+package creators
+
+import (
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/domain/shared"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// DeleteCreator deletes a creator and their profile image. Credited
+// sketches, groupings and browse sections still referencing the creator
+// block the delete unless opts reassigns them to another creator or
+// detaches them.
+func (s *CreatorService) DeleteCreator(actor *models.User, id int, opts shared.DeleteOptions) (*shared.DeleteReport, error) {
+	creator, err := s.Repos.Creators.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := shared.Delete(s.Repos.Creators, id, opts)
+	if err != nil || opts.DryRun {
+		return report, err
+	}
+
+	err = report.DeleteImage(s.ImgStore, "creator", creator.ProfileImage)
+	if err != nil {
+		return report, err
+	}
+
+	err = audit.Record(s.Repos, actor, models.AuditCreator, id, models.AuditDelete, creator, nil)
+	return report, err
+}
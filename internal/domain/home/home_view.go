@@ -0,0 +1,109 @@
+package home
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type HomeContent struct {
+	Featured  []*models.Sketch
+	People    []*models.Person
+	Shows     []*models.ShowRef
+	Recurring []*models.RecurringRef
+}
+
+// GetHomeContent returns the entities scheduled in each homepage section,
+// a section without any active slots is filled by popularity instead
+func (s *HomeService) GetHomeContent() (*HomeContent, error) {
+	now := time.Now()
+	content := &HomeContent{}
+
+	ids, err := s.sectionIds(models.HomeFeaturedSketches, now)
+	if err != nil {
+		return nil, err
+	}
+	content.Featured, err = s.Repos.Sketches.GetFeatured(ids)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		return nil, fmt.Errorf("get featured sketches: %w", err)
+	}
+
+	ids, err = s.sectionIds(models.HomeSpotlightPeople, now)
+	if err != nil {
+		return nil, err
+	}
+	people, err := s.Repos.People.GetPeople(ids)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		return nil, fmt.Errorf("get spotlight people: %w", err)
+	}
+	content.People = orderByIds(people, ids, func(p *models.Person) *int { return p.ID })
+
+	ids, err = s.sectionIds(models.HomeShows, now)
+	if err != nil {
+		return nil, err
+	}
+	shows, err := s.Repos.Shows.GetShowRefs(ids)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		return nil, fmt.Errorf("get home shows: %w", err)
+	}
+	content.Shows = orderByIds(shows, ids, func(sh *models.ShowRef) *int { return sh.ID })
+
+	ids, err = s.sectionIds(models.HomeRecurring, now)
+	if err != nil {
+		return nil, err
+	}
+	recurring, err := s.Repos.Recurring.GetRecurringRefs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("get home recurring: %w", err)
+	}
+	content.Recurring = orderByIds(recurring, ids, func(r *models.RecurringRef) *int { return r.ID })
+
+	return content, nil
+}
+
+func (s *HomeService) GetSlot(id int) (*models.HomeSlot, error) {
+	return s.Repos.HomeSlots.Get(id)
+}
+
+func (s *HomeService) ListSlots(section string) ([]*models.HomeSlot, error) {
+	return s.Repos.HomeSlots.List(section)
+}
+
+func (s *HomeService) sectionIds(section string, now time.Time) ([]int, error) {
+	ids, err := s.Repos.HomeSlots.GetActiveIDs(section, now)
+	if err != nil {
+		return nil, fmt.Errorf("get %s slots: %w", section, err)
+	}
+
+	if len(ids) > 0 {
+		return ids, nil
+	}
+
+	ids, err = s.Repos.HomeSlots.GetPopularIDs(section, FallbackSizes[section])
+	if err != nil {
+		return nil, fmt.Errorf("get popular %s: %w", section, err)
+	}
+
+	return ids, nil
+}
+
+// orderByIds sorts items into the order of ids. The same entity can be
+// scheduled in more than one slot of a section, it's only shown once.
+func orderByIds[T any](items []T, ids []int, id func(T) *int) []T {
+	byId := make(map[int]T, len(items))
+	for _, item := range items {
+		byId[safeDeref(id(item))] = item
+	}
+
+	ordered := make([]T, 0, len(items))
+	for _, i := range ids {
+		if item, ok := byId[i]; ok {
+			ordered = append(ordered, item)
+			delete(byId, i)
+		}
+	}
+
+	return ordered
+}
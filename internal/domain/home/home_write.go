@@ -0,0 +1,77 @@
+package home
+
+import (
+	"errors"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+var (
+	ErrEntityNotFound   = errors.New("home: slot entity does not exist")
+	ErrInvalidSlotOrder = errors.New("home: slot order must contain every slot in the section")
+)
+
+func (s *HomeService) AddSlot(slot *models.HomeSlot) error {
+	err := s.checkEntity(safeDeref(slot.Section), safeDeref(slot.EntityID))
+	if err != nil {
+		return err
+	}
+
+	return s.Repos.HomeSlots.Insert(slot)
+}
+
+// UpdateSlot changes the entity, position or schedule of a slot. A slot
+// can't be moved to another section.
+func (s *HomeService) UpdateSlot(slot *models.HomeSlot) error {
+	err := s.checkEntity(safeDeref(slot.Section), safeDeref(slot.EntityID))
+	if err != nil {
+		return err
+	}
+
+	return s.Repos.HomeSlots.Update(slot)
+}
+
+func (s *HomeService) DeleteSlot(id int) error {
+	return s.Repos.HomeSlots.Delete(id)
+}
+
+// ReorderSlots sets the positions of a section's slots to the order of
+// slotIds
+func (s *HomeService) ReorderSlots(section string, slotIds []int) error {
+	slots, err := s.Repos.HomeSlots.List(section)
+	if err != nil {
+		return err
+	}
+
+	if len(slots) != len(slotIds) {
+		return ErrInvalidSlotOrder
+	}
+
+	inSection := map[int]bool{}
+	for _, slot := range slots {
+		inSection[safeDeref(slot.ID)] = true
+	}
+
+	for _, id := range slotIds {
+		if !inSection[id] {
+			return ErrInvalidSlotOrder
+		}
+		// so a duplicated id fails
+		delete(inSection, id)
+	}
+
+	return s.Repos.HomeSlots.UpdatePositions(slotIds)
+}
+
+func (s *HomeService) checkEntity(section string, id int) error {
+	exists, err := s.Repos.HomeSlots.EntityExists(section, id)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrEntityNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package home
+
+import (
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type HomeService struct {
+	Repos models.Repositories
+}
+
+// FallbackSizes is how many of the most popular entities fill a section
+// when editors haven't scheduled any slots for it
+var FallbackSizes = map[string]int{
+	models.HomeFeaturedSketches: 5,
+	models.HomeSpotlightPeople:  9,
+	models.HomeShows:            12,
+	models.HomeRecurring:        12,
+}
@@ -0,0 +1,107 @@
+package library
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// Export is everything a user has rated and liked
+type Export struct {
+	Username      string                `json:"username"`
+	ExportedAt    time.Time             `json:"exportedAt"`
+	Ratings       []*models.RatedSketch `json:"ratings"`
+	LikedSketches []*models.LikedSketch `json:"likedSketches"`
+	LikedQuotes   []*models.LikedQuote  `json:"likedQuotes"`
+}
+
+func (s *LibraryService) Export(user *models.User) (*Export, error) {
+	userId := safeDeref(user.ID)
+	// a filter without a page size returns every row
+	all := &models.Filter{SortBy: "oldest"}
+
+	ratings, _, err := s.Repos.Library.GetRatings(userId, all)
+	if err != nil {
+		return nil, fmt.Errorf("export ratings: %w", err)
+	}
+
+	likes, _, err := s.Repos.Library.GetLikedSketches(userId, all)
+	if err != nil {
+		return nil, fmt.Errorf("export sketch likes: %w", err)
+	}
+
+	quotes, _, err := s.Repos.Library.GetLikedQuotes(userId, all)
+	if err != nil {
+		return nil, fmt.Errorf("export quote likes: %w", err)
+	}
+
+	return &Export{
+		Username:      safeDeref(user.Username),
+		ExportedAt:    time.Now().UTC(),
+		Ratings:       ratings,
+		LikedSketches: likes,
+		LikedQuotes:   quotes,
+	}, nil
+}
+
+var csvHeader = []string{
+	"type", "date", "sketch_id", "sketch_title", "sketch_slug",
+	"rating", "quote_id", "quote_text",
+}
+
+// WriteCSV writes the export as a single csv, the type column is one of
+// rating, sketch_like or quote_like
+func (e *Export) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, r := range e.Ratings {
+		record := sketchRecord("rating", r.RatedAt, r.Sketch)
+		record[5] = strconv.Itoa(safeDeref(r.Rating))
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	for _, l := range e.LikedSketches {
+		if err := cw.Write(sketchRecord("sketch_like", l.LikedAt, l.Sketch)); err != nil {
+			return err
+		}
+	}
+
+	for _, q := range e.LikedQuotes {
+		record := sketchRecord("quote_like", q.LikedAt, q.Sketch)
+		if q.Quote != nil {
+			record[6] = strconv.Itoa(safeDeref(q.Quote.ID))
+			record[7] = safeDeref(q.Quote.Text)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func sketchRecord(kind string, date *time.Time, sketch *models.SketchRef) []string {
+	record := make([]string, len(csvHeader))
+	record[0] = kind
+	if date != nil {
+		record[1] = date.UTC().Format(time.RFC3339)
+	}
+
+	if sketch != nil {
+		record[2] = strconv.Itoa(safeDeref(sketch.ID))
+		record[3] = safeDeref(sketch.Title)
+		record[4] = safeDeref(sketch.Slug)
+	}
+
+	return record
+}
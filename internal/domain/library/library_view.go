@@ -0,0 +1,61 @@
+package library
+
+import (
+	"fmt"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type RatingsResult struct {
+	Ratings  []*models.RatedSketch
+	Metadata models.Metadata
+	Filter   *models.Filter
+}
+
+type LikedSketchesResult struct {
+	Likes    []*models.LikedSketch
+	Metadata models.Metadata
+	Filter   *models.Filter
+}
+
+type LikedQuotesResult struct {
+	Quotes   []*models.LikedQuote
+	Metadata models.Metadata
+	Filter   *models.Filter
+}
+
+func (s *LibraryService) GetRatings(userId int, f *models.Filter) (RatingsResult, error) {
+	result := RatingsResult{Filter: f}
+	ratings, metadata, err := s.Repos.Library.GetRatings(userId, f)
+	if err != nil {
+		return result, fmt.Errorf("get user ratings error: %w", err)
+	}
+
+	result.Ratings = ratings
+	result.Metadata = metadata
+	return result, nil
+}
+
+func (s *LibraryService) GetLikedSketches(userId int, f *models.Filter) (LikedSketchesResult, error) {
+	result := LikedSketchesResult{Filter: f}
+	likes, metadata, err := s.Repos.Library.GetLikedSketches(userId, f)
+	if err != nil {
+		return result, fmt.Errorf("get user sketch likes error: %w", err)
+	}
+
+	result.Likes = likes
+	result.Metadata = metadata
+	return result, nil
+}
+
+func (s *LibraryService) GetLikedQuotes(userId int, f *models.Filter) (LikedQuotesResult, error) {
+	result := LikedQuotesResult{Filter: f}
+	quotes, metadata, err := s.Repos.Library.GetLikedQuotes(userId, f)
+	if err != nil {
+		return result, fmt.Errorf("get user quote likes error: %w", err)
+	}
+
+	result.Quotes = quotes
+	result.Metadata = metadata
+	return result, nil
+}
@@ -0,0 +1,9 @@
+package library
+
+import (
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type LibraryService struct {
+	Repos models.Repositories
+}
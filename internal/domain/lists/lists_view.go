@@ -0,0 +1,91 @@
+package lists
+
+import (
+	"fmt"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type ListSketchesResult struct {
+	List     *models.SketchList
+	Sketches []*models.SketchRef
+	// Notes maps a sketch id to the owner's note for it
+	Notes    map[int]string
+	Metadata models.Metadata
+	Filter   *models.Filter
+}
+
+// CanView reports whether viewer, who may be nil, can see the list.
+// Unlisted lists are visible to anyone with the link.
+func CanView(list *models.SketchList, viewer *models.User) bool {
+	if safeDeref(list.Visibility) != models.ListPrivate {
+		return true
+	}
+	return IsOwner(list, viewer)
+}
+
+// CanViewById reports whether viewer can see the list when it's looked up
+// by its id. Ids are sequential and would let unlisted lists be enumerated,
+// so only the owner reads a non-public list by id, anyone else has to
+// follow its slug.
+func CanViewById(list *models.SketchList, viewer *models.User) bool {
+	if safeDeref(list.Visibility) == models.ListPublic {
+		return true
+	}
+	return IsOwner(list, viewer)
+}
+
+func IsOwner(list *models.SketchList, user *models.User) bool {
+	return user != nil && user.ID != nil && safeDeref(list.UserID) == *user.ID
+}
+
+func (s *ListService) GetList(id int) (*models.SketchList, error) {
+	return s.Repos.SketchLists.GetById(id)
+}
+
+func (s *ListService) GetListBySlug(slug string) (*models.SketchList, error) {
+	return s.Repos.SketchLists.GetBySlug(slug)
+}
+
+// GetUserLists returns the lists of owner that viewer can browse, only the
+// owner sees their private and unlisted lists
+func (s *ListService) GetUserLists(owner, viewer *models.User) ([]*models.SketchList, error) {
+	visibilities := []string{models.ListPublic}
+	if viewer != nil && viewer.ID != nil && safeDeref(owner.ID) == *viewer.ID {
+		visibilities = append(visibilities, models.ListUnlisted, models.ListPrivate)
+	}
+
+	return s.Repos.SketchLists.GetByUser(safeDeref(owner.ID), visibilities)
+}
+
+func (s *ListService) GetItems(listId int) ([]*models.SketchListItem, error) {
+	return s.Repos.SketchLists.GetItems(listId)
+}
+
+// GetListSketches returns a page of the list's sketches, f can use any of
+// the catalog sorts or models.ListSort for the owner's order
+func (s *ListService) GetListSketches(list *models.SketchList, f *models.Filter) (ListSketchesResult, error) {
+	result := ListSketchesResult{List: list, Filter: f}
+	f.ListID = safeDeref(list.ID)
+
+	sketches, metadata, err := s.Repos.Sketches.Get(f)
+	if err != nil {
+		return result, fmt.Errorf("list sketches error: %w", err)
+	}
+
+	items, err := s.Repos.SketchLists.GetItems(f.ListID)
+	if err != nil {
+		return result, fmt.Errorf("list items error: %w", err)
+	}
+
+	result.Notes = map[int]string{}
+	for _, item := range items {
+		if note := safeDeref(item.Note); note != "" {
+			result.Notes[safeDeref(item.SketchID)] = note
+		}
+	}
+
+	result.Sketches = sketches
+	result.Metadata = metadata
+	return result, nil
+}
@@ -0,0 +1,104 @@
+package lists
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+var ErrInvalidItemOrder = errors.New("lists: item order must contain every sketch in the list")
+
+// CreateList saves a new list for user. The share slug is the title plus a
+// random suffix so it can't be guessed from the title alone.
+func (s *ListService) CreateList(user *models.User, list *models.SketchList) error {
+	list.UserID = user.ID
+	if safeDeref(list.Visibility) == "" {
+		visibility := models.ListPrivate
+		list.Visibility = &visibility
+	}
+
+	var err error
+	for range 3 {
+		var slug string
+		slug, err = shareSlug(safeDeref(list.Title))
+		if err != nil {
+			return err
+		}
+		list.Slug = &slug
+
+		err = s.Repos.SketchLists.Insert(list)
+		if !errors.Is(err, models.ErrDuplicateSlug) {
+			break
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	list.Username = user.Username
+	return nil
+}
+
+// UpdateList saves a list's title, description and visibility, the share
+// slug never changes so existing links keep working
+func (s *ListService) UpdateList(list *models.SketchList) error {
+	return s.Repos.SketchLists.Update(list)
+}
+
+func (s *ListService) DeleteList(id int) error {
+	return s.Repos.SketchLists.Delete(id)
+}
+
+func (s *ListService) AddItem(item *models.SketchListItem) error {
+	return s.Repos.SketchLists.AddItem(item)
+}
+
+func (s *ListService) UpdateItemNote(listId, sketchId int, note *string) error {
+	return s.Repos.SketchLists.UpdateItemNote(listId, sketchId, note)
+}
+
+func (s *ListService) RemoveItem(listId, sketchId int) error {
+	return s.Repos.SketchLists.RemoveItem(listId, sketchId)
+}
+
+// ReorderItems sets the item positions of a list to the order of sketchIds
+func (s *ListService) ReorderItems(listId int, sketchIds []int) error {
+	items, err := s.Repos.SketchLists.GetItems(listId)
+	if err != nil {
+		return err
+	}
+
+	if len(items) != len(sketchIds) {
+		return ErrInvalidItemOrder
+	}
+
+	existing := map[int]bool{}
+	for _, item := range items {
+		existing[safeDeref(item.SketchID)] = true
+	}
+
+	for _, id := range sketchIds {
+		if !existing[id] {
+			return ErrInvalidItemOrder
+		}
+		delete(existing, id)
+	}
+
+	return s.Repos.SketchLists.UpdatePositions(listId, sketchIds)
+}
+
+func shareSlug(title string) (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	suffix := hex.EncodeToString(b)
+	if slug := models.CreateSlugName(title); slug != "" {
+		return slug + "-" + suffix, nil
+	}
+	return suffix, nil
+}
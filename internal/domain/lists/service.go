@@ -0,0 +1,14 @@
+package lists
+
+import (
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type ListService struct {
+	Repos models.Repositories
+}
+
+const (
+	MaxTitleLength = 100
+	MaxNoteLength  = 500
+)
@@ -0,0 +1,31 @@
+package people
+
+import (
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/domain/shared"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// DeletePerson deletes a person and their profile image. Cast members,
+// characters, homepage slots and browse sections still referencing them
+// block the delete unless opts reassigns them to another person or
+// detaches them.
+func (s *PersonService) DeletePerson(actor *models.User, id int, opts shared.DeleteOptions) (*shared.DeleteReport, error) {
+	person, err := s.Repos.People.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := shared.Delete(s.Repos.People, id, opts)
+	if err != nil || opts.DryRun {
+		return report, err
+	}
+
+	err = report.DeleteImage(s.ImgStore, "person", person.ProfileImg)
+	if err != nil {
+		return report, err
+	}
+
+	err = audit.Record(s.Repos, actor, models.AuditPerson, id, models.AuditDelete, person, nil)
+	return report, err
+}
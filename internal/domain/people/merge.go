@@ -0,0 +1,80 @@
+package people
+
+import (
+	"errors"
+	"strings"
+
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/domain/shared"
+	"sketchdb.cozycole.net/internal/media"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type MergeReport struct {
+	Merged       *models.Person      `json:"merged"`
+	Into         *models.Person      `json:"into"`
+	Rows         *models.MergeResult `json:"rows"`
+	DeletedImage string              `json:"deletedImage,omitempty"`
+	DryRun       bool                `json:"dryRun"`
+}
+
+// MergePeople merges the duplicate person fromId into intoId. Cast
+// members, portrayed characters and homepage and browse references move
+// to intoId, which gains fromId's name and aliases as aliases. fromId is
+// deleted along with whichever profile image isn't kept, and its id
+// redirects to intoId. A dry run reports what would change.
+func (s *PersonService) MergePeople(actor *models.User, fromId, intoId int, opts shared.MergeOptions) (*MergeReport, error) {
+	if fromId == intoId {
+		return nil, shared.ErrSelfMerge
+	}
+
+	from, err := s.Repos.People.GetById(fromId)
+	if err != nil {
+		return nil, err
+	}
+
+	into, err := s.Repos.People.GetById(intoId)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := *into
+	aliases := shared.MergeAliases(
+		personName(into),
+		safeDeref(into.Alias), personName(from), safeDeref(from.Alias),
+	)
+	merged.Alias = &aliases
+
+	report := &MergeReport{Merged: from, Into: &merged, DryRun: opts.DryRun}
+	report.DeletedImage = safeDeref(from.ProfileImg)
+	if opts.KeepMergedImage && from.ProfileImg != nil {
+		merged.ProfileImg = from.ProfileImg
+		report.DeletedImage = safeDeref(into.ProfileImg)
+	}
+
+	report.Rows, err = s.Repos.People.Merge(fromId, &merged, opts.DryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	if report.DeletedImage != "" {
+		err = media.DeleteImageVariants(s.ImgStore, "person", report.DeletedImage)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	err = errors.Join(
+		audit.Record(s.Repos, actor, models.AuditPerson, fromId, models.AuditDelete, from, nil),
+		audit.Record(s.Repos, actor, models.AuditPerson, intoId, models.AuditUpdate, into, &merged),
+	)
+	return report, err
+}
+
+func personName(p *models.Person) string {
+	return strings.TrimSpace(safeDeref(p.First) + " " + safeDeref(p.Last))
+}
@@ -0,0 +1,166 @@
+package people
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/external/moviedb"
+	"sketchdb.cozycole.net/internal/media"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// maps TMDb's known_for_department to the wording used in person.professions
+var departmentProfessions = map[string]string{
+	"Acting":     "actor",
+	"Writing":    "writer",
+	"Directing":  "director",
+	"Production": "producer",
+	"Editing":    "editor",
+}
+
+var ErrTMDbDisabled = errors.New("people: tmdb client not configured")
+
+// LookupTMDb fetches a TMDb person by id without touching the database
+func (s *PersonService) LookupTMDb(tmdbId string) (*moviedb.Person, error) {
+	if s.TMDb == nil {
+		return nil, ErrTMDbDisabled
+	}
+	return s.TMDb.GetPerson(tmdbId)
+}
+
+// PrefillFromTMDb fetches the person referenced by person.TMDbID and fills in
+// any of biography, birth date, IMDb id and professions that are still blank.
+// Fields that already have a value are left alone. The fetched TMDb person is
+// returned so the caller can use its profile image and credits.
+func (s *PersonService) PrefillFromTMDb(person *models.Person) (*moviedb.Person, error) {
+	if s.TMDb == nil || safeDeref(person.TMDbID) == "" {
+		return nil, nil
+	}
+
+	tmdbPerson, err := s.TMDb.GetPerson(*person.TMDbID)
+	if err != nil {
+		return nil, fmt.Errorf("tmdb get person error: %w", err)
+	}
+
+	if strings.TrimSpace(safeDeref(person.Description)) == "" && tmdbPerson.Biography != "" {
+		person.Description = &tmdbPerson.Biography
+	}
+
+	if (person.BirthDate == nil || person.BirthDate.IsZero()) && tmdbPerson.BirthDate != nil {
+		person.BirthDate = tmdbPerson.BirthDate
+	}
+
+	if safeDeref(person.IMDbID) == "" && tmdbPerson.IMDbID != "" {
+		person.IMDbID = &tmdbPerson.IMDbID
+	}
+
+	if profession, ok := departmentProfessions[tmdbPerson.KnownForDepartment]; ok &&
+		strings.TrimSpace(safeDeref(person.Professions)) == "" {
+		person.Professions = &profession
+	}
+
+	return tmdbPerson, nil
+}
+
+// SaveTMDbProfileImage downloads the TMDb profile image and saves its
+// variants under /person. Returns the generated image name.
+func (s *PersonService) SaveTMDbProfileImage(tmdbPerson *moviedb.Person) (string, error) {
+	if s.TMDb == nil || tmdbPerson == nil || tmdbPerson.ProfilePath == "" {
+		return "", moviedb.ErrNotFound
+	}
+
+	img, err := s.TMDb.GetImage(tmdbPerson.ProfilePath)
+	if err != nil {
+		return "", fmt.Errorf("tmdb get image error: %w", err)
+	}
+
+	imgName, err := media.GenerateFileName(img)
+	if err != nil {
+		return "", err
+	}
+
+	err = media.RunImagePipeline(
+		img,
+		media.Large,
+		media.Profile,
+		imgName,
+		"/person",
+		s.ImgStore,
+		false,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return imgName, nil
+}
+
+func (s *PersonService) CreatePerson(actor *models.User, person *models.Person) (int, error) {
+	id, err := s.Repos.People.Insert(person)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := s.Repos.People.GetById(id)
+	if err != nil {
+		return id, err
+	}
+
+	return id, audit.Record(s.Repos, actor, models.AuditPerson, id, models.AuditCreate, nil, created)
+}
+
+func (s *PersonService) UpdatePerson(actor *models.User, person *models.Person) error {
+	return s.updatePerson(actor, models.AuditUpdate, person)
+}
+
+// RevertPerson restores a person's details to how they were after the
+// audited write entryId, keeping the current profile image as older
+// images may have been deleted
+func (s *PersonService) RevertPerson(actor *models.User, personId, entryId int) (*models.Person, error) {
+	current, err := s.Repos.People.GetById(personId)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := audit.Revision(s.Repos, models.AuditPerson, personId, entryId)
+	if err != nil {
+		return nil, err
+	}
+
+	var person models.Person
+	if err := json.Unmarshal(revision, &person); err != nil {
+		return nil, err
+	}
+
+	person.ID = &personId
+	person.ProfileImg = current.ProfileImg
+	err = s.updatePerson(actor, models.AuditRevert, &person)
+	if err != nil && !errors.Is(err, audit.ErrNotRecorded) {
+		return nil, err
+	}
+
+	return &person, err
+}
+
+func (s *PersonService) updatePerson(actor *models.User, action string, person *models.Person) error {
+	id := safeDeref(person.ID)
+	stalePerson, err := s.Repos.People.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.Repos.People.Update(person)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.Repos.People.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	return audit.Record(s.Repos, actor, models.AuditPerson, id, action, stalePerson, updated)
+}
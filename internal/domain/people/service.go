@@ -1,6 +1,7 @@
 package people
 
 import (
+	"sketchdb.cozycole.net/internal/external/moviedb"
 	"sketchdb.cozycole.net/internal/fileStore"
 	"sketchdb.cozycole.net/internal/models"
 )
@@ -8,4 +9,6 @@ import (
 type PersonService struct {
 	Repos    models.Repositories
 	ImgStore fileStore.FileStorageInterface
+	// TMDb is optional, person lookups are skipped when nil
+	TMDb moviedb.Client
 }
@@ -0,0 +1,6 @@
+package pipeline
+
+// JobCounts returns the number of pipeline jobs in each status
+func (s *PipelineService) JobCounts() (map[string]int, error) {
+	return s.Repos.Pipeline.CountByStatus()
+}
@@ -1,9 +1,16 @@
 package pipeline
 
-import "sketchdb.cozycole.net/internal/models"
+import (
+	"errors"
+	"time"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+var ErrJobQueued = errors.New("pipeline: video already has a queued job")
 
 func (s *PipelineService) AddPipelineJob(videoId int) (*models.PipelineJob, error) {
-	status := "pending"
+	status := models.PipelinePending
 	job := &models.PipelineJob{
 		Status: &status,
 	}
@@ -15,3 +22,47 @@ func (s *PipelineService) AddPipelineJob(videoId int) (*models.PipelineJob, erro
 
 	return job, nil
 }
+
+// ClaimJob returns the next runnable job along with the video it belongs to.
+// Returns models.ErrNoRecord when the queue is empty.
+func (s *PipelineService) ClaimJob(staleAfter time.Duration) (*models.PipelineJob, *models.SketchVideo, error) {
+	job, err := s.Repos.Pipeline.ClaimNext(staleAfter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	video, err := s.Repos.Sketches.GetVideo(*job.VideoID)
+	if err != nil {
+		return job, nil, err
+	}
+
+	return job, video, nil
+}
+
+func (s *PipelineService) CompleteJob(jobId int) error {
+	return s.Repos.Pipeline.Complete(jobId)
+}
+
+// FailJob records the job error and reschedules it for retryAt. A nil
+// retryAt marks the job as permanently failed.
+func (s *PipelineService) FailJob(jobId int, jobErr error, retryAt *time.Time) error {
+	return s.Repos.Pipeline.Fail(jobId, jobErr.Error(), retryAt)
+}
+
+// RerunPipeline queues a new job for a video that has already been through
+// the pipeline. Returns ErrJobQueued if a job is pending or running.
+func (s *PipelineService) RerunPipeline(videoId int) (*models.PipelineJob, error) {
+	jobs, err := s.Repos.Pipeline.GetByVideo(videoId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, job := range jobs {
+		status := safeDeref(job.Status)
+		if status == models.PipelinePending || status == models.PipelineRunning {
+			return nil, ErrJobQueued
+		}
+	}
+
+	return s.AddPipelineJob(videoId)
+}
@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// maxErrorOutput caps how much of a failed command's output is stored
+// in pipeline_jobs.error
+const maxErrorOutput = 2048
+
+// CommandProcessor runs an external program for every job. The job and
+// video details are passed through the environment:
+//
+//	PIPELINE_JOB_ID, PIPELINE_ATTEMPT, VIDEO_ID, SKETCH_ID, VIDEO_S3_KEY
+//
+// A non-zero exit status fails the job and the tail of the command's
+// output is recorded as the job error.
+type CommandProcessor struct {
+	Command string
+	Args    []string
+}
+
+func (p *CommandProcessor) Process(ctx context.Context, job *models.PipelineJob, video *models.SketchVideo) error {
+	if video.HotS3Key == nil {
+		return fmt.Errorf("video %d has no hot storage key", safeDeref(video.ID))
+	}
+
+	cmd := exec.CommandContext(ctx, p.Command, p.Args...)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("PIPELINE_JOB_ID=%d", safeDeref(job.ID)),
+		fmt.Sprintf("PIPELINE_ATTEMPT=%d", safeDeref(job.Attempts)),
+		fmt.Sprintf("VIDEO_ID=%d", safeDeref(video.ID)),
+		fmt.Sprintf("SKETCH_ID=%d", safeDeref(video.SketchID)),
+		fmt.Sprintf("VIDEO_S3_KEY=%s", *video.HotS3Key),
+	)
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("pipeline command timed out: %w", ctx.Err())
+		}
+
+		output := strings.TrimSpace(out.String())
+		if len(output) > maxErrorOutput {
+			output = output[len(output)-maxErrorOutput:]
+		}
+		if output == "" {
+			return fmt.Errorf("pipeline command failed: %w", err)
+		}
+		return fmt.Errorf("pipeline command failed: %w: %s", err, output)
+	}
+
+	return nil
+}
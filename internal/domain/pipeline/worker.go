@@ -0,0 +1,150 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// Processor does the actual work for a claimed pipeline job.
+type Processor interface {
+	Process(ctx context.Context, job *models.PipelineJob, video *models.SketchVideo) error
+}
+
+// Worker polls pipeline_jobs and hands claimed jobs to its Processor.
+// Several workers (or several processes) can safely run against the same
+// database since jobs are claimed with FOR UPDATE SKIP LOCKED.
+type Worker struct {
+	Service      *PipelineService
+	Processor    Processor
+	Concurrency  int
+	PollInterval time.Duration
+	JobTimeout   time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	InfoLog      *log.Logger
+	ErrorLog     *log.Logger
+}
+
+// Run starts Concurrency goroutines and blocks until ctx is cancelled
+// and every in-flight job has returned.
+func (w *Worker) Run(ctx context.Context) {
+	concurrency := max(w.Concurrency, 1)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+
+	w.InfoLog.Printf("Started %d pipeline worker(s)", concurrency)
+	wg.Wait()
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for {
+		processed, err := w.RunOnce(ctx)
+		if err != nil {
+			w.ErrorLog.Printf("pipeline worker: %s", err)
+		}
+
+		// keep draining the queue while there's work
+		if processed && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.PollInterval):
+		}
+	}
+}
+
+// RunOnce claims and processes a single job. The returned bool reports
+// whether a job was claimed.
+func (w *Worker) RunOnce(ctx context.Context) (bool, error) {
+	// a job still "running" after twice its timeout belongs to a dead worker
+	job, video, err := w.Service.ClaimJob(2 * w.JobTimeout)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			return false, nil
+		}
+		if job == nil {
+			return false, fmt.Errorf("claim job: %w", err)
+		}
+		return true, w.fail(job, fmt.Errorf("get video: %w", err))
+	}
+
+	w.InfoLog.Printf("pipeline job %d: processing video %d (attempt %d)",
+		*job.ID, *job.VideoID, *job.Attempts)
+
+	jobCtx, cancel := context.WithTimeout(ctx, w.JobTimeout)
+	defer cancel()
+
+	err = w.Processor.Process(jobCtx, job, video)
+	if err != nil {
+		return true, w.fail(job, err)
+	}
+
+	err = w.Service.CompleteJob(*job.ID)
+	if err != nil {
+		return true, fmt.Errorf("complete job %d: %w", *job.ID, err)
+	}
+
+	w.InfoLog.Printf("pipeline job %d: done", *job.ID)
+	return true, nil
+}
+
+func (w *Worker) fail(job *models.PipelineJob, jobErr error) error {
+	var retryAt *time.Time
+	attempts := safeDeref(job.Attempts)
+	if attempts < w.MaxAttempts {
+		t := time.Now().Add(w.backoff(attempts))
+		retryAt = &t
+	}
+
+	err := w.Service.FailJob(*job.ID, jobErr, retryAt)
+	if err != nil {
+		return fmt.Errorf("fail job %d: %w", *job.ID, err)
+	}
+
+	if retryAt != nil {
+		return fmt.Errorf("job %d attempt %d failed, retrying at %s: %w",
+			*job.ID, attempts, retryAt.Format(time.RFC3339), jobErr)
+	}
+	return fmt.Errorf("job %d failed after %d attempts: %w", *job.ID, attempts, jobErr)
+}
+
+// backoff doubles the base delay for every attempt already made, capped
+// at MaxBackoff.
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= w.MaxBackoff {
+			return w.MaxBackoff
+		}
+	}
+	return min(delay, w.MaxBackoff)
+}
+
+func safeDeref[T any](ptr *T) T {
+	if ptr != nil {
+		return *ptr
+	}
+	var zero T
+	return zero
+}
@@ -0,0 +1,152 @@
+package popularity
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// rolledUp are the entities whose score is the sum of their sketches'
+var rolledUp = []string{
+	models.PopularityPerson,
+	models.PopularityCharacter,
+	models.PopularityCreator,
+	models.PopularityShow,
+}
+
+// maxReportedChanges limits the changes listed per entity in a report
+const maxReportedChanges = 25
+
+type ScoreChange struct {
+	ID  int     `json:"id"`
+	Old float64 `json:"old"`
+	New float64 `json:"new"`
+}
+
+type EntityReport struct {
+	Total   int `json:"total"`
+	Changed int `json:"changed"`
+	// Changes are the largest changes by absolute difference
+	Changes []ScoreChange `json:"changes"`
+}
+
+type Report struct {
+	DryRun   bool                     `json:"dryRun"`
+	Entities map[string]*EntityReport `json:"entities"`
+}
+
+// Recompute scores every sketch and rolls the scores up to people,
+// characters, creators and shows. With dryRun nothing is written and the
+// report shows what would change.
+func (s *PopularityService) Recompute(ctx context.Context, dryRun bool) (*Report, error) {
+	signals, err := s.Repos.Popularity.GetSketchSignals(s.Weights.HalfLife)
+	if err != nil {
+		return nil, err
+	}
+
+	sketchScores := scoreSketches(signals, s.Weights)
+	scores := map[string]map[int]float64{
+		models.PopularitySketch: sketchScores,
+	}
+
+	for _, entity := range rolledUp {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		links, err := s.Repos.Popularity.GetSketchLinks(entity)
+		if err != nil {
+			return nil, err
+		}
+		scores[entity] = rollup(links, sketchScores)
+	}
+
+	report := &Report{DryRun: dryRun, Entities: map[string]*EntityReport{}}
+	for entity, newScores := range scores {
+		current, err := s.Repos.Popularity.GetScores(entity)
+		if err != nil {
+			return nil, err
+		}
+
+		// rows without any linked sketches drop to zero
+		for id := range current {
+			if _, ok := newScores[id]; !ok {
+				newScores[id] = 0
+			}
+		}
+
+		report.Entities[entity] = diff(current, newScores)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	err = s.Repos.Popularity.UpdateScores(scores)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func diff(current, updated map[int]float64) *EntityReport {
+	report := &EntityReport{Total: len(updated), Changes: []ScoreChange{}}
+	for id, score := range updated {
+		old := current[id]
+		if old == score {
+			continue
+		}
+		report.Changed++
+		report.Changes = append(report.Changes, ScoreChange{ID: id, Old: old, New: score})
+	}
+
+	sort.Slice(report.Changes, func(i, j int) bool {
+		a, b := report.Changes[i], report.Changes[j]
+		da, db := math.Abs(a.New-a.Old), math.Abs(b.New-b.Old)
+		if da != db {
+			return da > db
+		}
+		return a.ID < b.ID
+	})
+
+	if len(report.Changes) > maxReportedChanges {
+		report.Changes = report.Changes[:maxReportedChanges]
+	}
+
+	return report
+}
+
+// Scheduler runs Recompute every Interval until its context is cancelled
+type Scheduler struct {
+	Service  *PopularityService
+	Interval time.Duration
+	InfoLog  *log.Logger
+	ErrorLog *log.Logger
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	s.InfoLog.Printf("Started popularity scoring, interval %s", s.Interval)
+
+	for {
+		report, err := s.Service.Recompute(ctx, false)
+		if err != nil {
+			s.ErrorLog.Printf("popularity: %s", err)
+		} else {
+			for _, entity := range append([]string{models.PopularitySketch}, rolledUp...) {
+				r := report.Entities[entity]
+				s.InfoLog.Printf("popularity: %s scores updated, %d of %d changed", entity, r.Changed, r.Total)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.Interval):
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package popularity
+
+import (
+	"math"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// maxRating is the top of the sketch rating scale
+const maxRating = 5
+
+// scoreSketches computes the score of each sketch. Ratings count towards
+// the score scaled by the sketch's bayesian average rating.
+func scoreSketches(signals []*models.SketchSignals, w Weights) map[int]float64 {
+	var ratingSum float64
+	var ratingCount int
+	for _, s := range signals {
+		ratingSum += s.RatingSum
+		ratingCount += s.RatingCount
+	}
+
+	mean := float64(maxRating) / 2
+	if ratingCount > 0 {
+		mean = ratingSum / float64(ratingCount)
+	}
+
+	scores := make(map[int]float64, len(signals))
+	for _, s := range signals {
+		avg := (w.PriorRatings*mean + s.RatingSum) / (w.PriorRatings + float64(s.RatingCount))
+
+		score := w.Like*s.Likes +
+			w.Rating*s.Ratings*(avg/maxRating) +
+			w.QuoteLike*float64(s.QuoteLikes)
+
+		scores[s.SketchID] = round(score)
+	}
+
+	return scores
+}
+
+// rollup sums the scores of the sketches linked to each entity
+func rollup(links []models.SketchLink, sketchScores map[int]float64) map[int]float64 {
+	scores := map[int]float64{}
+	for _, l := range links {
+		scores[l.EntityID] += sketchScores[l.SketchID]
+	}
+
+	for id, score := range scores {
+		scores[id] = round(score)
+	}
+	return scores
+}
+
+// round to the precision of the REAL popularity_score columns so unchanged
+// scores aren't reported as changes
+func round(score float64) float64 {
+	return float64(float32(math.Round(score*1000) / 1000))
+}
@@ -0,0 +1,103 @@
+package popularity
+
+import (
+	"testing"
+
+	"sketchdb.cozycole.net/internal/assert"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+func TestScoreSketches(t *testing.T) {
+	weights := Weights{Like: 1, Rating: 2, QuoteLike: 0.5, PriorRatings: 2}
+
+	tests := []struct {
+		name    string
+		signals []*models.SketchSignals
+		want    map[int]float64
+	}{
+		{
+			name:    "No Sketches",
+			signals: nil,
+			want:    map[int]float64{},
+		},
+		{
+			name: "Likes And Quote Likes",
+			signals: []*models.SketchSignals{
+				{SketchID: 1, Likes: 3, QuoteLikes: 4},
+				{SketchID: 2},
+			},
+			want: map[int]float64{1: 5, 2: 0},
+		},
+		{
+			// the mean rating is 50/11, a single 5 star rating is pulled
+			// towards it more than ten ratings averaging 4.5
+			name: "Bayesian Average",
+			signals: []*models.SketchSignals{
+				{SketchID: 1, Ratings: 1, RatingCount: 1, RatingSum: 5},
+				{SketchID: 2, Ratings: 10, RatingCount: 10, RatingSum: 45},
+			},
+			want: map[int]float64{1: round(1.879), 2: round(18.03)},
+		},
+		{
+			name: "Decayed Ratings",
+			signals: []*models.SketchSignals{
+				{SketchID: 1, Ratings: 0.5, RatingCount: 1, RatingSum: 4},
+			},
+			want: map[int]float64{1: round(0.8)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := scoreSketches(tt.signals, weights)
+			assert.DeepEqual(t, scores, tt.want)
+		})
+	}
+}
+
+func TestRollup(t *testing.T) {
+	sketchScores := map[int]float64{10: 1.5, 11: 2.25, 12: 0.0004}
+
+	tests := []struct {
+		name  string
+		links []models.SketchLink
+		want  map[int]float64
+	}{
+		{
+			name:  "No Links",
+			links: nil,
+			want:  map[int]float64{},
+		},
+		{
+			name: "Sums Linked Sketches",
+			links: []models.SketchLink{
+				{EntityID: 1, SketchID: 10},
+				{EntityID: 1, SketchID: 11},
+				{EntityID: 2, SketchID: 11},
+			},
+			want: map[int]float64{1: 3.75, 2: 2.25},
+		},
+		{
+			name: "Unscored Sketch",
+			links: []models.SketchLink{
+				{EntityID: 3, SketchID: 99},
+			},
+			want: map[int]float64{3: 0},
+		},
+		{
+			name: "Rounded",
+			links: []models.SketchLink{
+				{EntityID: 4, SketchID: 10},
+				{EntityID: 4, SketchID: 12},
+			},
+			want: map[int]float64{4: 1.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := rollup(tt.links, sketchScores)
+			assert.DeepEqual(t, scores, tt.want)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package popularity
+
+import (
+	"time"
+
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type PopularityService struct {
+	Repos   models.Repositories
+	Weights Weights
+}
+
+// Weights controls how the engagement signals of a sketch are combined
+type Weights struct {
+	Like      float64
+	Rating    float64
+	QuoteLike float64
+	// HalfLife is the age at which a like or rating counts half as much
+	HalfLife time.Duration
+	// PriorRatings is the number of average ratings each sketch is assumed
+	// to have, so a single 5 star rating doesn't beat hundreds of 4.5s
+	PriorRatings float64
+}
+
+var DefaultWeights = Weights{
+	Like:         1,
+	Rating:       1.5,
+	QuoteLike:    0.25,
+	HalfLife:     90 * 24 * time.Hour,
+	PriorRatings: 5,
+}
@@ -0,0 +1,159 @@
+package quotes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+var ErrInvalidLineRange = errors.New("quotes: invalid transcript line range")
+
+// PromoteTranscriptLines creates a single quote out of the transcript lines
+// from startLineId to endLineId (inclusive, in transcript order). The quote
+// spans from the earliest line start to the latest line end. If linkCast is
+// set, cast members whose character or actor name matches one of the
+// lines' speaker labels are attached to the quote.
+func (s *QuoteService) PromoteTranscriptLines(actor *models.User, sketchId, startLineId, endLineId int, linkCast bool) (*models.Quote, error) {
+	transcript, err := s.Repos.Quotes.GetTranscriptBySketch(sketchId)
+	if err != nil {
+		return nil, fmt.Errorf("get transcript error: %w", err)
+	}
+
+	start, end := -1, -1
+	for i, l := range transcript {
+		switch safeDeref(l.ID) {
+		case startLineId:
+			start = i
+		case endLineId:
+			end = i
+		}
+	}
+
+	// single line quote
+	if startLineId == endLineId {
+		end = start
+	}
+
+	if start == -1 || end == -1 || start > end {
+		return nil, ErrInvalidLineRange
+	}
+
+	staleQuotes, err := s.Repos.Quotes.GetBySketch(sketchId, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get quotes error: %w", err)
+	}
+
+	lines := transcript[start : end+1]
+	quote := mergeTranscriptLines(lines)
+
+	var castIds []int
+	if linkCast {
+		cast, err := s.Repos.Cast.GetCastMembers(sketchId)
+		if err != nil && !errors.Is(err, models.ErrNoRecord) {
+			return nil, fmt.Errorf("get cast error: %w", err)
+		}
+		castIds = matchSpeakers(lines, cast)
+	}
+
+	err = s.Repos.Quotes.InsertQuote(sketchId, quote, castIds)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes, err := s.Repos.Quotes.GetBySketch(sketchId, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get quotes error: %w", err)
+	}
+
+	for _, q := range quotes {
+		if safeDeref(q.ID) == *quote.ID {
+			quote = q
+			break
+		}
+	}
+
+	err = audit.Record(s.Repos, actor, models.AuditQuotes, sketchId, models.AuditUpdate, quotesSnapshot(staleQuotes), quotesSnapshot(quotes))
+	if err != nil {
+		return quote, err
+	}
+
+	return quote, nil
+}
+
+// mergeTranscriptLines joins the lines' text into a new quote. Consecutive
+// lines by the same speaker are joined with a space, a change of speaker
+// starts a new line.
+func mergeTranscriptLines(lines []*models.TranscriptLine) *models.Quote {
+	var sb strings.Builder
+	var startMs, endMs *int
+	for i, l := range lines {
+		if i > 0 {
+			if !strings.EqualFold(safeDeref(l.Speaker), safeDeref(lines[i-1].Speaker)) {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString(strings.TrimSpace(safeDeref(l.Text)))
+
+		if l.StartMs != nil && (startMs == nil || *l.StartMs < *startMs) {
+			startMs = l.StartMs
+		}
+		if l.EndMs != nil && (endMs == nil || *l.EndMs > *endMs) {
+			endMs = l.EndMs
+		}
+	}
+
+	text := sb.String()
+	return &models.Quote{
+		Text:        &text,
+		StartTimeMs: startMs,
+		EndTimeMs:   endMs,
+	}
+}
+
+// matchSpeakers returns the ids of the cast members whose character name,
+// character or actor full name matches a speaker label (case insensitive).
+func matchSpeakers(lines []*models.TranscriptLine, cast []*models.CastMember) []int {
+	speakers := map[string]bool{}
+	for _, l := range lines {
+		if speaker := normalizeName(safeDeref(l.Speaker)); speaker != "" {
+			speakers[speaker] = true
+		}
+	}
+
+	ids := []int{}
+	if len(speakers) == 0 {
+		return ids
+	}
+
+	for _, cm := range cast {
+		if cm.ID == nil {
+			continue
+		}
+
+		names := []string{safeDeref(cm.CharacterName)}
+		if cm.Character != nil {
+			names = append(names, safeDeref(cm.Character.Name))
+		}
+		if cm.Actor != nil {
+			names = append(names, safeDeref(cm.Actor.First)+" "+safeDeref(cm.Actor.Last))
+		}
+
+		for _, name := range names {
+			if n := normalizeName(name); n != "" && speakers[n] {
+				ids = append(ids, *cm.ID)
+				break
+			}
+		}
+	}
+
+	return ids
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,159 @@
+package quotes
+
+import (
+	"testing"
+
+	"sketchdb.cozycole.net/internal/assert"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func line(speaker, text string, startMs, endMs *int) *models.TranscriptLine {
+	l := &models.TranscriptLine{Text: &text, StartMs: startMs, EndMs: endMs}
+	if speaker != "" {
+		l.Speaker = &speaker
+	}
+	return l
+}
+
+func TestMergeTranscriptLines(t *testing.T) {
+	tests := []struct {
+		name        string
+		lines       []*models.TranscriptLine
+		wantText    string
+		wantStartMs *int
+		wantEndMs   *int
+	}{
+		{
+			name:        "Single Line",
+			lines:       []*models.TranscriptLine{line("Tim", " Get out of here ", ptr(1000), ptr(2000))},
+			wantText:    "Get out of here",
+			wantStartMs: ptr(1000),
+			wantEndMs:   ptr(2000),
+		},
+		{
+			name: "Same Speaker Joined",
+			lines: []*models.TranscriptLine{
+				line("Tim", "You sure", ptr(1000), ptr(2000)),
+				line("tim", "about that?", ptr(2000), ptr(3000)),
+			},
+			wantText:    "You sure about that?",
+			wantStartMs: ptr(1000),
+			wantEndMs:   ptr(3000),
+		},
+		{
+			name: "Speaker Change Starts A Line",
+			lines: []*models.TranscriptLine{
+				line("Tim", "Hi.", ptr(1000), ptr(2000)),
+				line("Sam", "Hello.", ptr(2000), ptr(3000)),
+				line("Tim", "Bye.", ptr(3000), ptr(4000)),
+			},
+			wantText:    "Hi.\nHello.\nBye.",
+			wantStartMs: ptr(1000),
+			wantEndMs:   ptr(4000),
+		},
+		{
+			name: "Overlapping Times",
+			lines: []*models.TranscriptLine{
+				line("", "one", ptr(1500), ptr(5000)),
+				line("", "two", ptr(1000), ptr(3000)),
+			},
+			wantText:    "one two",
+			wantStartMs: ptr(1000),
+			wantEndMs:   ptr(5000),
+		},
+		{
+			name: "Missing Times",
+			lines: []*models.TranscriptLine{
+				line("", "one", nil, nil),
+				line("", "two", ptr(2000), nil),
+			},
+			wantText:    "one two",
+			wantStartMs: ptr(2000),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := mergeTranscriptLines(tt.lines)
+			assert.Equal(t, safeDeref(quote.Text), tt.wantText)
+			assert.DeepEqual(t, quote.StartTimeMs, tt.wantStartMs)
+			assert.DeepEqual(t, quote.EndTimeMs, tt.wantEndMs)
+			assert.Equal(t, quote.ID == nil, true)
+		})
+	}
+}
+
+func TestMatchSpeakers(t *testing.T) {
+	cast := []*models.CastMember{
+		{
+			ID:            ptr(1),
+			CharacterName: ptr("Carl"),
+			Actor:         &models.PersonRef{First: ptr("Tim"), Last: ptr("Robinson")},
+		},
+		{
+			ID:        ptr(2),
+			Character: &models.CharacterRef{Name: ptr("Driver Guy")},
+		},
+		{
+			ID:            ptr(3),
+			CharacterName: ptr("Karen"),
+			Actor:         &models.PersonRef{First: ptr("Sam"), Last: ptr("Richardson")},
+		},
+		{
+			// not saved yet, can't be linked
+			CharacterName: ptr("Carl"),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		speakers []string
+		want     []int
+	}{
+		{
+			name:     "No Speakers",
+			speakers: []string{"", ""},
+			want:     []int{},
+		},
+		{
+			name:     "Character Name",
+			speakers: []string{"Carl"},
+			want:     []int{1},
+		},
+		{
+			name:     "Linked Character Case And Spacing",
+			speakers: []string{"  driver   GUY "},
+			want:     []int{2},
+		},
+		{
+			name:     "Actor Name",
+			speakers: []string{"sam richardson"},
+			want:     []int{3},
+		},
+		{
+			name:     "Several Speakers In Cast Order",
+			speakers: []string{"Karen", "Carl", "Karen"},
+			want:     []int{1, 3},
+		},
+		{
+			name:     "Unknown Speaker",
+			speakers: []string{"Narrator"},
+			want:     []int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := make([]*models.TranscriptLine, 0, len(tt.speakers))
+			for _, s := range tt.speakers {
+				lines = append(lines, line(s, "line", nil, nil))
+			}
+
+			assert.DeepEqual(t, matchSpeakers(lines, cast), tt.want)
+		})
+	}
+}
@@ -1,11 +1,19 @@
 package quotes
 
 import (
+	"strconv"
+
+	"sketchdb.cozycole.net/internal/domain/audit"
 	"sketchdb.cozycole.net/internal/models"
 )
 
-func (s *QuoteService) UpdateQuotes(sketchId int, quotes []*models.Quote, deleted []int) ([]*models.Quote, error) {
-	err := s.Repos.Quotes.BatchUpdateQuotes(sketchId, quotes, deleted)
+func (s *QuoteService) UpdateQuotes(actor *models.User, sketchId int, quotes []*models.Quote, deleted []int) ([]*models.Quote, error) {
+	staleQuotes, err := s.Repos.Quotes.GetBySketch(sketchId, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Repos.Quotes.BatchUpdateQuotes(sketchId, quotes, deleted)
 	if err != nil {
 		return nil, err
 	}
@@ -45,5 +53,24 @@ func (s *QuoteService) UpdateQuotes(sketchId int, quotes []*models.Quote, delete
 		return nil, err
 	}
 
+	err = audit.Record(s.Repos, actor, models.AuditQuotes, sketchId, models.AuditUpdate, quotesSnapshot(staleQuotes), quotesSnapshot(updatedQuotes))
+	if err != nil {
+		return updatedQuotes, err
+	}
+
 	return updatedQuotes, nil
 }
+
+// quotesSnapshot keys a sketch's quotes by id for the audit log, so each
+// added, edited or removed quote shows up as its own change. Likes are
+// left out as they aren't edits.
+func quotesSnapshot(quotes []*models.Quote) map[string]*models.Quote {
+	snap := map[string]*models.Quote{}
+	for _, q := range quotes {
+		c := *q
+		c.LikeCount = nil
+		c.UserLiked = nil
+		snap[strconv.Itoa(safeDeref(q.ID))] = &c
+	}
+	return snap
+}
@@ -0,0 +1,70 @@
+package quotes
+
+import (
+	"fmt"
+	"io"
+
+	"sketchdb.cozycole.net/internal/models"
+	"sketchdb.cozycole.net/internal/subtitles"
+)
+
+// ImportTranscript parses a subtitle file and replaces the sketch's
+// transcript lines with its cues.
+func (s *QuoteService) ImportTranscript(sketchId int, r io.Reader, format string) ([]*models.TranscriptLine, error) {
+	exists, err := s.Repos.Sketches.Exists(sketchId)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, models.ErrNoSketch
+	}
+
+	cues, err := subtitles.Parse(r, format)
+	if err != nil {
+		return nil, err
+	}
+	// an empty file would wipe the transcript
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("%w: no cues", subtitles.ErrMalformed)
+	}
+
+	lines := make([]*models.TranscriptLine, 0, len(cues))
+	for _, c := range cues {
+		line := &models.TranscriptLine{
+			Text:    &c.Text,
+			StartMs: &c.StartMs,
+			EndMs:   &c.EndMs,
+		}
+		if c.Speaker != "" {
+			line.Speaker = &c.Speaker
+		}
+		lines = append(lines, line)
+	}
+
+	err = s.Repos.Quotes.ReplaceTranscript(sketchId, lines)
+	if err != nil {
+		return nil, fmt.Errorf("replace transcript error: %w", err)
+	}
+
+	return lines, nil
+}
+
+// ExportTranscript writes the sketch's transcript lines as a subtitle file.
+func (s *QuoteService) ExportTranscript(sketchId int, w io.Writer, format string) error {
+	lines, err := s.Repos.Quotes.GetTranscriptBySketch(sketchId)
+	if err != nil {
+		return fmt.Errorf("get transcript error: %w", err)
+	}
+
+	cues := make([]subtitles.Cue, 0, len(lines))
+	for _, l := range lines {
+		cues = append(cues, subtitles.Cue{
+			StartMs: safeDeref(l.StartMs),
+			EndMs:   safeDeref(l.EndMs),
+			Speaker: safeDeref(l.Speaker),
+			Text:    safeDeref(l.Text),
+		})
+	}
+
+	return subtitles.Write(w, format, cues)
+}
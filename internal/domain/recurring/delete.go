@@ -0,0 +1,30 @@
+package recurring
+
+import (
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/domain/shared"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// DeleteRecurring deletes a recurring sketch and its thumbnail. Sketches
+// and homepage slots still referencing it block the delete unless opts
+// reassigns them to another recurring sketch or detaches them.
+func (s *RecurringService) DeleteRecurring(actor *models.User, id int, opts shared.DeleteOptions) (*shared.DeleteReport, error) {
+	recurring, err := s.Repos.Recurring.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := shared.Delete(s.Repos.Recurring, id, opts)
+	if err != nil || opts.DryRun {
+		return report, err
+	}
+
+	err = report.DeleteImage(s.ImgStore, "recurring", recurring.ThumbnailName)
+	if err != nil {
+		return report, err
+	}
+
+	err = audit.Record(s.Repos, actor, models.AuditRecurring, id, models.AuditDelete, recurringSnapshot(recurring), nil)
+	return report, err
+}
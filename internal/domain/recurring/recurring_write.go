@@ -0,0 +1,48 @@
+package recurring
+
+import (
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+func (s *RecurringService) CreateRecurring(actor *models.User, recurring *models.Recurring) (int, error) {
+	id, err := s.Repos.Recurring.Insert(recurring)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := s.Repos.Recurring.GetById(id)
+	if err != nil {
+		return id, err
+	}
+
+	return id, audit.Record(s.Repos, actor, models.AuditRecurring, id, models.AuditCreate, nil, recurringSnapshot(created))
+}
+
+func (s *RecurringService) UpdateRecurring(actor *models.User, recurring *models.Recurring) error {
+	id := safeDeref(recurring.ID)
+	staleRecurring, err := s.Repos.Recurring.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.Repos.Recurring.Update(recurring)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.Repos.Recurring.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	return audit.Record(s.Repos, actor, models.AuditRecurring, id, models.AuditUpdate, recurringSnapshot(staleRecurring), recurringSnapshot(updated))
+}
+
+// recurringSnapshot leaves out the recurring sketch's sketches, they're
+// edited on the sketches themselves
+func recurringSnapshot(recurring *models.Recurring) *models.Recurring {
+	snap := *recurring
+	snap.Sketches = nil
+	return &snap
+}
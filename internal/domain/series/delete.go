@@ -0,0 +1,30 @@
+package series
+
+import (
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/domain/shared"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// DeleteSeries deletes a series and its thumbnail. Sketches still in the
+// series block the delete unless opts reassigns them to another series or
+// detaches them, which also clears their part numbers.
+func (s *SeriesService) DeleteSeries(actor *models.User, id int, opts shared.DeleteOptions) (*shared.DeleteReport, error) {
+	series, err := s.Repos.Series.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := shared.Delete(s.Repos.Series, id, opts)
+	if err != nil || opts.DryRun {
+		return report, err
+	}
+
+	err = report.DeleteImage(s.ImgStore, "series", series.ThumbnailName)
+	if err != nil {
+		return report, err
+	}
+
+	err = audit.Record(s.Repos, actor, models.AuditSeries, id, models.AuditDelete, seriesSnapshot(series), nil)
+	return report, err
+}
@@ -0,0 +1,48 @@
+package series
+
+import (
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+func (s *SeriesService) CreateSeries(actor *models.User, series *models.Series) (int, error) {
+	id, err := s.Repos.Series.Insert(series)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := s.Repos.Series.GetById(id)
+	if err != nil {
+		return id, err
+	}
+
+	return id, audit.Record(s.Repos, actor, models.AuditSeries, id, models.AuditCreate, nil, seriesSnapshot(created))
+}
+
+func (s *SeriesService) UpdateSeries(actor *models.User, series *models.Series) error {
+	id := safeDeref(series.ID)
+	staleSeries, err := s.Repos.Series.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.Repos.Series.Update(series)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.Repos.Series.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	return audit.Record(s.Repos, actor, models.AuditSeries, id, models.AuditUpdate, seriesSnapshot(staleSeries), seriesSnapshot(updated))
+}
+
+// seriesSnapshot leaves out the series' sketches, they're edited on the
+// sketches themselves
+func seriesSnapshot(series *models.Series) *models.Series {
+	snap := *series
+	snap.Sketches = nil
+	return &snap
+}
@@ -0,0 +1,113 @@
+package shared
+
+import (
+	"errors"
+
+	"sketchdb.cozycole.net/internal/fileStore"
+	"sketchdb.cozycole.net/internal/media"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+var (
+	ErrHasDependents    = errors.New("delete: row is still referenced, reassign or detach its dependents")
+	ErrSelfReassign     = errors.New("delete: can't reassign dependents to the row being deleted")
+	ErrReassignConflict = errors.New("delete: choose either reassign or detach")
+	ErrCantReassign     = errors.New("delete: dependents can only be detached")
+	ErrNoReassignTarget = errors.New("delete: the row to reassign dependents to doesn't exist")
+
+	// errDryRun rolls a dry run back once its dependents are counted
+	errDryRun = errors.New("delete: dry run")
+)
+
+// Deletable is a model whose rows can be deleted once their dependents
+// are reassigned or detached
+type Deletable interface {
+	DeleteWithDependents(id int, reassignTo *int, check models.DependentsCheck) (models.Dependents, error)
+	Exists(id int) (bool, error)
+}
+
+type DeleteOptions struct {
+	// ReassignTo moves the dependents to another row of the same kind
+	ReassignTo int
+	// Detach drops the references to the deleted row instead
+	Detach bool
+	DryRun bool
+}
+
+type DeleteReport struct {
+	Dependents    models.Dependents `json:"dependents"`
+	ReassignedTo  int               `json:"reassignedTo,omitempty"`
+	DeletedImages []string          `json:"deletedImages,omitempty"`
+	DryRun        bool              `json:"dryRun"`
+}
+
+// Delete deletes the row id from model. It counts the row's dependents and
+// refuses to delete while there are any, unless opts reassigns them to
+// another row or detaches them. A dry run stops after counting.
+func Delete(model Deletable, id int, opts DeleteOptions) (*DeleteReport, error) {
+	if opts.ReassignTo != 0 && opts.Detach {
+		return nil, ErrReassignConflict
+	}
+	if opts.ReassignTo == id {
+		return nil, ErrSelfReassign
+	}
+
+	var reassignTo *int
+	if opts.ReassignTo != 0 {
+		exists, err := model.Exists(opts.ReassignTo)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrNoReassignTarget
+		}
+		reassignTo = &opts.ReassignTo
+	}
+
+	return CheckedDelete(opts, func(check models.DependentsCheck) (models.Dependents, error) {
+		return model.DeleteWithDependents(id, reassignTo, check)
+	})
+}
+
+// CheckedDelete runs del with a check applying opts to the dependents it
+// counts, so the count and the delete happen in the same transaction
+func CheckedDelete(opts DeleteOptions, del func(check models.DependentsCheck) (models.Dependents, error)) (*DeleteReport, error) {
+	deps, err := del(func(deps models.Dependents) error {
+		if opts.DryRun {
+			return errDryRun
+		}
+		if deps.Total() > 0 && opts.ReassignTo == 0 && !opts.Detach {
+			return ErrHasDependents
+		}
+		return nil
+	})
+
+	report := &DeleteReport{
+		Dependents:   deps,
+		ReassignedTo: opts.ReassignTo,
+		DryRun:       opts.DryRun,
+	}
+	switch {
+	case errors.Is(err, errDryRun):
+		return report, nil
+	case errors.Is(err, ErrHasDependents):
+		return report, err
+	case err != nil:
+		return nil, err
+	}
+	return report, nil
+}
+
+// DeleteImage removes the variants of a deleted row's image, if it had one
+func (r *DeleteReport) DeleteImage(store fileStore.FileStorageInterface, prefix string, img *string) error {
+	if img == nil || *img == "" {
+		return nil
+	}
+
+	err := media.DeleteImageVariants(store, prefix, *img)
+	if err != nil {
+		return err
+	}
+	r.DeletedImages = append(r.DeletedImages, *img)
+	return nil
+}
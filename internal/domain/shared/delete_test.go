@@ -0,0 +1,143 @@
+package shared
+
+import (
+	"errors"
+	"testing"
+
+	"sketchdb.cozycole.net/internal/assert"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// fakeDeletable deletes rows that pass the check, keeping track of what
+// it was asked to do
+type fakeDeletable struct {
+	dependents models.Dependents
+	existing   []int
+
+	called     bool
+	deleted    bool
+	reassignTo *int
+}
+
+func (m *fakeDeletable) DeleteWithDependents(id int, reassignTo *int, check models.DependentsCheck) (models.Dependents, error) {
+	m.called = true
+	err := check(m.dependents)
+	if err != nil {
+		return m.dependents, err
+	}
+	m.deleted = true
+	m.reassignTo = reassignTo
+	return m.dependents, nil
+}
+
+func (m *fakeDeletable) Exists(id int) (bool, error) {
+	for _, e := range m.existing {
+		if e == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestDelete(t *testing.T) {
+	withDependents := models.Dependents{"sketches": 2, "homeSlots": 1}
+
+	tests := []struct {
+		name           string
+		dependents     models.Dependents
+		opts           DeleteOptions
+		wantErr        error
+		wantReport     bool
+		wantCalled     bool
+		wantDeleted    bool
+		wantReassignTo int
+	}{
+		{
+			name:    "Reassign And Detach",
+			opts:    DeleteOptions{ReassignTo: 2, Detach: true},
+			wantErr: ErrReassignConflict,
+		},
+		{
+			name:    "Reassign To Self",
+			opts:    DeleteOptions{ReassignTo: 1},
+			wantErr: ErrSelfReassign,
+		},
+		{
+			name:    "Reassign To Missing Row",
+			opts:    DeleteOptions{ReassignTo: 3},
+			wantErr: ErrNoReassignTarget,
+		},
+		{
+			name:       "Has Dependents",
+			dependents: withDependents,
+			wantErr:    ErrHasDependents,
+			wantReport: true,
+			wantCalled: true,
+		},
+		{
+			name:       "Dry Run",
+			dependents: withDependents,
+			opts:       DeleteOptions{DryRun: true},
+			wantReport: true,
+			wantCalled: true,
+		},
+		{
+			name:       "Dry Run Reassign",
+			dependents: withDependents,
+			opts:       DeleteOptions{ReassignTo: 2, DryRun: true},
+			wantReport: true,
+			wantCalled: true,
+		},
+		{
+			name:        "No Dependents",
+			dependents:  models.Dependents{"sketches": 0},
+			wantReport:  true,
+			wantCalled:  true,
+			wantDeleted: true,
+		},
+		{
+			name:        "Detach",
+			dependents:  withDependents,
+			opts:        DeleteOptions{Detach: true},
+			wantReport:  true,
+			wantCalled:  true,
+			wantDeleted: true,
+		},
+		{
+			name:           "Reassign",
+			dependents:     withDependents,
+			opts:           DeleteOptions{ReassignTo: 2},
+			wantReport:     true,
+			wantCalled:     true,
+			wantDeleted:    true,
+			wantReassignTo: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &fakeDeletable{dependents: tt.dependents, existing: []int{1, 2}}
+
+			report, err := Delete(model, 1, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error: %v; want: %v", err, tt.wantErr)
+			}
+
+			assert.Equal(t, report != nil, tt.wantReport)
+			assert.Equal(t, model.called, tt.wantCalled)
+			assert.Equal(t, model.deleted, tt.wantDeleted)
+			if model.deleted {
+				assert.Equal(t, model.reassignTo != nil, tt.wantReassignTo != 0)
+				if model.reassignTo != nil {
+					assert.Equal(t, *model.reassignTo, tt.wantReassignTo)
+				}
+			}
+
+			if report != nil {
+				assert.DeepEqual(t, report.Dependents, tt.dependents)
+				assert.Equal(t, report.DryRun, tt.opts.DryRun)
+				assert.Equal(t, report.ReassignedTo, tt.opts.ReassignTo)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package shared
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrSelfMerge = errors.New("merge: can't merge a row into itself")
+
+type MergeOptions struct {
+	// KeepMergedImage keeps the image of the row being merged away rather
+	// than the image of the row it's merged into
+	KeepMergedImage bool
+	DryRun          bool
+}
+
+// MergeAliases unions comma separated alias lists. The first spelling of
+// each alias is kept, case insensitively, and any alias matching name is
+// dropped.
+func MergeAliases(name string, aliases ...string) string {
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(name)): true}
+	merged := []string{}
+	for _, list := range aliases {
+		for _, alias := range strings.Split(list, ",") {
+			alias = strings.TrimSpace(alias)
+			key := strings.ToLower(alias)
+			if alias == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, alias)
+		}
+	}
+	return strings.Join(merged, ", ")
+}
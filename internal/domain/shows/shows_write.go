@@ -0,0 +1,87 @@
+package shows
+
+import (
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/domain/shared"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+func (s *ShowService) CreateShow(actor *models.User, show *models.Show) (int, error) {
+	id, err := s.Repos.Shows.Insert(show)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := s.Repos.Shows.GetById(id)
+	if err != nil {
+		return id, err
+	}
+
+	return id, audit.Record(s.Repos, actor, models.AuditShow, id, models.AuditCreate, nil, showSnapshot(created))
+}
+
+func (s *ShowService) UpdateShow(actor *models.User, show *models.Show) error {
+	id := safeDeref(show.ID)
+	staleShow, err := s.Repos.Shows.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.Repos.Shows.Update(show)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.Repos.Shows.GetById(id)
+	if err != nil {
+		return err
+	}
+
+	return audit.Record(s.Repos, actor, models.AuditShow, id, models.AuditUpdate, showSnapshot(staleShow), showSnapshot(updated))
+}
+
+// showSnapshot leaves out seasons, they're edited separately from the
+// show's details
+func showSnapshot(show *models.Show) *models.Show {
+	snap := *show
+	snap.Seasons = nil
+	return &snap
+}
+
+// DeleteShow deletes a show with its seasons, episodes and groupings, and
+// their images. Nothing can take over a show's seasons, so its dependents
+// are only ever detached: the sketches in it are kept without an episode
+// or grouping, and it's dropped from the homepage and browse sections.
+func (s *ShowService) DeleteShow(actor *models.User, id int, opts shared.DeleteOptions) (*shared.DeleteReport, error) {
+	if opts.ReassignTo != 0 {
+		return nil, shared.ErrCantReassign
+	}
+
+	show, err := s.Repos.Shows.GetById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := shared.CheckedDelete(opts, func(check models.DependentsCheck) (models.Dependents, error) {
+		return s.Repos.Shows.DeleteWithDependents(id, check)
+	})
+	if err != nil || opts.DryRun {
+		return report, err
+	}
+
+	err = report.DeleteImage(s.ImgStore, "show", show.ProfileImg)
+	if err != nil {
+		return report, err
+	}
+
+	for _, season := range show.Seasons {
+		for _, episode := range season.Episodes {
+			err = report.DeleteImage(s.ImgStore, "episode", episode.Thumbnail)
+			if err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, audit.Record(s.Repos, actor, models.AuditShow, id, models.AuditDelete, showSnapshot(show), nil)
+}
@@ -0,0 +1,190 @@
+package sketches
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"path"
+	"time"
+
+	"sketchdb.cozycole.net/internal/fileStore"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+var (
+	ErrVideoNotArchived = errors.New("sketches: video has no cold copy")
+	ErrVideoIsHot       = errors.New("sketches: video is already in hot storage")
+	ErrVideoNotHot      = errors.New("sketches: video is not in hot storage")
+)
+
+// ArchiveVideo copies a hot video into archive storage under the same key
+// and records the cold key once the copy is confirmed to exist. The hot
+// copy is left in place, see EvictVideo.
+func (s *SketchService) ArchiveVideo(video *models.SketchVideo) error {
+	hotKey := safeDeref(video.HotS3Key)
+	if hotKey == "" {
+		return fmt.Errorf("video %d has no hot key", safeDeref(video.ID))
+	}
+
+	err := copyObject(s.ImgStore, s.ArchiveStore, hotKey)
+	if err != nil {
+		return fmt.Errorf("archive video %d: %w", safeDeref(video.ID), err)
+	}
+
+	now := time.Now()
+	video.ColdS3Key = &hotKey
+	video.ArchivedAt = &now
+	return s.Repos.Sketches.UpdateVideoStorage(video)
+}
+
+// EvictVideo drops the hot copy of an archived video after checking the
+// cold copy is still there, the hot object is queued for deletion.
+func (s *SketchService) EvictVideo(video *models.SketchVideo) error {
+	coldKey := safeDeref(video.ColdS3Key)
+	if coldKey == "" {
+		return ErrVideoNotArchived
+	}
+
+	exists, err := s.ArchiveStore.Exists(coldKey)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("video %d: cold object %s is missing", safeDeref(video.ID), coldKey)
+	}
+
+	err = s.Repos.Sketches.EvictVideo(safeDeref(video.ID))
+	if err != nil {
+		return err
+	}
+
+	video.HotS3Key = nil
+	return nil
+}
+
+// RestoreVideo copies an archived video back into hot storage. It is kept
+// hot for another retention period before being evicted again.
+func (s *SketchService) RestoreVideo(sketchId, videoId int) (*models.SketchVideo, error) {
+	video, err := s.GetVideo(sketchId, videoId)
+	if err != nil {
+		return nil, err
+	}
+
+	coldKey := safeDeref(video.ColdS3Key)
+	if coldKey == "" {
+		return nil, ErrVideoNotArchived
+	}
+
+	if video.HotS3Key != nil {
+		return nil, ErrVideoIsHot
+	}
+
+	err = copyObject(s.ArchiveStore, s.ImgStore, coldKey)
+	if err != nil {
+		return nil, fmt.Errorf("restore video %d: %w", videoId, err)
+	}
+
+	now := time.Now()
+	video.HotS3Key = &coldKey
+	video.HotRestoredAt = &now
+	err = s.Repos.Sketches.UpdateVideoStorage(video)
+	if err != nil {
+		return nil, err
+	}
+
+	return video, nil
+}
+
+// copyObject streams key from src to dst and verifies it landed
+func copyObject(src, dst fileStore.FileStorageInterface, key string) error {
+	body, err := src.GetFile(key)
+	if err != nil {
+		return fmt.Errorf("get %s: %w", key, err)
+	}
+	defer body.Close()
+
+	err = dst.UploadFile(key, body, mime.TypeByExtension(path.Ext(key)))
+	if err != nil {
+		return fmt.Errorf("upload %s: %w", key, err)
+	}
+
+	exists, err := dst.Exists(key)
+	if err != nil {
+		return fmt.Errorf("verify %s: %w", key, err)
+	}
+	if !exists {
+		return fmt.Errorf("verify %s: object missing after upload", key)
+	}
+
+	return nil
+}
+
+// Archiver periodically archives processed videos and evicts hot copies
+// that have been archived for longer than Retention.
+type Archiver struct {
+	Service   *SketchService
+	Interval  time.Duration
+	Retention time.Duration
+	BatchSize int
+	InfoLog   *log.Logger
+	ErrorLog  *log.Logger
+}
+
+func (a *Archiver) Run(ctx context.Context) {
+	a.InfoLog.Printf("Started video archiver, interval %s, hot retention %s", a.Interval, a.Retention)
+
+	for {
+		a.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(a.Interval):
+		}
+	}
+}
+
+// RunOnce archives then evicts up to BatchSize videos each. Failures are
+// logged and retried on the next run.
+func (a *Archiver) RunOnce(ctx context.Context) {
+	batch := max(a.BatchSize, 1)
+
+	toArchive, err := a.Service.Repos.Sketches.GetVideosToArchive(batch)
+	if err != nil {
+		a.ErrorLog.Printf("video archiver: %s", err)
+	}
+
+	for _, v := range toArchive {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := a.Service.ArchiveVideo(v)
+		if err != nil {
+			a.ErrorLog.Printf("video archiver: %s", err)
+			continue
+		}
+		a.InfoLog.Printf("video archiver: archived video %d (%s)", safeDeref(v.ID), safeDeref(v.ColdS3Key))
+	}
+
+	cutoff := time.Now().Add(-a.Retention)
+	toEvict, err := a.Service.Repos.Sketches.GetVideosToEvict(cutoff, batch)
+	if err != nil {
+		a.ErrorLog.Printf("video archiver: %s", err)
+	}
+
+	for _, v := range toEvict {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := a.Service.EvictVideo(v)
+		if err != nil {
+			a.ErrorLog.Printf("video archiver: evict video %d: %s", safeDeref(v.ID), err)
+			continue
+		}
+		a.InfoLog.Printf("video archiver: evicted hot copy of video %d", safeDeref(v.ID))
+	}
+}
@@ -0,0 +1,212 @@
+package sketches
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"sketchdb.cozycole.net/internal/fileStore"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// PendingMediaDeletions counts the storage objects still queued for
+// deletion, including ones waiting on a retry
+func (s *SketchService) PendingMediaDeletions() (int, error) {
+	return s.Repos.MediaDeletions.CountPending()
+}
+
+func (s *SketchService) mediaStore(store string) (fileStore.FileStorageInterface, error) {
+	switch store {
+	case models.MediaStore:
+		return s.ImgStore, nil
+	case models.ArchiveStore:
+		return s.ArchiveStore, nil
+	}
+	return nil, fmt.Errorf("unknown store %q", store)
+}
+
+// MediaCleaner deletes the storage objects queued in media_deletions,
+// aborting the multipart uploads queued with them. Deletions are retried
+// with a backoff until they succeed, deleting an object or aborting an
+// upload that's already gone succeeds, so a retry after a partial failure
+// is harmless. Several cleaners can run against the same database since
+// claimed deletions are skipped by the others until their lease runs out.
+type MediaCleaner struct {
+	Service     *SketchService
+	Interval    time.Duration
+	BatchSize   int
+	Lease       time.Duration
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	InfoLog     *log.Logger
+	ErrorLog    *log.Logger
+}
+
+func (c *MediaCleaner) Run(ctx context.Context) {
+	c.InfoLog.Printf("Started media cleaner, interval %s", c.Interval)
+
+	for {
+		// keep draining the queue while full batches are coming back
+		claimed, err := c.RunOnce(ctx)
+		if err != nil {
+			c.ErrorLog.Printf("media cleaner: %s", err)
+		}
+		if claimed == max(c.BatchSize, 1) && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.Interval):
+		}
+	}
+}
+
+// RunOnce claims up to BatchSize due deletions and deletes them, one
+// DeleteFiles call per store. Deletions of multipart uploads first abort
+// the upload. Only the objects that failed are retried. Returns the number
+// claimed.
+func (c *MediaCleaner) RunOnce(ctx context.Context) (int, error) {
+	deletions, err := c.Service.Repos.MediaDeletions.Claim(max(c.BatchSize, 1), c.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("claim deletions: %w", err)
+	}
+
+	byStore := map[string][]*models.MediaDeletion{}
+	for _, d := range deletions {
+		if ctx.Err() != nil {
+			return len(deletions), nil
+		}
+
+		if d.UploadID != nil {
+			err := c.abortUpload(d)
+			if err != nil {
+				c.fail([]int{safeDeref(d.ID)}, safeDeref(d.Attempts),
+					fmt.Sprintf("abort upload of %s", safeDeref(d.ObjectKey)), err)
+				continue
+			}
+		}
+
+		store := safeDeref(d.Store)
+		byStore[store] = append(byStore[store], d)
+	}
+
+	for store, batch := range byStore {
+		if ctx.Err() != nil {
+			break
+		}
+		c.deleteBatch(store, batch)
+	}
+
+	return len(deletions), nil
+}
+
+// deleteBatch deletes a store's objects in one DeleteFiles call, closing
+// the deletions that succeeded and rescheduling the ones that didn't
+func (c *MediaCleaner) deleteBatch(store string, batch []*models.MediaDeletion) {
+	keys := make([]string, 0, len(batch))
+	for _, d := range batch {
+		keys = append(keys, safeDeref(d.ObjectKey))
+	}
+
+	done, failed, err := splitDeleted(batch, c.deleteFiles(store, keys))
+	if err != nil {
+		ids := make([]int, 0, len(batch))
+		attempts := 0
+		for _, d := range batch {
+			ids = append(ids, safeDeref(d.ID))
+			attempts = max(attempts, safeDeref(d.Attempts))
+		}
+		c.fail(ids, attempts, fmt.Sprintf("delete %d %s objects", len(keys), store), err)
+		return
+	}
+
+	for d, deleteErr := range failed {
+		c.fail([]int{safeDeref(d.ID)}, safeDeref(d.Attempts),
+			fmt.Sprintf("delete %s", safeDeref(d.ObjectKey)), deleteErr)
+	}
+
+	if len(done) == 0 {
+		return
+	}
+
+	err = c.Service.Repos.MediaDeletions.Complete(done)
+	if err != nil {
+		// the objects are gone, the lease expiring just means
+		// deleting them again
+		c.ErrorLog.Printf("media cleaner: complete deletions: %s", err)
+		return
+	}
+	c.InfoLog.Printf("media cleaner: deleted %d %s objects", len(done), store)
+}
+
+// splitDeleted sorts a batch by the result of deleting its objects into
+// the ids of the deletions that are done and the ones that failed with
+// their error. A deleteErr that doesn't name the failed keys fails the
+// whole batch and is returned.
+func splitDeleted(batch []*models.MediaDeletion, deleteErr error) ([]int, map[*models.MediaDeletion]error, error) {
+	var partial *fileStore.DeleteFilesError
+	if deleteErr != nil && !errors.As(deleteErr, &partial) {
+		return nil, nil, deleteErr
+	}
+
+	done := make([]int, 0, len(batch))
+	failed := map[*models.MediaDeletion]error{}
+	for _, d := range batch {
+		if partial != nil {
+			if err, ok := partial.Failed[safeDeref(d.ObjectKey)]; ok {
+				failed[d] = err
+				continue
+			}
+		}
+		done = append(done, safeDeref(d.ID))
+	}
+	return done, failed, nil
+}
+
+// fail schedules the retry of deletions that failed
+func (c *MediaCleaner) fail(ids []int, attempts int, what string, deleteErr error) {
+	retryAt := time.Now().Add(c.backoff(attempts))
+	c.ErrorLog.Printf("media cleaner: %s (attempt %d), retrying at %s: %s",
+		what, attempts, retryAt.Format(time.RFC3339), deleteErr)
+
+	err := c.Service.Repos.MediaDeletions.Fail(ids, deleteErr.Error(), retryAt)
+	if err != nil {
+		c.ErrorLog.Printf("media cleaner: fail deletions: %s", err)
+	}
+}
+
+func (c *MediaCleaner) abortUpload(d *models.MediaDeletion) error {
+	fs, err := c.Service.mediaStore(safeDeref(d.Store))
+	if err != nil {
+		return err
+	}
+	return fs.AbortMultipartUpload(safeDeref(d.ObjectKey), safeDeref(d.UploadID))
+}
+
+func (c *MediaCleaner) deleteFiles(store string, keys []string) error {
+	fs, err := c.Service.mediaStore(store)
+	if err != nil {
+		return err
+	}
+	return fs.DeleteFiles(keys)
+}
+
+// backoff doubles the base delay for every attempt already made, capped
+// at MaxBackoff
+func (c *MediaCleaner) backoff(attempts int) time.Duration {
+	delay := c.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= c.MaxBackoff {
+			return c.MaxBackoff
+		}
+	}
+	return min(delay, c.MaxBackoff)
+}
@@ -0,0 +1,215 @@
+package sketches
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"slices"
+	"testing"
+	"time"
+
+	"sketchdb.cozycole.net/internal/assert"
+	"sketchdb.cozycole.net/internal/fileStore"
+	mock "sketchdb.cozycole.net/internal/fileStore/mocks"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// fakeMediaDeletions hands out its deletions once and records what
+// happened to them
+type fakeMediaDeletions struct {
+	deletions []*models.MediaDeletion
+	completed []int
+	failed    []int
+}
+
+func (m *fakeMediaDeletions) Claim(limit int, lease time.Duration) ([]*models.MediaDeletion, error) {
+	claimed := m.deletions[:min(limit, len(m.deletions))]
+	m.deletions = m.deletions[len(claimed):]
+	return claimed, nil
+}
+
+func (m *fakeMediaDeletions) Complete(ids []int) error {
+	m.completed = append(m.completed, ids...)
+	return nil
+}
+
+func (m *fakeMediaDeletions) CountPending() (int, error) {
+	return len(m.deletions), nil
+}
+
+func (m *fakeMediaDeletions) Fail(ids []int, errMsg string, retryAt time.Time) error {
+	m.failed = append(m.failed, ids...)
+	return nil
+}
+
+// fakeStore fails to abort the uploads and delete the keys it's given
+type fakeStore struct {
+	mock.FileStorage
+	abortFails  map[string]bool
+	deleteFails map[string]bool
+	requestErr  error
+
+	aborted []string
+	deleted []string
+}
+
+func (s *fakeStore) AbortMultipartUpload(key, uploadId string) error {
+	if s.abortFails[uploadId] {
+		return errors.New("abort failed")
+	}
+	s.aborted = append(s.aborted, uploadId)
+	return nil
+}
+
+func (s *fakeStore) DeleteFiles(keys []string) error {
+	if s.requestErr != nil {
+		return s.requestErr
+	}
+
+	var failed *fileStore.DeleteFilesError
+	for _, key := range keys {
+		if s.deleteFails[key] {
+			if failed == nil {
+				failed = &fileStore.DeleteFilesError{Failed: map[string]error{}}
+			}
+			failed.Failed[key] = errors.New("AccessDenied")
+			continue
+		}
+		s.deleted = append(s.deleted, key)
+	}
+
+	if failed != nil {
+		return failed
+	}
+	return nil
+}
+
+func deletion(id int, store, key string, uploadId *string) *models.MediaDeletion {
+	attempts := 1
+	return &models.MediaDeletion{ID: &id, Store: &store, ObjectKey: &key, UploadID: uploadId, Attempts: &attempts}
+}
+
+func TestMediaCleanerRunOnce(t *testing.T) {
+	upload := "upload-1"
+
+	tests := []struct {
+		name          string
+		deletions     []*models.MediaDeletion
+		abortFails    map[string]bool
+		deleteFails   map[string]bool
+		requestErr    error
+		wantAborted   []string
+		wantDeleted   []string
+		wantCompleted []int
+		wantFailed    []int
+	}{
+		{
+			name: "All Deleted",
+			deletions: []*models.MediaDeletion{
+				deletion(1, models.MediaStore, "a.jpg", nil),
+				deletion(2, models.ArchiveStore, "b.mp4", nil),
+			},
+			wantDeleted:   []string{"a.jpg", "b.mp4"},
+			wantCompleted: []int{1, 2},
+		},
+		{
+			name: "Upload Aborted Then Deleted",
+			deletions: []*models.MediaDeletion{
+				deletion(1, models.MediaStore, "v.mp4", &upload),
+			},
+			wantAborted:   []string{upload},
+			wantDeleted:   []string{"v.mp4"},
+			wantCompleted: []int{1},
+		},
+		{
+			name: "Failed Abort Isn't Deleted",
+			deletions: []*models.MediaDeletion{
+				deletion(1, models.MediaStore, "v.mp4", &upload),
+				deletion(2, models.MediaStore, "a.jpg", nil),
+			},
+			abortFails:    map[string]bool{upload: true},
+			wantDeleted:   []string{"a.jpg"},
+			wantCompleted: []int{2},
+			wantFailed:    []int{1},
+		},
+		{
+			name: "Only Failed Keys Retried",
+			deletions: []*models.MediaDeletion{
+				deletion(1, models.MediaStore, "a.jpg", nil),
+				deletion(2, models.MediaStore, "b.jpg", nil),
+				deletion(3, models.MediaStore, "c.jpg", nil),
+			},
+			deleteFails:   map[string]bool{"b.jpg": true},
+			wantDeleted:   []string{"a.jpg", "c.jpg"},
+			wantCompleted: []int{1, 3},
+			wantFailed:    []int{2},
+		},
+		{
+			name: "Failed Request Retries Batch",
+			deletions: []*models.MediaDeletion{
+				deletion(1, models.MediaStore, "a.jpg", nil),
+				deletion(2, models.MediaStore, "b.jpg", nil),
+			},
+			requestErr: errors.New("connection reset"),
+			wantFailed: []int{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeMediaDeletions{deletions: tt.deletions}
+			store := &fakeStore{abortFails: tt.abortFails, deleteFails: tt.deleteFails, requestErr: tt.requestErr}
+			logger := log.New(io.Discard, "", 0)
+
+			cleaner := &MediaCleaner{
+				Service: &SketchService{
+					Repos:        models.Repositories{MediaDeletions: repo},
+					ImgStore:     store,
+					ArchiveStore: store,
+				},
+				BatchSize:   10,
+				BaseBackoff: time.Minute,
+				MaxBackoff:  time.Hour,
+				InfoLog:     logger,
+				ErrorLog:    logger,
+			}
+
+			claimed, err := cleaner.RunOnce(context.Background())
+			assert.Equal(t, err, nil)
+			assert.Equal(t, claimed, len(tt.deletions))
+
+			slices.Sort(store.deleted)
+			slices.Sort(repo.completed)
+			slices.Sort(repo.failed)
+			assert.DeepEqual(t, store.aborted, tt.wantAborted)
+			assert.DeepEqual(t, store.deleted, tt.wantDeleted)
+			assert.DeepEqual(t, repo.completed, tt.wantCompleted)
+			assert.DeepEqual(t, repo.failed, tt.wantFailed)
+		})
+	}
+}
+
+func TestMediaCleanerBackoff(t *testing.T) {
+	cleaner := &MediaCleaner{BaseBackoff: time.Minute, MaxBackoff: time.Hour}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: time.Minute},
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 4, want: 8 * time.Minute},
+		{attempts: 7, want: time.Hour},
+		{attempts: 100, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, cleaner.backoff(tt.attempts), tt.want)
+	}
+
+	// a base past the cap is capped too
+	cleaner.BaseBackoff = 2 * time.Hour
+	assert.Equal(t, cleaner.backoff(1), time.Hour)
+}
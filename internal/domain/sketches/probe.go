@@ -0,0 +1,152 @@
+package sketches
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sketchdb.cozycole.net/internal/fileStore"
+	"sketchdb.cozycole.net/internal/models"
+	"sketchdb.cozycole.net/internal/probe"
+)
+
+var ErrInvalidVideo = errors.New("sketches: invalid video file")
+
+const probeTimeout = 2 * time.Minute
+
+// InspectVideo reads the real size and content type of an uploaded object
+// and probes it for duration, dimensions and codecs. Files that can't be
+// parsed or have no video stream return ErrInvalidVideo. Only ffprobe can
+// accept a container the fallback parser doesn't support, the declared
+// content type alone is never trusted.
+func (s *SketchService) InspectVideo(ctx context.Context, key string) (*models.SketchVideo, error) {
+	stat, err := s.ImgStore.Stat(key)
+	if err != nil {
+		if errors.Is(err, fileStore.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s does not exist", ErrInvalidVideo, key)
+		}
+		return nil, err
+	}
+
+	if stat.Size == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidVideo)
+	}
+
+	video := &models.SketchVideo{
+		SizeBytes:   &stat.Size,
+		ContentType: &stat.ContentType,
+	}
+
+	var input string
+	if locator, ok := s.ImgStore.(fileStore.Locator); ok {
+		input, err = locator.Locate(key, probeTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	reader := &fileStore.ObjectReader{Store: s.ImgStore, Key: key, Size: stat.Size}
+	info, err := s.Prober.Probe(ctx, input, reader, stat.Size)
+	if err != nil {
+		switch {
+		case errors.Is(err, probe.ErrUnsupported):
+			return nil, fmt.Errorf("%w: not a recognised video container", ErrInvalidVideo)
+		case errors.Is(err, probe.ErrMalformed):
+			return nil, fmt.Errorf("%w: %s", ErrInvalidVideo, err)
+		}
+		return nil, err
+	}
+
+	if !info.HasVideo() {
+		return nil, fmt.Errorf("%w: no video stream", ErrInvalidVideo)
+	}
+
+	// clients don't always send a content type with the upload
+	switch stat.ContentType {
+	case "", "application/octet-stream", "binary/octet-stream":
+		if ct := info.ContentType(); ct != "" {
+			video.ContentType = &ct
+		}
+	}
+
+	now := time.Now()
+	video.Container = &info.Container
+	video.VideoCodec = &info.VideoCodec
+	video.ProbedAt = &now
+
+	if info.AudioCodec != "" {
+		video.AudioCodec = &info.AudioCodec
+	}
+	if info.DurationMs > 0 {
+		video.DurationMs = &info.DurationMs
+	}
+	if info.Width > 0 && info.Height > 0 {
+		video.Width = &info.Width
+		video.Height = &info.Height
+	}
+
+	return video, nil
+}
+
+// ProbeVideo (re)probes an existing video in hot storage and stores the
+// results
+func (s *SketchService) ProbeVideo(ctx context.Context, sketchId, videoId int) (*models.SketchVideo, error) {
+	video, err := s.GetVideo(sketchId, videoId)
+	if err != nil {
+		return nil, err
+	}
+
+	if video.HotS3Key == nil {
+		return nil, ErrVideoNotHot
+	}
+
+	probed, err := s.InspectVideo(ctx, *video.HotS3Key)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeProbe(video, probed)
+	err = s.Repos.Sketches.UpdateVideoProbe(video)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.backfillDuration(video)
+	if err != nil {
+		return nil, err
+	}
+
+	return video, nil
+}
+
+// mergeProbe copies the probed fields onto video, keeping the existing
+// dimensions if the probe didn't find any
+func mergeProbe(video, probed *models.SketchVideo) {
+	video.SizeBytes = probed.SizeBytes
+	video.ContentType = probed.ContentType
+	video.Container = probed.Container
+	video.DurationMs = probed.DurationMs
+	video.VideoCodec = probed.VideoCodec
+	video.AudioCodec = probed.AudioCodec
+	video.ProbedAt = probed.ProbedAt
+
+	if probed.Width != nil && probed.Height != nil {
+		video.Width = probed.Width
+		video.Height = probed.Height
+	}
+}
+
+// backfillDuration sets the sketch duration from the video if it's empty
+func (s *SketchService) backfillDuration(video *models.SketchVideo) error {
+	ms := safeDeref(video.DurationMs)
+	if ms <= 0 {
+		return nil
+	}
+
+	seconds := int((ms + 500) / 1000)
+	return s.Repos.Sketches.BackfillDuration(safeDeref(video.SketchID), seconds)
+}
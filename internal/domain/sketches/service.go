@@ -3,10 +3,12 @@ package sketches
 import (
 	"sketchdb.cozycole.net/internal/fileStore"
 	"sketchdb.cozycole.net/internal/models"
+	"sketchdb.cozycole.net/internal/probe"
 )
 
 type SketchService struct {
 	Repos        models.Repositories
 	ImgStore     fileStore.FileStorageInterface
 	ArchiveStore fileStore.FileStorageInterface
+	Prober       probe.Prober
 }
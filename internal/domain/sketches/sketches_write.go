@@ -1,17 +1,17 @@
 package sketches
 
 import (
-	"errors"
-	"fmt"
+	"encoding/json"
 	"io"
 	"mime/multipart"
 
+	"sketchdb.cozycole.net/internal/domain/audit"
 	"sketchdb.cozycole.net/internal/media"
 	"sketchdb.cozycole.net/internal/models"
 	"sketchdb.cozycole.net/internal/utils"
 )
 
-func (s *SketchService) CreateSketch(sketch *models.Sketch, thumbnail *multipart.FileHeader, cropBorder bool) (*models.Sketch, error) {
+func (s *SketchService) CreateSketch(actor *models.User, sketch *models.Sketch, thumbnail *multipart.FileHeader, cropBorder bool) (*models.Sketch, error) {
 	if sketch.Episode != nil && sketch.Episode.ID != nil {
 		ep, err := s.Repos.Shows.GetEpisode(*sketch.Episode.ID)
 		if err != nil {
@@ -90,23 +90,53 @@ func (s *SketchService) CreateSketch(sketch *models.Sketch, thumbnail *multipart
 		return nil, err
 	}
 
-	createdSketch, err := s.Repos.Sketches.GetById(id)
+	createdSketch, err := s.GetSketch(id)
 	if err != nil {
 		s.Repos.Sketches.Delete(id)
 		return nil, err
 	}
 
+	err = audit.Record(s.Repos, actor, models.AuditSketch, id, models.AuditCreate, nil, sketchSnapshot(createdSketch))
+	if err != nil {
+		return createdSketch, err
+	}
+
 	return createdSketch, nil
 }
 
-func (s *SketchService) UpdateSketch(sketch *models.Sketch, thumbnail []byte, cropBorder bool) (*models.Sketch, error) {
-	oldSketch, err := s.Repos.Sketches.GetById(safeDeref(sketch.ID))
+func (s *SketchService) UpdateSketch(actor *models.User, sketch *models.Sketch, thumbnail []byte, cropBorder bool) (*models.Sketch, error) {
+	return s.updateSketch(actor, models.AuditUpdate, sketch, thumbnail, cropBorder)
+}
+
+// RevertSketch restores a sketch's details to how they were after the
+// audited write entryId. The current thumbnail and popularity are kept,
+// older thumbnails may have been deleted and popularity isn't edited.
+func (s *SketchService) RevertSketch(actor *models.User, sketchId, entryId int) (*models.Sketch, error) {
+	current, err := s.GetSketch(sketchId)
 	if err != nil {
-		if errors.Is(err, models.ErrNoRecord) {
-			return sketch, models.ErrNoSketch
-		} else {
-			return sketch, err
-		}
+		return nil, err
+	}
+
+	revision, err := audit.Revision(s.Repos, models.AuditSketch, sketchId, entryId)
+	if err != nil {
+		return nil, err
+	}
+
+	var sketch models.Sketch
+	if err := json.Unmarshal(revision, &sketch); err != nil {
+		return nil, err
+	}
+
+	sketch.ID = &sketchId
+	sketch.ThumbnailName = current.ThumbnailName
+	sketch.Popularity = current.Popularity
+	return s.updateSketch(actor, models.AuditRevert, &sketch, nil, false)
+}
+
+func (s *SketchService) updateSketch(actor *models.User, action string, sketch *models.Sketch, thumbnail []byte, cropBorder bool) (*models.Sketch, error) {
+	oldSketch, err := s.GetSketch(safeDeref(sketch.ID))
+	if err != nil {
+		return sketch, err
 	}
 
 	if sketch.Episode != nil && sketch.Episode.ID != nil {
@@ -185,97 +215,50 @@ func (s *SketchService) UpdateSketch(sketch *models.Sketch, thumbnail []byte, cr
 		}
 	}
 
-	return s.GetSketch(*sketch.ID)
-}
-
-type DeleteSketchInfo struct {
-	Videos          []*models.SketchVideo
-	CastScreenshots []*models.CastScreenshot
-}
-
-func (s *SketchService) DeleteSketch(id int) (*DeleteSketchInfo, error) {
-	deleteInfo := DeleteSketchInfo{}
-
-	var err error
-	deleteInfo.Videos, err = s.Repos.Sketches.GetVideos(id)
+	updatedSketch, err := s.GetSketch(*sketch.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	deleteInfo.CastScreenshots, err = s.Repos.Cast.GetCastScreenshots(id)
+	err = audit.Record(s.Repos, actor, models.AuditSketch, *sketch.ID, action, sketchSnapshot(oldSketch), sketchSnapshot(updatedSketch))
 	if err != nil {
-		return nil, err
+		return updatedSketch, err
 	}
 
-	err = s.Repos.Sketches.Delete(id)
-	if err != nil {
-		return nil, err
-	}
-
-	return &deleteInfo, nil
+	return updatedSketch, nil
 }
 
-// Delete any images or videos associated with a previously deleted sketch
-func (s *SketchService) CleanupSketchMedia(info *DeleteSketchInfo) error {
-	coldVidKeys := []string{}
-	hotVidKeys := []string{}
-	for _, v := range info.Videos {
-		if v.HotS3Key != nil {
-			hotKey := fmt.Sprintf("video/%s", *v.HotS3Key)
-			hotVidKeys = append(hotVidKeys, hotKey)
-		}
-		if v.ColdS3Key != nil {
-			coldKey := fmt.Sprintf("video/%s", *v.ColdS3Key)
-			coldVidKeys = append(coldVidKeys, coldKey)
-		}
-	}
-
-	screenshotKeys := []string{}
-	for _, s := range info.CastScreenshots {
-		if s.ProfileImage != nil {
-			shotKey := fmt.Sprintf("cast_auto_screenshots/profile/%s", *s.ProfileImage)
-			screenshotKeys = append(screenshotKeys, shotKey)
-		}
-		if s.ThumbnailName != nil {
-			shotKey := fmt.Sprintf("cast_auto_screenshots/thumbnail/%s", *s.ThumbnailName)
-			screenshotKeys = append(screenshotKeys, shotKey)
-		}
-	}
-
-	err := s.ArchiveStore.DeleteFiles(coldVidKeys)
-	if err != nil {
-		return err
-	}
-	err = s.ImgStore.DeleteFiles(hotVidKeys)
-	if err != nil {
-		return err
-	}
-	err = s.ImgStore.DeleteFiles(screenshotKeys)
-	return err
+// sketchSnapshot copies the editable details of a sketch for the audit
+// log. Cast is audited separately and ratings, likes and popularity
+// change without anyone editing the sketch.
+func sketchSnapshot(sketch *models.Sketch) *models.Sketch {
+	snap := *sketch
+	snap.Cast = nil
+	snap.Popularity = nil
+	snap.Rating = nil
+	snap.TotalRatings = nil
+	snap.Liked = nil
+	return &snap
 }
 
-func (s *SketchService) DeleteScreenshots(sketchId int) error {
-	screenshots, err := s.Repos.Cast.GetCastScreenshots(sketchId)
+// DeleteSketch deletes a sketch. Its videos and screenshots are queued for
+// deletion from storage by the same transaction, see MediaCleaner.
+func (s *SketchService) DeleteSketch(actor *models.User, id int) error {
+	sketch, err := s.GetSketch(id)
 	if err != nil {
 		return err
 	}
 
-	err = s.Repos.Sketches.DeleteScreenshots(sketchId)
+	err = s.Repos.Sketches.Delete(id)
 	if err != nil {
 		return err
 	}
 
-	screenshotKeys := []string{}
-	for _, s := range screenshots {
-		if s.ProfileImage != nil {
-			shotKey := fmt.Sprintf("cast_auto_screenshots/profile/%s", *s.ProfileImage)
-			screenshotKeys = append(screenshotKeys, shotKey)
-		}
-		if s.ThumbnailName != nil {
-			shotKey := fmt.Sprintf("cast_auto_screenshots/thumbnail/%s", *s.ThumbnailName)
-			screenshotKeys = append(screenshotKeys, shotKey)
-		}
-	}
+	return audit.Record(s.Repos, actor, models.AuditSketch, id, models.AuditDelete, sketchSnapshot(sketch), nil)
+}
 
-	return s.ImgStore.DeleteFiles(screenshotKeys)
+// DeleteScreenshots deletes the cast screenshots of a sketch, their images
+// are queued for deletion from storage, see MediaCleaner
+func (s *SketchService) DeleteScreenshots(sketchId int) error {
+	return s.Repos.Sketches.DeleteScreenshots(sketchId)
 }
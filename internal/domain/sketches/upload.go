@@ -0,0 +1,232 @@
+package sketches
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sketchdb.cozycole.net/internal/fileStore"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+var (
+	ErrInvalidPartNumber = errors.New("sketches: part number out of range")
+	ErrUploadIncomplete  = errors.New("sketches: not all parts have been uploaded")
+)
+
+// DefaultPartSize is used unless the file is too large to fit in
+// fileStore.MaxParts parts of this size
+const DefaultPartSize = 16 << 20
+
+type PartURL struct {
+	PartNumber int    `json:"partNumber"`
+	URL        string `json:"url"`
+}
+
+// StartVideoUpload begins a multipart upload of a new video version for the
+// sketch. The client uploads PartCount parts of PartSize bytes (the last
+// may be smaller) then calls CompleteVideoUpload.
+func (s *SketchService) StartVideoUpload(sketchId int, fileName, contentType string, fileSize int64) (*models.VideoUpload, error) {
+	key := fmt.Sprintf("video/%s%s", uuid.New().String(), filepath.Ext(fileName))
+	partSize := partSizeFor(fileSize)
+
+	uploadId, err := s.ImgStore.CreateMultipartUpload(key, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &models.VideoUpload{
+		SketchID:    &sketchId,
+		S3Key:       &key,
+		UploadID:    &uploadId,
+		FileName:    &fileName,
+		ContentType: &contentType,
+		FileSize:    &fileSize,
+		PartSize:    &partSize,
+	}
+
+	err = s.Repos.VideoUploads.Insert(upload)
+	if err != nil {
+		s.ImgStore.AbortMultipartUpload(key, uploadId)
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+func partSizeFor(fileSize int64) int64 {
+	partSize := int64(DefaultPartSize)
+	if needed := (fileSize + fileStore.MaxParts - 1) / fileStore.MaxParts; needed > partSize {
+		partSize = needed
+	}
+	return partSize
+}
+
+// GetVideoUpload returns the upload if it belongs to the sketch, otherwise
+// models.ErrNoRecord
+func (s *SketchService) GetVideoUpload(sketchId, id int) (*models.VideoUpload, error) {
+	upload, err := s.Repos.VideoUploads.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if safeDeref(upload.SketchID) != sketchId {
+		return nil, models.ErrNoRecord
+	}
+
+	return upload, nil
+}
+
+func (s *SketchService) GetVideoUploads(sketchId int) ([]*models.VideoUpload, error) {
+	return s.Repos.VideoUploads.GetBySketch(sketchId)
+}
+
+// GetUploadedParts lists the parts already stored, used by clients to
+// resume an interrupted upload
+func (s *SketchService) GetUploadedParts(upload *models.VideoUpload) ([]fileStore.UploadPart, error) {
+	return s.ImgStore.ListUploadedParts(safeDeref(upload.S3Key), safeDeref(upload.UploadID))
+}
+
+// PresignUploadParts returns an upload url for each part number. Presigning
+// keeps the upload from being swept as stale.
+func (s *SketchService) PresignUploadParts(upload *models.VideoUpload, partNumbers []int, duration time.Duration) ([]PartURL, error) {
+	count := upload.PartCount()
+	for _, n := range partNumbers {
+		if n < 1 || n > count {
+			return nil, fmt.Errorf("%w: %d (upload has %d parts)", ErrInvalidPartNumber, n, count)
+		}
+	}
+
+	urls := make([]PartURL, 0, len(partNumbers))
+	for _, n := range partNumbers {
+		url, err := s.ImgStore.PresignUploadPart(
+			safeDeref(upload.S3Key), safeDeref(upload.UploadID), n, duration,
+		)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, PartURL{PartNumber: n, URL: url})
+	}
+
+	err := s.Repos.VideoUploads.Touch(safeDeref(upload.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// CompleteVideoUpload assembles the parts, verifies the result and adds it
+// as a video version of the sketch. If parts is empty the parts stored so
+// far are used. An assembled file that isn't added, because it fails
+// verification or anything else goes wrong, is queued for deletion.
+func (s *SketchService) CompleteVideoUpload(ctx context.Context, upload *models.VideoUpload, parts []fileStore.UploadPart, video *models.SketchVideo) (*models.SketchVideo, error) {
+	key, uploadId := safeDeref(upload.S3Key), safeDeref(upload.UploadID)
+
+	if len(parts) == 0 {
+		var err error
+		parts, err = s.ImgStore.ListUploadedParts(key, uploadId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(parts) != upload.PartCount() {
+		return nil, fmt.Errorf("%w: got %d of %d", ErrUploadIncomplete, len(parts), upload.PartCount())
+	}
+
+	err := s.ImgStore.CompleteMultipartUpload(key, uploadId, parts)
+	if err != nil {
+		return nil, err
+	}
+
+	// the parts are assembled now, so the upload can't be completed again.
+	// If it doesn't become a video the object is queued for deletion.
+	added, err := s.addUploadedVideo(ctx, upload, video)
+	if err != nil {
+		return nil, errors.Join(err, s.Repos.VideoUploads.Discard(safeDeref(upload.ID)))
+	}
+
+	// a row left behind is swept like any stale upload, aborting a
+	// completed upload doesn't touch its object
+	s.Repos.VideoUploads.Delete(safeDeref(upload.ID))
+	return added, nil
+}
+
+func (s *SketchService) addUploadedVideo(ctx context.Context, upload *models.VideoUpload, video *models.SketchVideo) (*models.SketchVideo, error) {
+	key := safeDeref(upload.S3Key)
+	probed, err := s.InspectVideo(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if safeDeref(probed.SizeBytes) != safeDeref(upload.FileSize) {
+		return nil, fmt.Errorf("%w: size %d does not match declared size %d",
+			ErrInvalidVideo, safeDeref(probed.SizeBytes), safeDeref(upload.FileSize))
+	}
+
+	mergeProbe(video, probed)
+	video.HotS3Key = &key
+	return s.AddVideo(safeDeref(upload.SketchID), video)
+}
+
+// AbortVideoUpload discards the uploaded parts and forgets the upload
+func (s *SketchService) AbortVideoUpload(upload *models.VideoUpload) error {
+	err := s.ImgStore.AbortMultipartUpload(safeDeref(upload.S3Key), safeDeref(upload.UploadID))
+	if err != nil {
+		return err
+	}
+
+	return s.Repos.VideoUploads.Delete(safeDeref(upload.ID))
+}
+
+// UploadSweeper aborts multipart uploads that have been inactive for
+// longer than MaxAge, their parts are otherwise stored (and billed)
+// indefinitely.
+type UploadSweeper struct {
+	Service  *SketchService
+	Interval time.Duration
+	MaxAge   time.Duration
+	InfoLog  *log.Logger
+	ErrorLog *log.Logger
+}
+
+func (u *UploadSweeper) Run(ctx context.Context) {
+	u.InfoLog.Printf("Started upload sweeper, interval %s, max age %s", u.Interval, u.MaxAge)
+
+	for {
+		u.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(u.Interval):
+		}
+	}
+}
+
+func (u *UploadSweeper) RunOnce(ctx context.Context) {
+	stale, err := u.Service.Repos.VideoUploads.GetStale(time.Now().Add(-u.MaxAge))
+	if err != nil {
+		u.ErrorLog.Printf("upload sweeper: %s", err)
+		return
+	}
+
+	for _, upload := range stale {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := u.Service.AbortVideoUpload(upload)
+		if err != nil {
+			u.ErrorLog.Printf("upload sweeper: abort upload %d: %s", safeDeref(upload.ID), err)
+			continue
+		}
+		u.InfoLog.Printf("upload sweeper: aborted upload %d (%s)", safeDeref(upload.ID), safeDeref(upload.S3Key))
+	}
+}
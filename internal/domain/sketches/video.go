@@ -1,15 +1,27 @@
 package sketches
 
-import "sketchdb.cozycole.net/internal/models"
+import (
+	"errors"
 
-func (s *SketchService) AddVideo(sketchId int, hotS3Key string) (*models.SketchVideo, error) {
-	video := &models.SketchVideo{HotS3Key: &hotS3Key}
+	"sketchdb.cozycole.net/internal/models"
+)
 
+var ErrVideoProcessing = errors.New("sketches: video has a running pipeline job")
+
+// AddVideo stores a new version of the sketch's video. The first video
+// added to a sketch becomes its primary. The sketch duration is filled in
+// from the video if it isn't set.
+func (s *SketchService) AddVideo(sketchId int, video *models.SketchVideo) (*models.SketchVideo, error) {
 	err := s.Repos.Sketches.InsertSketchVideo(sketchId, video)
 	if err != nil {
 		return nil, err
 	}
 
+	err = s.backfillDuration(video)
+	if err != nil {
+		return nil, err
+	}
+
 	return video, nil
 }
 
@@ -21,3 +33,92 @@ func (s *SketchService) GetVideos(sketchId int) ([]*models.SketchVideo, error) {
 
 	return videos, nil
 }
+
+// GetVideo returns the video if it belongs to the sketch, otherwise
+// models.ErrNoRecord
+func (s *SketchService) GetVideo(sketchId, videoId int) (*models.SketchVideo, error) {
+	video, err := s.Repos.Sketches.GetVideo(videoId)
+	if err != nil {
+		return nil, err
+	}
+
+	if safeDeref(video.SketchID) != sketchId {
+		return nil, models.ErrNoRecord
+	}
+
+	return video, nil
+}
+
+// UpdateVideo sets the source label and resolution of a video, nil fields
+// of input are left unchanged
+func (s *SketchService) UpdateVideo(sketchId, videoId int, input *models.SketchVideo) (*models.SketchVideo, error) {
+	video, err := s.GetVideo(sketchId, videoId)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Source != nil {
+		video.Source = input.Source
+	}
+	if input.Width != nil {
+		video.Width = input.Width
+	}
+	if input.Height != nil {
+		video.Height = input.Height
+	}
+
+	err = s.Repos.Sketches.UpdateVideo(video)
+	if err != nil {
+		return nil, err
+	}
+
+	return video, nil
+}
+
+func (s *SketchService) SetPrimaryVideo(sketchId, videoId int) error {
+	return s.Repos.Sketches.SetPrimaryVideo(sketchId, videoId)
+}
+
+// DeleteVideo removes a video version and queues its hot and cold objects
+// for deletion, see MediaCleaner. If it was the primary, the most recently added remaining version is
+// promoted.
+func (s *SketchService) DeleteVideo(sketchId, videoId int) error {
+	video, err := s.GetVideo(sketchId, videoId)
+	if err != nil {
+		return err
+	}
+
+	jobs, err := s.Repos.Pipeline.GetByVideo(videoId)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if safeDeref(job.Status) == models.PipelineRunning {
+			return ErrVideoProcessing
+		}
+	}
+
+	err = s.Repos.Sketches.DeleteVideo(videoId)
+	if err != nil {
+		return err
+	}
+
+	if safeDeref(video.IsPrimary) {
+		remaining, err := s.Repos.Sketches.GetVideos(sketchId)
+		if err != nil {
+			return err
+		}
+
+		// with no primary left the versions are ordered by id
+		if len(remaining) > 0 {
+			newest := remaining[len(remaining)-1]
+			err = s.Repos.Sketches.SetPrimaryVideo(sketchId, safeDeref(newest.ID))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
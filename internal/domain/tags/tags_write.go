@@ -0,0 +1,40 @@
+package tags
+
+import (
+	"sketchdb.cozycole.net/internal/domain/audit"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+func (s *TagsService) CreateTag(actor *models.User, tag *models.Tag) (int, error) {
+	id, err := s.Repos.Tags.Insert(tag)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := s.Repos.Tags.Get(id)
+	if err != nil {
+		return id, err
+	}
+
+	return id, audit.Record(s.Repos, actor, models.AuditTag, id, models.AuditCreate, nil, created)
+}
+
+func (s *TagsService) UpdateTag(actor *models.User, tag *models.Tag) error {
+	id := safeDeref(tag.ID)
+	staleTag, err := s.Repos.Tags.Get(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.Repos.Tags.Update(tag)
+	if err != nil {
+		return err
+	}
+
+	updated, err := s.Repos.Tags.Get(id)
+	if err != nil {
+		return err
+	}
+
+	return audit.Record(s.Repos, actor, models.AuditTag, id, models.AuditUpdate, staleTag, updated)
+}
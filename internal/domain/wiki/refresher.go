@@ -0,0 +1,188 @@
+package wiki
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"sketchdb.cozycole.net/internal/external/wikipedia"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type RedirectedLink struct {
+	*models.WikiLink
+	Target string `json:"target"`
+}
+
+type FailedLink struct {
+	*models.WikiLink
+	Error string `json:"error"`
+}
+
+// RefreshReport summarizes a refresh run. Missing and redirected pages
+// are not changed in the database, an editor should fix the wiki page.
+type RefreshReport struct {
+	Checked    int                `json:"checked"`
+	Updated    []*models.WikiLink `json:"updated"`
+	Missing    []*models.WikiLink `json:"missing"`
+	Redirected []RedirectedLink   `json:"redirected"`
+	Failed     []FailedLink       `json:"failed"`
+}
+
+// RefreshAll refetches the extract of every person and show with a wiki
+// page and updates person.description / show.about when it changed.
+func (s *WikiService) RefreshAll(ctx context.Context) (*RefreshReport, error) {
+	links, err := s.Repos.Wiki.GetLinks()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RefreshReport{
+		Updated:    []*models.WikiLink{},
+		Missing:    []*models.WikiLink{},
+		Redirected: []RedirectedLink{},
+		Failed:     []FailedLink{},
+	}
+
+	// people and shows can share a page, only fetch each once per run
+	type result struct {
+		extract *models.WikiExtract
+		err     error
+	}
+	fetched := map[string]result{}
+
+	for _, link := range links {
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+
+		page := safeDeref(link.Page)
+		res, ok := fetched[page]
+		if !ok {
+			res.extract, res.err = s.fetch(page)
+			fetched[page] = res
+		}
+
+		report.Checked++
+
+		if errors.Is(res.err, wikipedia.ErrPageMissing) {
+			report.Missing = append(report.Missing, link)
+			continue
+		}
+
+		if res.err != nil {
+			report.Failed = append(report.Failed, FailedLink{link, res.err.Error()})
+			continue
+		}
+
+		if safeDeref(res.extract.Redirected) {
+			report.Redirected = append(report.Redirected, RedirectedLink{
+				link, safeDeref(res.extract.Title),
+			})
+		}
+
+		text := safeDeref(res.extract.Extract)
+		if text == "" || text == safeDeref(link.Text) {
+			continue
+		}
+
+		err = s.Repos.Wiki.UpdateLinkText(link, text)
+		if err != nil {
+			report.Failed = append(report.Failed, FailedLink{link, err.Error()})
+			continue
+		}
+		report.Updated = append(report.Updated, link)
+	}
+
+	return report, nil
+}
+
+// Refresher runs RefreshAll every Interval until its context is cancelled,
+// or once when started. Only one refresh runs at a time.
+type Refresher struct {
+	Service  *WikiService
+	Interval time.Duration
+	InfoLog  *log.Logger
+	ErrorLog *log.Logger
+
+	running atomic.Bool
+}
+
+func (r *Refresher) Run(ctx context.Context) {
+	r.InfoLog.Printf("Started wikipedia refresher, interval %s", r.Interval)
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !r.running.CompareAndSwap(false, true) {
+			r.InfoLog.Printf("wiki refresh: skipped, a refresh is already running")
+			continue
+		}
+		r.refresh(ctx)
+	}
+}
+
+// Start runs a refresh in the background and returns false if one is
+// already running
+func (r *Refresher) Start(ctx context.Context) bool {
+	if !r.running.CompareAndSwap(false, true) {
+		return false
+	}
+
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				r.ErrorLog.Printf("wiki refresh: %s", err)
+			}
+		}()
+		r.refresh(ctx)
+	}()
+	return true
+}
+
+// refresh runs RefreshAll and logs its report, the caller sets running
+func (r *Refresher) refresh(ctx context.Context) {
+	defer r.running.Store(false)
+
+	report, err := r.Service.RefreshAll(ctx)
+	if err != nil {
+		r.ErrorLog.Printf("wiki refresh: %s", err)
+		if report == nil {
+			return
+		}
+	}
+
+	r.logReport(report)
+}
+
+func (r *Refresher) logReport(report *RefreshReport) {
+	r.InfoLog.Printf(
+		"wiki refresh: checked %d, updated %d, missing %d, redirected %d, failed %d",
+		report.Checked, len(report.Updated), len(report.Missing),
+		len(report.Redirected), len(report.Failed),
+	)
+
+	for _, l := range report.Missing {
+		r.ErrorLog.Printf("wiki refresh: %s %d (%s) page %q no longer exists",
+			safeDeref(l.EntityType), safeDeref(l.EntityID), safeDeref(l.Name), safeDeref(l.Page))
+	}
+
+	for _, l := range report.Redirected {
+		r.InfoLog.Printf("wiki refresh: %s %d (%s) page %q redirects to %q",
+			safeDeref(l.EntityType), safeDeref(l.EntityID), safeDeref(l.Name), safeDeref(l.Page), l.Target)
+	}
+
+	for _, l := range report.Failed {
+		r.ErrorLog.Printf("wiki refresh: %s %d (%s) page %q: %s",
+			safeDeref(l.EntityType), safeDeref(l.EntityID), safeDeref(l.Name), safeDeref(l.Page), l.Error)
+	}
+}
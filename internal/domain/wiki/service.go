@@ -0,0 +1,15 @@
+package wiki
+
+import (
+	"time"
+
+	"sketchdb.cozycole.net/internal/external/wikipedia"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+type WikiService struct {
+	Repos  models.Repositories
+	Client *wikipedia.Client
+	// MaxAge is how long a cached extract is served before being refetched
+	MaxAge time.Duration
+}
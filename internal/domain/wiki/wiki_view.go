@@ -0,0 +1,75 @@
+package wiki
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"sketchdb.cozycole.net/internal/external/wikipedia"
+	"sketchdb.cozycole.net/internal/models"
+)
+
+// GetExtract returns the intro extract of a page, serving it from the cache
+// while it's younger than MaxAge. If Wikipedia can't be reached a stale
+// cached copy is returned instead of an error.
+func (s *WikiService) GetExtract(page string) (string, error) {
+	cached, err := s.Repos.Wiki.Get(page)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		return "", fmt.Errorf("get cached extract error: %w", err)
+	}
+
+	if cached != nil && !safeDeref(cached.Missing) && s.isFresh(cached) {
+		return safeDeref(cached.Extract), nil
+	}
+
+	extract, err := s.fetch(page)
+	if err != nil {
+		if cached != nil && !safeDeref(cached.Missing) &&
+			!errors.Is(err, wikipedia.ErrPageMissing) {
+			return safeDeref(cached.Extract), nil
+		}
+		return "", err
+	}
+
+	return safeDeref(extract.Extract), nil
+}
+
+func (s *WikiService) isFresh(e *models.WikiExtract) bool {
+	if e.FetchedAt == nil {
+		return false
+	}
+	return time.Since(*e.FetchedAt) < s.MaxAge
+}
+
+// fetch gets the page from Wikipedia and stores the result in the cache.
+// Missing pages are cached as such and return wikipedia.ErrPageMissing.
+func (s *WikiService) fetch(page string) (*models.WikiExtract, error) {
+	result, err := s.Client.GetPage(page)
+	if err != nil && !errors.Is(err, wikipedia.ErrPageMissing) {
+		return nil, err
+	}
+
+	missing := errors.Is(err, wikipedia.ErrPageMissing)
+	extract := &models.WikiExtract{
+		Page:    &page,
+		Missing: &missing,
+	}
+
+	if result != nil {
+		title := wikipedia.PageName(result.Title)
+		extract.Title = &title
+		extract.Extract = &result.Extract
+		extract.Redirected = &result.Redirected
+	}
+
+	upsertErr := s.Repos.Wiki.Upsert(extract)
+	if upsertErr != nil {
+		return nil, fmt.Errorf("cache extract error: %w", upsertErr)
+	}
+
+	if missing {
+		return extract, wikipedia.ErrPageMissing
+	}
+
+	return extract, nil
+}
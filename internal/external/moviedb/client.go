@@ -0,0 +1,239 @@
+package moviedb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultAPIURL   = "https://api.themoviedb.org/3"
+	DefaultImageURL = "https://image.tmdb.org/t/p/original"
+
+	// number of credits kept in Person.KnownFor
+	knownForLimit = 8
+	// profile images are small, anything past this is not an image we want
+	maxImageSize = 10 << 20
+)
+
+var ErrNotFound = errors.New("moviedb: not found")
+
+// Client is implemented by TMDbClient, tests can provide their own
+// implementation or point a TMDbClient at an httptest server.
+type Client interface {
+	GetPerson(id string) (*Person, error)
+	GetImage(path string) ([]byte, error)
+}
+
+type Person struct {
+	ID                 int        `json:"id"`
+	Name               string     `json:"name"`
+	Biography          string     `json:"biography"`
+	BirthDate          *time.Time `json:"birthDate"`
+	KnownForDepartment string     `json:"knownForDepartment"`
+	ProfilePath        string     `json:"profilePath"`
+	IMDbID             string     `json:"imdbId"`
+	KnownFor           []Credit   `json:"knownFor"`
+}
+
+type Credit struct {
+	ID          int        `json:"id"`
+	MediaType   string     `json:"mediaType"`
+	Title       string     `json:"title"`
+	Character   string     `json:"character"`
+	ReleaseDate *time.Time `json:"releaseDate"`
+	Popularity  float64    `json:"popularity"`
+}
+
+type TMDbClient struct {
+	// Token is either a v4 read access token (sent as a bearer token)
+	// or a v3 api key (sent as the api_key query param)
+	Token      string
+	APIURL     string
+	ImageURL   string
+	HTTPClient *http.Client
+}
+
+func NewTMDbClient(token string) *TMDbClient {
+	return &TMDbClient{
+		Token:      token,
+		APIURL:     DefaultAPIURL,
+		ImageURL:   DefaultImageURL,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type personResponse struct {
+	ID                 int    `json:"id"`
+	Name               string `json:"name"`
+	Biography          string `json:"biography"`
+	Birthday           string `json:"birthday"`
+	KnownForDepartment string `json:"known_for_department"`
+	ProfilePath        string `json:"profile_path"`
+	IMDbID             string `json:"imdb_id"`
+	CombinedCredits    struct {
+		Cast []creditResponse `json:"cast"`
+	} `json:"combined_credits"`
+}
+
+type creditResponse struct {
+	ID           int     `json:"id"`
+	MediaType    string  `json:"media_type"`
+	Title        string  `json:"title"`
+	Name         string  `json:"name"`
+	Character    string  `json:"character"`
+	ReleaseDate  string  `json:"release_date"`
+	FirstAirDate string  `json:"first_air_date"`
+	Popularity   float64 `json:"popularity"`
+}
+
+// GetPerson fetches a person's details along with their most popular
+// credits. Returns ErrNotFound if TMDb has no person with the id.
+func (c *TMDbClient) GetPerson(id string) (*Person, error) {
+	params := url.Values{}
+	params.Set("append_to_response", "combined_credits")
+
+	endpoint := fmt.Sprintf("%s/person/%s", c.APIURL, url.PathEscape(id))
+	body, err := c.get(endpoint, params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp personResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("moviedb: json unmarshal failed: %w", err)
+	}
+
+	person := &Person{
+		ID:                 resp.ID,
+		Name:               resp.Name,
+		Biography:          strings.TrimSpace(resp.Biography),
+		BirthDate:          parseDate(resp.Birthday),
+		KnownForDepartment: resp.KnownForDepartment,
+		ProfilePath:        resp.ProfilePath,
+		IMDbID:             resp.IMDbID,
+		KnownFor:           knownFor(resp.CombinedCredits.Cast, knownForLimit),
+	}
+
+	return person, nil
+}
+
+// GetImage downloads an image given its TMDb file path (e.g. Person.ProfilePath)
+func (c *TMDbClient) GetImage(path string) ([]byte, error) {
+	if path == "" {
+		return nil, ErrNotFound
+	}
+	return c.get(c.ImageURL+path, nil, false)
+}
+
+func (c *TMDbClient) get(endpoint string, params url.Values, auth bool) ([]byte, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+
+	// v4 read access tokens are JWTs, anything else is a v3 api key
+	bearer := strings.Count(c.Token, ".") == 2
+	if auth && !bearer && c.Token != "" {
+		params.Set("api_key", c.Token)
+	}
+
+	reqURL := endpoint
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("moviedb: create request failed: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if auth && bearer {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moviedb: http request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moviedb: non-OK HTTP status: %s", resp.Status)
+	}
+
+	// read a byte past the limit to tell a truncated body from one that
+	// fits exactly
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("moviedb: read body failed: %w", err)
+	}
+	if len(body) > maxImageSize {
+		return nil, fmt.Errorf("moviedb: response is larger than %d bytes", maxImageSize)
+	}
+
+	return body, nil
+}
+
+// knownFor returns up to limit credits ordered by popularity, with
+// duplicate titles (e.g. multiple characters in one show) removed.
+func knownFor(cast []creditResponse, limit int) []Credit {
+	sort.SliceStable(cast, func(i, j int) bool {
+		return cast[i].Popularity > cast[j].Popularity
+	})
+
+	seen := map[string]bool{}
+	credits := []Credit{}
+	for _, c := range cast {
+		if len(credits) == limit {
+			break
+		}
+
+		key := fmt.Sprintf("%s-%d", c.MediaType, c.ID)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		credit := Credit{
+			ID:         c.ID,
+			MediaType:  c.MediaType,
+			Title:      c.Title,
+			Character:  c.Character,
+			Popularity: c.Popularity,
+		}
+
+		releaseDate := c.ReleaseDate
+		if c.MediaType == "tv" {
+			credit.Title = c.Name
+			releaseDate = c.FirstAirDate
+		}
+		credit.ReleaseDate = parseDate(releaseDate)
+
+		credits = append(credits, credit)
+	}
+
+	return credits
+}
+
+func parseDate(date string) *time.Time {
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,111 @@
+package moviedb_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sketchdb.cozycole.net/internal/assert"
+	"sketchdb.cozycole.net/internal/external/moviedb"
+)
+
+const personJSON = `{
+	"id": 1234,
+	"name": "Tim Robinson",
+	"biography": " Tim Robinson is an American comedian. ",
+	"birthday": "1981-05-23",
+	"known_for_department": "Acting",
+	"profile_path": "/abc.jpg",
+	"imdb_id": "nm3142672",
+	"combined_credits": {
+		"cast": [
+			{"id": 1, "media_type": "tv", "name": "Detroiters", "character": "Tim Cramblin", "first_air_date": "2017-02-07", "popularity": 10.5},
+			{"id": 2, "media_type": "tv", "name": "I Think You Should Leave", "character": "Various", "first_air_date": "2019-04-23", "popularity": 30.1},
+			{"id": 2, "media_type": "tv", "name": "I Think You Should Leave", "character": "Host", "first_air_date": "2019-04-23", "popularity": 30.1},
+			{"id": 3, "media_type": "movie", "title": "Friendship", "character": "Craig", "release_date": "", "popularity": 20}
+		]
+	}
+}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/3/person/1234", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer a.b.c" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, r.URL.Query().Get("append_to_response"), "combined_credits")
+		w.Write([]byte(personJSON))
+	})
+	mux.HandleFunc("/img/abc.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("jpg"))
+	})
+	mux.HandleFunc("/img/huge.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), 10<<20+1))
+	})
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestGetPerson(t *testing.T) {
+	ts := newTestServer(t)
+
+	client := moviedb.NewTMDbClient("a.b.c")
+	client.APIURL = ts.URL + "/3"
+	client.ImageURL = ts.URL + "/img"
+
+	person, err := client.GetPerson("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, person.Name, "Tim Robinson")
+	assert.Equal(t, person.Biography, "Tim Robinson is an American comedian.")
+	assert.Equal(t, person.IMDbID, "nm3142672")
+	assert.Equal(t, person.BirthDate.Equal(time.Date(1981, 5, 23, 0, 0, 0, 0, time.UTC)), true)
+
+	assert.Equal(t, len(person.KnownFor), 3)
+	assert.Equal(t, person.KnownFor[0].Title, "I Think You Should Leave")
+	assert.Equal(t, person.KnownFor[1].Title, "Friendship")
+	assert.Equal(t, person.KnownFor[1].ReleaseDate == nil, true)
+	assert.Equal(t, person.KnownFor[2].Character, "Tim Cramblin")
+
+	img, err := client.GetImage(person.ProfilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(img), "jpg")
+}
+
+func TestGetPersonErrors(t *testing.T) {
+	ts := newTestServer(t)
+
+	client := moviedb.NewTMDbClient("a.b.c")
+	client.APIURL = ts.URL + "/3"
+
+	_, err := client.GetPerson("999")
+	assert.Equal(t, errors.Is(err, moviedb.ErrNotFound), true)
+
+	client.Token = "bad.token"
+	_, err = client.GetPerson("1234")
+	if err == nil {
+		t.Error("expected error for unauthorized request")
+	}
+}
+
+func TestGetImageTooLarge(t *testing.T) {
+	ts := newTestServer(t)
+
+	client := moviedb.NewTMDbClient("a.b.c")
+	client.ImageURL = ts.URL + "/img"
+
+	_, err := client.GetImage("/huge.jpg")
+	if err == nil {
+		t.Error("expected error for an image over the size limit")
+	}
+}
@@ -2,69 +2,179 @@ package wikipedia
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"time"
 )
 
 var URL_TEMPLATE = "https://en.wikipedia.org/wiki/%s"
 
-// WikipediaResponse models the structure of the response we care about.
-type WikipediaResponse struct {
+const DefaultEndpoint = "https://en.wikipedia.org/w/api.php"
+
+var ErrPageMissing = errors.New("wikipedia: page does not exist")
+
+// DefaultClient is used by GetExtract
+var DefaultClient = NewClient(nil)
+
+// Page is the result of an extract lookup. If the requested title was a
+// redirect, Title is the page it resolved to and Redirected is set.
+type Page struct {
+	Title      string
+	Extract    string
+	Redirected bool
+}
+
+type Client struct {
+	HTTPClient *http.Client
+	Endpoint   string
+	UserAgent  string
+	// Retries is the number of extra attempts made after a network error
+	// or a 5xx/429 response
+	Retries    int
+	RetryDelay time.Duration
+}
+
+// NewClient returns a client for the English Wikipedia API. If httpClient is
+// nil a client with a 10 second timeout is used.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Client{
+		HTTPClient: httpClient,
+		Endpoint:   DefaultEndpoint,
+		UserAgent:  "theSketchDb/1.0 (https://thesketchdb.com)",
+		Retries:    2,
+		RetryDelay: time.Second,
+	}
+}
+
+type queryResponse struct {
 	Query struct {
-		Pages map[string]struct {
+		Normalized []redirect `json:"normalized"`
+		Redirects  []redirect `json:"redirects"`
+		Pages      []struct {
+			Title   string `json:"title"`
+			Missing bool   `json:"missing"`
+			Invalid bool   `json:"invalid"`
 			Extract string `json:"extract"`
 		} `json:"pages"`
 	} `json:"query"`
 }
 
+type redirect struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
 // GetExtract fetches the intro extract for a given Wikipedia page.
 func GetExtract(pageName string) (string, error) {
-	endpoint := "https://en.wikipedia.org/w/api.php"
+	page, err := DefaultClient.GetPage(pageName)
+	if err != nil {
+		return "", err
+	}
+	return page.Extract, nil
+}
 
+// GetPage fetches the intro extract for a given Wikipedia page, following
+// redirects. Returns ErrPageMissing if the page doesn't exist.
+func (c *Client) GetPage(pageName string) (*Page, error) {
 	params := url.Values{}
 	params.Add("action", "query")
 	params.Add("format", "json")
+	params.Add("formatversion", "2")
 	params.Add("titles", pageName)
 	params.Add("prop", "extracts")
 	params.Add("exintro", "")
+	params.Add("redirects", "")
 
-	reqURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
+	reqURL := fmt.Sprintf("%s?%s", c.Endpoint, params.Encode())
 
+	body, err := c.get(reqURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp queryResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("json unmarshal failed: %w", err)
+	}
+
+	if len(resp.Query.Pages) == 0 {
+		return nil, fmt.Errorf("no extract found for page %q", pageName)
+	}
+
+	p := resp.Query.Pages[0]
+	if p.Missing || p.Invalid {
+		return nil, ErrPageMissing
+	}
+
+	return &Page{
+		Title:      p.Title,
+		Extract:    p.Extract,
+		Redirected: len(resp.Query.Redirects) > 0,
+	}, nil
+}
+
+func (c *Client) get(reqURL string) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(c.RetryDelay * time.Duration(attempt))
+		}
+
+		body, retry, err := c.do(reqURL)
+		if err == nil {
+			return body, nil
+		}
+
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+// do performs a single request, the returned bool reports whether the
+// error is worth retrying
+func (c *Client) do(reqURL string) ([]byte, bool, error) {
 	req, err := http.NewRequest("GET", reqURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("create request failed: %w", err)
+		return nil, false, fmt.Errorf("create request failed: %w", err)
 	}
-	req.Header.Set("User-Agent", "theSketchDb/1.0 (https://thesketchdb.com)")
+	req.Header.Set("User-Agent", c.UserAgent)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("http request failed: %w", err)
+		return nil, true, fmt.Errorf("http request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("non-OK HTTP status: %s", resp.Status)
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("non-OK HTTP status: %s", resp.Status)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("read body failed: %w", err)
+		return nil, true, fmt.Errorf("read body failed: %w", err)
 	}
 
-	var wikiResp WikipediaResponse
-	if err := json.Unmarshal(body, &wikiResp); err != nil {
-		return "", fmt.Errorf("json unmarshal failed: %w", err)
-	}
-
-	for _, page := range wikiResp.Query.Pages {
-		return page.Extract, nil
-	}
+	return body, false, nil
+}
 
-	return "", fmt.Errorf("no extract found for page %q", pageName)
+// PageName converts a page title as returned by the API ("Shane Gillis")
+// into the form stored in wiki_page columns ("Shane_Gillis").
+func PageName(title string) string {
+	return strings.ReplaceAll(title, " ", "_")
 }
 
 // ExtractPageName takes a Wikipedia URL and returns the page name (e.g. "Shane_Gillis").
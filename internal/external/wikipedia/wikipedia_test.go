@@ -1,8 +1,13 @@
 package wikipedia_test
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"sketchdb.cozycole.net/internal/assert"
 	"sketchdb.cozycole.net/internal/external/wikipedia"
 )
 
@@ -23,3 +28,71 @@ func TestGetExtract(t *testing.T) {
 		t.Logf("Extract: %s", extract)
 	}
 }
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *wikipedia.Client {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	client := wikipedia.NewClient(ts.Client())
+	client.Endpoint = ts.URL
+	client.RetryDelay = time.Millisecond
+	return client
+}
+
+func TestClientGetPage(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("titles") {
+		case "Shane_Gillis":
+			w.Write([]byte(`{"query":{"pages":[{"title":"Shane Gillis","extract":"<p>Comedian</p>"}]}}`))
+		case "Gillis":
+			w.Write([]byte(`{"query":{"redirects":[{"from":"Gillis","to":"Shane Gillis"}],` +
+				`"pages":[{"title":"Shane Gillis","extract":"<p>Comedian</p>"}]}}`))
+		default:
+			w.Write([]byte(`{"query":{"pages":[{"title":"Nope","missing":true}]}}`))
+		}
+	})
+
+	page, err := client.GetPage("Shane_Gillis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, page.Extract, "<p>Comedian</p>")
+	assert.Equal(t, page.Redirected, false)
+
+	page, err = client.GetPage("Gillis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, page.Redirected, true)
+	assert.Equal(t, wikipedia.PageName(page.Title), "Shane_Gillis")
+
+	_, err = client.GetPage("Nope")
+	assert.Equal(t, errors.Is(err, wikipedia.ErrPageMissing), true)
+}
+
+func TestClientRetries(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"query":{"pages":[{"title":"A","extract":"a"}]}}`))
+	})
+
+	page, err := client.GetPage("A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, page.Extract, "a")
+	assert.Equal(t, calls, 3)
+
+	calls = 0
+	client.Retries = 0
+	_, err = client.GetPage("A")
+	if err == nil {
+		t.Error("expected error without retries")
+	}
+	assert.Equal(t, calls, 1)
+}
@@ -0,0 +1,610 @@
+package fileStore
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidKey       = errors.New("fileStore: invalid key")
+	ErrInvalidSignature = errors.New("fileStore: invalid upload signature")
+	ErrUploadExpired    = errors.New("fileStore: upload url expired")
+	ErrNoSuchUpload     = errors.New("fileStore: no such multipart upload")
+	ErrInvalidPart      = errors.New("fileStore: invalid part")
+)
+
+// multipartDir holds in progress multipart uploads, one directory per
+// upload containing the target key and a file per part
+const multipartDir = ".multipart"
+
+// LocalStorage stores files on disk under Root, keyed the same way as the
+// S3 bucket. Presigned uploads are PUT requests to UploadURL/{key} signed
+// with Secret, see UploadHandler.
+type LocalStorage struct {
+	Root      string
+	UploadURL string
+	Secret    []byte
+}
+
+// NewLocalStorage creates the root directory if needed. If secret is empty a
+// random one is generated, meaning upload urls don't survive a restart.
+func NewLocalStorage(root, uploadURL string, secret []byte) (*LocalStorage, error) {
+	err := os.MkdirAll(root, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+
+	return &LocalStorage{
+		Root:      root,
+		UploadURL: strings.TrimRight(uploadURL, "/"),
+		Secret:    secret,
+	}, nil
+}
+
+// resolve maps a key onto a path under Root, rejecting keys that would
+// escape it
+func (s *LocalStorage) resolve(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || strings.HasPrefix(cleaned+"/", "/"+multipartDir+"/") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(cleaned)), nil
+}
+
+func (s *LocalStorage) SaveFile(subPath string, file *bytes.Buffer) error {
+	return s.write(subPath, bytes.NewReader(file.Bytes()))
+}
+
+// write streams r to a temp file next to the destination and renames it
+// into place so readers never see a partial file
+func (s *LocalStorage) write(key string, r io.Reader) error {
+	dst, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	return writeFile(dst, r)
+}
+
+func writeFile(dst string, r io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0o755)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tmp.Name(), 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (s *LocalStorage) GetFile(key string) (io.ReadCloser, error) {
+	p, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+func (s *LocalStorage) GetFileRange(key string, offset, length int64) (io.ReadCloser, error) {
+	p, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.NewSectionReader(f, offset, length), f}, nil
+}
+
+// Stat derives the content type from the extension, falling back to
+// sniffing the start of the file
+func (s *LocalStorage) Stat(key string) (*ObjectInfo, error) {
+	p, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return nil, ErrNotFound
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		head := make([]byte, 512)
+		n, _ := io.ReadFull(f, head)
+		contentType = http.DetectContentType(head[:n])
+	}
+
+	return &ObjectInfo{Size: info.Size(), ContentType: contentType}, nil
+}
+
+// Locate returns the absolute path of the file
+func (s *LocalStorage) Locate(key string, duration time.Duration) (string, error) {
+	p, err := s.resolve(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(p)
+}
+
+// UploadFile ignores contentType, it's derived from the extension when served
+func (s *LocalStorage) UploadFile(key string, body io.Reader, contentType string) error {
+	return s.write(key, body)
+}
+
+// DeleteFile removes the file, deleting a missing file is not an error
+// (same as S3)
+func (s *LocalStorage) DeleteFile(subPath string) error {
+	p, err := s.resolve(subPath)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(p)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// DeleteFiles deletes every key it can, the ones that failed are returned
+// in a *DeleteFilesError
+func (s *LocalStorage) DeleteFiles(keys []string) error {
+	var failed *DeleteFilesError
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.DeleteFile(key); err != nil {
+			failed = failed.add(key, err)
+		}
+	}
+
+	if failed != nil {
+		return failed
+	}
+	return nil
+}
+
+func (s *LocalStorage) Exists(key string) (bool, error) {
+	p, err := s.resolve(key)
+	if err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return !info.IsDir(), nil
+}
+
+// PresignedUploadURL returns a url accepting a single PUT of exactly
+// contentLength bytes (if > 0) until duration has passed.
+func (s *LocalStorage) PresignedUploadURL(key string, duration time.Duration, contentLength int) (string, error) {
+	if _, err := s.resolve(key); err != nil {
+		return "", err
+	}
+
+	expires := time.Now().Add(duration).Unix()
+
+	params := url.Values{}
+	params.Set("expires", strconv.FormatInt(expires, 10))
+	params.Set("size", strconv.Itoa(contentLength))
+	params.Set("sig", s.sign(key, expires, contentLength))
+
+	return fmt.Sprintf("%s/%s?%s", s.UploadURL, strings.TrimLeft(key, "/"), params.Encode()), nil
+}
+
+func (s *LocalStorage) sign(key string, expires int64, size int) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	fmt.Fprintf(mac, "%s\n%d\n%d", strings.TrimLeft(key, "/"), expires, size)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyUpload checks the query params of a presigned upload url for key,
+// returning the allowed content length.
+func (s *LocalStorage) VerifyUpload(key string, query url.Values) (int, error) {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidSignature
+	}
+
+	size, err := strconv.Atoi(query.Get("size"))
+	if err != nil {
+		return 0, ErrInvalidSignature
+	}
+
+	expected := s.sign(key, expires, size)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("sig"))) {
+		return 0, ErrInvalidSignature
+	}
+
+	if time.Now().Unix() > expires {
+		return 0, ErrUploadExpired
+	}
+
+	return size, nil
+}
+
+// UploadHandler accepts PUT requests made to presigned upload urls. It
+// should be mounted with the UploadURL path prefix stripped.
+func (s *LocalStorage) UploadHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.Header().Set("Allow", http.MethodPut)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		key := strings.TrimLeft(r.URL.Path, "/")
+		if r.URL.Query().Has("uploadId") {
+			s.servePart(w, r, key)
+			return
+		}
+
+		size, err := s.VerifyUpload(key, r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		if size > 0 && r.ContentLength != int64(size) {
+			http.Error(w, "content length does not match signed size", http.StatusBadRequest)
+			return
+		}
+
+		if size > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, int64(size))
+		}
+
+		err = s.write(key, r.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// FileServer serves the files stored under root. Directories aren't
+// listed and in progress uploads and temp files aren't served, both get a
+// 404 as if they didn't exist.
+func FileServer(root string) http.Handler {
+	return http.FileServer(storedFiles{http.Dir(root)})
+}
+
+// storedFiles hides everything under root that isn't a stored object
+type storedFiles struct {
+	dir http.Dir
+}
+
+func (fsys storedFiles) Open(name string) (http.File, error) {
+	for _, elem := range strings.Split(path.Clean("/"+name), "/") {
+		// covers multipartDir and the temp files writeFile creates
+		if strings.HasPrefix(elem, ".") {
+			return nil, fs.ErrNotExist
+		}
+	}
+
+	f, err := fsys.dir.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, fs.ErrNotExist
+	}
+	return f, nil
+}
+
+func (s *LocalStorage) CreateMultipartUpload(key, contentType string) (string, error) {
+	if _, err := s.resolve(key); err != nil {
+		return "", err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	uploadId := hex.EncodeToString(id)
+
+	err := writeFile(filepath.Join(s.Root, multipartDir, uploadId, "key"), strings.NewReader(key))
+	if err != nil {
+		return "", err
+	}
+
+	return uploadId, nil
+}
+
+// uploadDir returns the directory of an in progress upload after checking
+// it was created for key
+func (s *LocalStorage) uploadDir(key, uploadId string) (string, error) {
+	if _, err := hex.DecodeString(uploadId); err != nil || uploadId == "" {
+		return "", ErrNoSuchUpload
+	}
+
+	dir := filepath.Join(s.Root, multipartDir, uploadId)
+	stored, err := os.ReadFile(filepath.Join(dir, "key"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", ErrNoSuchUpload
+		}
+		return "", err
+	}
+
+	if string(stored) != key {
+		return "", ErrNoSuchUpload
+	}
+
+	return dir, nil
+}
+
+func (s *LocalStorage) PresignUploadPart(key, uploadId string, partNumber int, duration time.Duration) (string, error) {
+	if partNumber < 1 || partNumber > MaxParts {
+		return "", ErrInvalidPart
+	}
+
+	if _, err := s.uploadDir(key, uploadId); err != nil {
+		return "", err
+	}
+
+	expires := time.Now().Add(duration).Unix()
+
+	params := url.Values{}
+	params.Set("uploadId", uploadId)
+	params.Set("partNumber", strconv.Itoa(partNumber))
+	params.Set("expires", strconv.FormatInt(expires, 10))
+	params.Set("sig", s.signPart(key, uploadId, partNumber, expires))
+
+	return fmt.Sprintf("%s/%s?%s", s.UploadURL, strings.TrimLeft(key, "/"), params.Encode()), nil
+}
+
+func (s *LocalStorage) signPart(key, uploadId string, partNumber int, expires int64) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	fmt.Fprintf(mac, "%s\n%d\npart\n%s\n%d", strings.TrimLeft(key, "/"), expires, uploadId, partNumber)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// servePart stores a part uploaded to a PresignUploadPart url and responds
+// with its ETag like S3 does
+func (s *LocalStorage) servePart(w http.ResponseWriter, r *http.Request, key string) {
+	query := r.URL.Query()
+	uploadId := query.Get("uploadId")
+
+	partNumber, err := strconv.Atoi(query.Get("partNumber"))
+	if err != nil {
+		http.Error(w, ErrInvalidSignature.Error(), http.StatusForbidden)
+		return
+	}
+
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		http.Error(w, ErrInvalidSignature.Error(), http.StatusForbidden)
+		return
+	}
+
+	expected := s.signPart(key, uploadId, partNumber, expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("sig"))) {
+		http.Error(w, ErrInvalidSignature.Error(), http.StatusForbidden)
+		return
+	}
+
+	if time.Now().Unix() > expires {
+		http.Error(w, ErrUploadExpired.Error(), http.StatusForbidden)
+		return
+	}
+
+	dir, err := s.uploadDir(key, uploadId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	hash := md5.New()
+	err = writeFile(filepath.Join(dir, strconv.Itoa(partNumber)), io.TeeReader(r.Body, hash))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", fmt.Sprintf("%q", hex.EncodeToString(hash.Sum(nil))))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *LocalStorage) ListUploadedParts(key, uploadId string) ([]UploadPart, error) {
+	dir, err := s.uploadDir(key, uploadId)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []UploadPart{}
+	for _, e := range entries {
+		n, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+
+		part, err := readPart(filepath.Join(dir, e.Name()), n)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+
+	return parts, nil
+}
+
+func readPart(p string, partNumber int) (UploadPart, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return UploadPart{}, err
+	}
+	defer f.Close()
+
+	hash := md5.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return UploadPart{}, err
+	}
+
+	return UploadPart{
+		PartNumber: partNumber,
+		ETag:       fmt.Sprintf("%q", hex.EncodeToString(hash.Sum(nil))),
+		Size:       size,
+	}, nil
+}
+
+// CompleteMultipartUpload concatenates the given parts into key. As with S3
+// every part must match its uploaded ETag and all but the last must be at
+// least MinPartSize.
+func (s *LocalStorage) CompleteMultipartUpload(key, uploadId string, parts []UploadPart) error {
+	dir, err := s.uploadDir(key, uploadId)
+	if err != nil {
+		return err
+	}
+
+	if len(parts) == 0 {
+		return fmt.Errorf("%w: no parts given", ErrInvalidPart)
+	}
+
+	sorted := sortParts(parts)
+	readers := make([]io.Reader, 0, len(sorted))
+	for i, p := range sorted {
+		if i > 0 && p.PartNumber == sorted[i-1].PartNumber {
+			return fmt.Errorf("%w: part %d given twice", ErrInvalidPart, p.PartNumber)
+		}
+
+		partPath := filepath.Join(dir, strconv.Itoa(p.PartNumber))
+		uploaded, err := readPart(partPath, p.PartNumber)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("%w: part %d was not uploaded", ErrInvalidPart, p.PartNumber)
+			}
+			return err
+		}
+
+		if strings.Trim(uploaded.ETag, `"`) != strings.Trim(p.ETag, `"`) {
+			return fmt.Errorf("%w: etag of part %d does not match", ErrInvalidPart, p.PartNumber)
+		}
+
+		if i < len(sorted)-1 && uploaded.Size < MinPartSize {
+			return fmt.Errorf("%w: part %d is smaller than the minimum part size", ErrInvalidPart, p.PartNumber)
+		}
+
+		f, err := os.Open(partPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+
+	err = s.write(key, io.MultiReader(readers...))
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(dir)
+}
+
+func (s *LocalStorage) AbortMultipartUpload(key, uploadId string) error {
+	dir, err := s.uploadDir(key, uploadId)
+	if err != nil {
+		if errors.Is(err, ErrNoSuchUpload) {
+			return nil
+		}
+		return err
+	}
+
+	return os.RemoveAll(dir)
+}
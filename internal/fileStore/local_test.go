@@ -0,0 +1,295 @@
+package fileStore
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"sketchdb.cozycole.net/internal/assert"
+)
+
+func TestLocalStorage(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewLocalStorage(root, "/local-upload", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.SaveFile("/person/small/a.jpg", bytes.NewBufferString("img"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "person", "small", "a.jpg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(data), "img")
+
+	exists, err := store.Exists("person/small/a.jpg")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, exists, true)
+
+	err = store.DeleteFiles([]string{"person/small/a.jpg", "person/small/missing.jpg"})
+	assert.Equal(t, err, nil)
+
+	exists, _ = store.Exists("person/small/a.jpg")
+	assert.Equal(t, exists, false)
+
+	// a key that can't be removed is reported, the others are deleted
+	err = store.SaveFile("person/small/b.jpg", bytes.NewBufferString("img"))
+	assert.Equal(t, err, nil)
+	err = store.DeleteFiles([]string{"person", "person/small/b.jpg"})
+	var failed *DeleteFilesError
+	assert.Equal(t, errors.As(err, &failed), true)
+	_, ok := failed.Failed["person"]
+	assert.Equal(t, ok, true)
+	assert.Equal(t, len(failed.Failed), 1)
+
+	exists, _ = store.Exists("person/small/b.jpg")
+	assert.Equal(t, exists, false)
+
+	// keys can't escape the root
+	err = store.SaveFile("../../etc/x", bytes.NewBufferString("x"))
+	assert.Equal(t, err, nil)
+	_, err = os.Stat(filepath.Join(root, "etc", "x"))
+	assert.Equal(t, err, nil)
+}
+
+func TestLocalStorageUpload(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "/local-upload", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := http.StripPrefix("/local-upload", store.UploadHandler())
+
+	put := func(rawURL, body string) int {
+		req := httptest.NewRequest(http.MethodPut, rawURL, strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	uploadURL, err := store.PresignedUploadURL("video/a.mp4", time.Minute, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, put(uploadURL, "toolong"), http.StatusBadRequest)
+	assert.Equal(t, put(strings.Replace(uploadURL, "a.mp4", "b.mp4", 1), "hello"), http.StatusForbidden)
+	assert.Equal(t, put(uploadURL, "hello"), http.StatusOK)
+
+	exists, _ := store.Exists("video/a.mp4")
+	assert.Equal(t, exists, true)
+
+	expiredURL, _ := store.PresignedUploadURL("video/c.mp4", -time.Minute, 0)
+	assert.Equal(t, put(expiredURL, "hello"), http.StatusForbidden)
+
+	u, _ := url.Parse(expiredURL)
+	_, err = store.VerifyUpload("video/c.mp4", u.Query())
+	assert.Equal(t, errors.Is(err, ErrUploadExpired), true)
+}
+
+func TestLocalStorageMultipart(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "/local-upload", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := http.StripPrefix("/local-upload", store.UploadHandler())
+
+	key := "video/big.mp4"
+	uploadId, err := store.CreateMultipartUpload(key, "video/mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	putPart := func(partNumber int, body []byte) (int, string) {
+		partURL, err := store.PresignUploadPart(key, uploadId, partNumber, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPut, partURL, bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code, rr.Header().Get("ETag")
+	}
+
+	first := bytes.Repeat([]byte("a"), MinPartSize)
+	code, etag1 := putPart(1, first)
+	assert.Equal(t, code, http.StatusOK)
+	code, etag2 := putPart(2, []byte("tail"))
+	assert.Equal(t, code, http.StatusOK)
+
+	parts, err := store.ListUploadedParts(key, uploadId)
+	assert.Equal(t, err, nil)
+	assert.DeepEqual(t, parts, []UploadPart{
+		{PartNumber: 1, ETag: etag1, Size: MinPartSize},
+		{PartNumber: 2, ETag: etag2, Size: 4},
+	})
+
+	// parts for another key can't be uploaded with this upload id
+	_, err = store.PresignUploadPart("video/other.mp4", uploadId, 1, time.Minute)
+	assert.Equal(t, errors.Is(err, ErrNoSuchUpload), true)
+
+	err = store.CompleteMultipartUpload(key, uploadId, []UploadPart{
+		{PartNumber: 2, ETag: etag1}, {PartNumber: 1, ETag: etag1},
+	})
+	assert.Equal(t, errors.Is(err, ErrInvalidPart), true)
+
+	err = store.CompleteMultipartUpload(key, uploadId, []UploadPart{
+		{PartNumber: 2, ETag: etag2}, {PartNumber: 1, ETag: etag1},
+	})
+	assert.Equal(t, err, nil)
+
+	f, err := store.GetFile(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(data), MinPartSize+4)
+	assert.Equal(t, string(data[MinPartSize:]), "tail")
+
+	_, err = store.ListUploadedParts(key, uploadId)
+	assert.Equal(t, errors.Is(err, ErrNoSuchUpload), true)
+	assert.Equal(t, store.AbortMultipartUpload(key, uploadId), nil)
+}
+
+func TestLocalStorageMultipartAbort(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "/local-upload", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uploadId, err := store.CreateMultipartUpload("video/a.mp4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the multipart directory isn't reachable as a regular key
+	_, err = store.GetFile(".multipart/" + uploadId + "/key")
+	assert.Equal(t, errors.Is(err, ErrInvalidKey), true)
+
+	assert.Equal(t, store.AbortMultipartUpload("video/a.mp4", uploadId), nil)
+
+	_, err = store.PresignUploadPart("video/a.mp4", uploadId, 1, time.Minute)
+	assert.Equal(t, errors.Is(err, ErrNoSuchUpload), true)
+}
+
+func TestFileServer(t *testing.T) {
+	root := t.TempDir()
+	store, err := NewLocalStorage(root, "/local-upload", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.SaveFile("person/small/a.jpg", bytes.NewBufferString("img"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploadId, err := store.CreateMultipartUpload("video/a.mp4", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := http.StripPrefix("/media", FileServer(root))
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Stored File",
+			urlPath:  "/media/person/small/a.jpg",
+			wantCode: http.StatusOK,
+			wantBody: "img",
+		},
+		{
+			name:     "Missing File",
+			urlPath:  "/media/person/small/b.jpg",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Root Directory",
+			urlPath:  "/media/",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Directory",
+			urlPath:  "/media/person/small/",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Directory Without Slash",
+			urlPath:  "/media/person",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Multipart Directory",
+			urlPath:  "/media/.multipart/",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Multipart Upload",
+			urlPath:  "/media/.multipart/" + uploadId + "/key",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.urlPath, nil))
+
+			assert.Equal(t, rr.Code, tt.wantCode)
+			if tt.wantBody != "" {
+				assert.Equal(t, rr.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestLocalStorageStatAndRange(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir(), "/local-upload", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without an extension the content type is sniffed
+	err = store.UploadFile("video/a", strings.NewReader("\x1a\x45\xdf\xa3456789"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := store.Stat("video/a")
+	assert.Equal(t, err, nil)
+	assert.DeepEqual(t, info, &ObjectInfo{Size: 10, ContentType: "video/webm"})
+
+	_, err = store.Stat("video/missing")
+	assert.Equal(t, err, ErrNotFound)
+
+	r := &ObjectReader{Store: store, Key: "video/a", Size: info.Size}
+	buf := make([]byte, 4)
+
+	n, err := r.ReadAt(buf, 3)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, string(buf[:n]), "\xa3456")
+
+	n, err = r.ReadAt(buf, 8)
+	assert.Equal(t, err, io.EOF)
+	assert.Equal(t, string(buf[:n]), "89")
+}
@@ -0,0 +1,34 @@
+package fileStore
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"sketchdb.cozycole.net/internal/metrics"
+)
+
+var s3Duration = metrics.Default.NewHistogramVec(
+	"sketchdb_s3_request_duration_seconds",
+	"Latency of S3 API calls, including retries.",
+	metrics.DefaultBuckets,
+	"storage", "operation", "result",
+)
+
+const metricsHandlerName = "sketchdb.metrics"
+
+// Instrument records the latency of every call made with the storage's
+// client under the given storage name, e.g. "media" or "archive".
+// Presigning a request doesn't send it, so presigned urls aren't counted.
+func (s *S3Storage) Instrument(name string) {
+	s.Client.Handlers.Complete.RemoveByName(metricsHandlerName)
+	s.Client.Handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: metricsHandlerName,
+		Fn: func(r *request.Request) {
+			result := "ok"
+			if r.Error != nil {
+				result = "error"
+			}
+			s3Duration.Observe(time.Since(r.Time).Seconds(), name, r.Operation.Name, result)
+		},
+	})
+}
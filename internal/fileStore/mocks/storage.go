@@ -2,6 +2,11 @@ package mock
 
 import (
 	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	"sketchdb.cozycole.net/internal/fileStore"
 )
 
 type FileStorage struct{}
@@ -14,6 +19,54 @@ func (s *FileStorage) DeleteFile(key string) error {
 	return nil
 }
 
+func (s *FileStorage) DeleteFiles(keys []string) error {
+	return nil
+}
+
+func (s *FileStorage) Exists(key string) (bool, error) {
+	return false, nil
+}
+
+func (s *FileStorage) GetFile(key string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func (s *FileStorage) GetFileRange(key string, offset, length int64) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+func (s *FileStorage) Stat(key string) (*fileStore.ObjectInfo, error) {
+	return nil, fileStore.ErrNotFound
+}
+
+func (s *FileStorage) PresignedUploadURL(key string, duration time.Duration, size int) (string, error) {
+	return "", nil
+}
+
+func (s *FileStorage) UploadFile(key string, body io.Reader, contentType string) error {
+	return nil
+}
+
+func (s *FileStorage) CreateMultipartUpload(key, contentType string) (string, error) {
+	return "", nil
+}
+
+func (s *FileStorage) PresignUploadPart(key, uploadId string, partNumber int, duration time.Duration) (string, error) {
+	return "", nil
+}
+
+func (s *FileStorage) ListUploadedParts(key, uploadId string) ([]fileStore.UploadPart, error) {
+	return nil, nil
+}
+
+func (s *FileStorage) CompleteMultipartUpload(key, uploadId string, parts []fileStore.UploadPart) error {
+	return nil
+}
+
+func (s *FileStorage) AbortMultipartUpload(key, uploadId string) error {
+	return nil
+}
+
 func (s *FileStorage) Type() string {
 	return "Mock"
 }
@@ -0,0 +1,36 @@
+package fileStore
+
+import (
+	"errors"
+	"io"
+)
+
+// ObjectReader reads an object with range requests, allowing parsers that
+// need random access to read only the parts of a file they need
+type ObjectReader struct {
+	Store FileStorageInterface
+	Key   string
+	Size  int64
+}
+
+func (o *ObjectReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= o.Size {
+		return 0, io.EOF
+	}
+
+	length := min(int64(len(p)), o.Size-off)
+	body, err := o.Store.GetFileRange(o.Key, off, length)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	n, err := io.ReadFull(body, p[:length])
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
@@ -2,21 +2,100 @@ package fileStore
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 )
 
 type FileStorageInterface interface {
 	DeleteFile(string) error
 	Exists(string) (bool, error)
+	GetFile(string) (io.ReadCloser, error)
+	// GetFileRange reads length bytes starting at offset
+	GetFileRange(key string, offset, length int64) (io.ReadCloser, error)
+	// Stat returns ErrNotFound if the object doesn't exist
+	Stat(key string) (*ObjectInfo, error)
 	PresignedUploadURL(string, time.Duration, int) (string, error)
 	SaveFile(string, *bytes.Buffer) error
+	// UploadFile streams a (potentially large) private object such as a
+	// video, unlike SaveFile which is for small publicly served images
+	UploadFile(key string, body io.Reader, contentType string) error
+	// DeleteFiles returns a *DeleteFilesError naming the keys that
+	// couldn't be deleted when only some of them failed
 	DeleteFiles([]string) error
+
+	// Multipart uploads let clients upload large files in parts, retrying
+	// or resuming individual parts. Parts other than the last must be at
+	// least MinPartSize bytes.
+	CreateMultipartUpload(key, contentType string) (string, error)
+	PresignUploadPart(key, uploadId string, partNumber int, duration time.Duration) (string, error)
+	ListUploadedParts(key, uploadId string) ([]UploadPart, error)
+	CompleteMultipartUpload(key, uploadId string, parts []UploadPart) error
+	AbortMultipartUpload(key, uploadId string) error
+}
+
+// Locator is implemented by storages whose objects can be read directly by
+// external tools such as ffprobe, Locate returns a url or local path
+type Locator interface {
+	Locate(key string, duration time.Duration) (string, error)
+}
+
+var ErrNotFound = errors.New("fileStore: object not found")
+
+// DeleteFilesError is returned by DeleteFiles when some of the keys
+// couldn't be deleted, every key not in Failed was
+type DeleteFilesError struct {
+	Failed map[string]error
+}
+
+func (e *DeleteFilesError) Error() string {
+	keys := make([]string, 0, len(e.Failed))
+	for key := range e.Failed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	msgs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", key, e.Failed[key]))
+	}
+	return fmt.Sprintf("fileStore: failed to delete %d objects: %s", len(keys), strings.Join(msgs, "; "))
+}
+
+// add records the failure of key, creating the error if needed
+func (e *DeleteFilesError) add(key string, err error) *DeleteFilesError {
+	if e == nil {
+		e = &DeleteFilesError{Failed: map[string]error{}}
+	}
+	e.Failed[key] = err
+	return e
+}
+
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+}
+
+const (
+	MinPartSize = 5 << 20
+	MaxParts    = 10_000
+)
+
+// UploadPart identifies an uploaded part of a multipart upload, ETag is the
+// value returned in the ETag header of the part upload response
+type UploadPart struct {
+	PartNumber int    `json:"partNumber"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
 }
 
 type S3Storage struct {
@@ -37,6 +116,71 @@ func (s *S3Storage) SaveFile(subPath string, file *bytes.Buffer) error {
 	return err
 }
 
+func (s *S3Storage) GetFile(key string) (io.ReadCloser, error) {
+	out, err := s.Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s *S3Storage) GetFileRange(key string, offset, length int64) (io.ReadCloser, error) {
+	out, err := s.Client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s *S3Storage) Stat(key string) (*ObjectInfo, error) {
+	out, err := s.Client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && (aerr.Code() == s3.ErrCodeNoSuchKey || aerr.Code() == "NotFound") {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &ObjectInfo{
+		Size:        aws.Int64Value(out.ContentLength),
+		ContentType: aws.StringValue(out.ContentType),
+	}, nil
+}
+
+// Locate returns a presigned GET url for the object
+func (s *S3Storage) Locate(key string, duration time.Duration) (string, error) {
+	req, _ := s.Client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	})
+	return req.Presign(duration)
+}
+
+func (s *S3Storage) UploadFile(key string, body io.Reader, contentType string) error {
+	uploader := s3manager.NewUploaderWithClient(s.Client)
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+
+	_, err := uploader.Upload(input)
+	return err
+}
+
 func (s *S3Storage) DeleteFile(subPath string) error {
 	_, err := s.Client.DeleteObject(&s3.DeleteObjectInput{
 		Bucket: &s.BucketName,
@@ -85,24 +229,28 @@ func (s *S3Storage) PresignedUploadURL(filename string, duration time.Duration,
 	return url, nil
 }
 
+// DeleteFiles deletes keys in chunks of up to 1000. Keys S3 refuses to
+// delete, or whose chunk failed, are returned in a *DeleteFilesError.
 func (s *S3Storage) DeleteFiles(keys []string) error {
 	const maxDeleteObjects = 1000
 
+	var failed *DeleteFilesError
 	for start := 0; start < len(keys); start += maxDeleteObjects {
 		end := start + maxDeleteObjects
 		if end > len(keys) {
 			end = len(keys)
 		}
 
-		if err := s.deleteFilesChunk(keys[start:end]); err != nil {
-			return err
-		}
+		failed = s.deleteFilesChunk(keys[start:end], failed)
 	}
 
+	if failed != nil {
+		return failed
+	}
 	return nil
 }
 
-func (s *S3Storage) deleteFilesChunk(keys []string) error {
+func (s *S3Storage) deleteFilesChunk(keys []string, failed *DeleteFilesError) *DeleteFilesError {
 	objects := make([]*s3.ObjectIdentifier, 0, len(keys))
 
 	for _, key := range keys {
@@ -116,20 +264,122 @@ func (s *S3Storage) deleteFilesChunk(keys []string) error {
 	}
 
 	if len(objects) == 0 {
-		return nil
+		return failed
 	}
 
-	_, err := s.Client.DeleteObjects(&s3.DeleteObjectsInput{
+	// quiet mode still reports the keys that failed
+	out, err := s.Client.DeleteObjects(&s3.DeleteObjectsInput{
 		Bucket: aws.String(s.BucketName),
 		Delete: &s3.Delete{
 			Objects: objects,
 			Quiet:   aws.Bool(true),
 		},
 	})
+	if err != nil {
+		err = fmt.Errorf("delete s3 objects chunk: %w", err)
+		for _, obj := range objects {
+			failed = failed.add(aws.StringValue(obj.Key), err)
+		}
+		return failed
+	}
+
+	for _, e := range out.Errors {
+		err := fmt.Errorf("%s: %s", aws.StringValue(e.Code), aws.StringValue(e.Message))
+		failed = failed.add(aws.StringValue(e.Key), err)
+	}
 
+	return failed
+}
+
+func (s *S3Storage) CreateMultipartUpload(key, contentType string) (string, error) {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(s.BucketName),
+		Key:    aws.String(key),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+
+	out, err := s.Client.CreateMultipartUpload(input)
 	if err != nil {
-		return fmt.Errorf("delete s3 objects chunk: %w", err)
+		return "", err
 	}
 
-	return nil
+	return aws.StringValue(out.UploadId), nil
+}
+
+func (s *S3Storage) PresignUploadPart(key, uploadId string, partNumber int, duration time.Duration) (string, error) {
+	req, _ := s.Client.UploadPartRequest(&s3.UploadPartInput{
+		Bucket:     aws.String(s.BucketName),
+		Key:        aws.String(key),
+		UploadId:   aws.String(uploadId),
+		PartNumber: aws.Int64(int64(partNumber)),
+	})
+
+	return req.Presign(duration)
+}
+
+func (s *S3Storage) ListUploadedParts(key, uploadId string) ([]UploadPart, error) {
+	parts := []UploadPart{}
+	err := s.Client.ListPartsPages(&s3.ListPartsInput{
+		Bucket:   aws.String(s.BucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadId),
+	}, func(page *s3.ListPartsOutput, lastPage bool) bool {
+		for _, p := range page.Parts {
+			parts = append(parts, UploadPart{
+				PartNumber: int(aws.Int64Value(p.PartNumber)),
+				ETag:       aws.StringValue(p.ETag),
+				Size:       aws.Int64Value(p.Size),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parts, nil
+}
+
+func (s *S3Storage) CompleteMultipartUpload(key, uploadId string, parts []UploadPart) error {
+	sorted := sortParts(parts)
+	completed := make([]*s3.CompletedPart, 0, len(sorted))
+	for _, p := range sorted {
+		completed = append(completed, &s3.CompletedPart{
+			ETag:       aws.String(p.ETag),
+			PartNumber: aws.Int64(int64(p.PartNumber)),
+		})
+	}
+
+	_, err := s.Client.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.BucketName),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadId),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	return err
+}
+
+// AbortMultipartUpload discards the upload and any uploaded parts, aborting
+// an upload that no longer exists is not an error
+func (s *S3Storage) AbortMultipartUpload(key, uploadId string) error {
+	_, err := s.Client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.BucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadId),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchUpload {
+		return nil
+	}
+	return err
+}
+
+func sortParts(parts []UploadPart) []UploadPart {
+	sorted := make([]UploadPart, len(parts))
+	copy(sorted, parts)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].PartNumber < sorted[j].PartNumber
+	})
+	return sorted
 }
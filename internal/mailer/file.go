@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// FileMailer stands in for a real mailer in development. Messages are
+// written to Dir as .eml files, or only logged when Dir is empty.
+type FileMailer struct {
+	Dir    string
+	Sender string
+	Log    *log.Logger
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+func (m *FileMailer) Send(msg *Message) error {
+	if m.Dir == "" {
+		m.Log.Printf("mail to %s: %s\n%s", msg.To, msg.Subject, msg.PlainBody)
+		return nil
+	}
+
+	body, err := msg.Bytes(m.Sender)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf(
+		"%s-%s.eml",
+		time.Now().UTC().Format("20060102T150405.000000000"),
+		unsafeFileChars.ReplaceAllString(msg.To, "_"),
+	)
+
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, body, 0o644); err != nil {
+		return err
+	}
+
+	m.Log.Printf("mail to %s written to %s", msg.To, path)
+	return nil
+}
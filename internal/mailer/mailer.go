@@ -0,0 +1,108 @@
+// Package mailer renders the emails sent to users and delivers them with
+// a pluggable Mailer.
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmlTemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed "templates"
+var templateFS embed.FS
+
+// Mailer delivers a rendered message
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+type Message struct {
+	To        string
+	Subject   string
+	PlainBody string
+	HTMLBody  string
+}
+
+// NewMessage renders the subject, plainBody and htmlBody templates defined
+// in templateFile, only htmlBody is html escaped
+func NewMessage(recipient, templateFile string, data any) (*Message, error) {
+	patterns := "templates/" + templateFile
+
+	text, err := template.New("email").ParseFS(templateFS, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := htmlTemplate.New("email").ParseFS(templateFS, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &Message{To: recipient}
+
+	var buf bytes.Buffer
+	if err := text.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return nil, fmt.Errorf("render subject: %w", err)
+	}
+	msg.Subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := text.ExecuteTemplate(&buf, "plainBody", data); err != nil {
+		return nil, fmt.Errorf("render plain body: %w", err)
+	}
+	msg.PlainBody = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := html.ExecuteTemplate(&buf, "htmlBody", data); err != nil {
+		return nil, fmt.Errorf("render html body: %w", err)
+	}
+	msg.HTMLBody = strings.TrimSpace(buf.String())
+
+	return msg, nil
+}
+
+// Bytes returns the message as a multipart/alternative MIME email
+func (m *Message) Bytes(from string) ([]byte, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	alternatives := []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", m.PlainBody},
+		{"text/html; charset=UTF-8", m.HTMLBody},
+	}
+
+	for _, alt := range alternatives {
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {alt.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write([]byte(alt.content)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", m.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", w.Boundary())
+	buf.Write(body.Bytes())
+
+	return buf.Bytes(), nil
+}
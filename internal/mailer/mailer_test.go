@@ -0,0 +1,65 @@
+package mailer
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sketchdb.cozycole.net/internal/assert"
+)
+
+func TestNewMessage(t *testing.T) {
+	data := map[string]string{
+		"Username":  "bob",
+		"URL":       "https://example.com/activate?token=a&b",
+		"ExpiresIn": "3 days",
+	}
+
+	msg, err := NewMessage("bob@example.com", "user_activation.tmpl", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, msg.To, "bob@example.com")
+	assert.Equal(t, msg.Subject, "Activate your SketchDB account")
+	assert.StringContains(t, msg.PlainBody, "token=a&b")
+	assert.StringContains(t, msg.HTMLBody, "token=a&amp;b")
+
+	_, err = NewMessage("bob@example.com", "missing.tmpl", data)
+	assert.Equal(t, err != nil, true)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	var logs bytes.Buffer
+	m := &FileMailer{Dir: dir, Sender: "noreply@example.com", Log: log.New(&logs, "", 0)}
+
+	msg := &Message{To: "bob@example.com", Subject: "Hi", PlainBody: "plain", HTMLBody: "<p>html</p>"}
+	err := m.Send(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	assert.Equal(t, len(files), 1)
+
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	data, _ := io.ReadAll(f)
+	assert.StringContains(t, string(data), "To: bob@example.com\r\n")
+	assert.StringContains(t, string(data), "multipart/alternative")
+	assert.StringContains(t, string(data), "<p>html</p>")
+
+	// without a directory the message is only logged
+	logs.Reset()
+	m.Dir = ""
+	assert.NilError(t, m.Send(msg))
+	assert.StringContains(t, logs.String(), "plain")
+}
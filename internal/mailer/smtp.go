@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTPMailer sends mail through an SMTP server, STARTTLS is used when the
+// server supports it
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Sender   string
+}
+
+func (m *SMTPMailer) Send(msg *Message) error {
+	body, err := msg.Bytes(m.Sender)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	return smtp.SendMail(addr, auth, m.Sender, []string{msg.To}, body)
+}
@@ -7,8 +7,10 @@ import (
 	_ "image/jpeg"
 	"math"
 	"path"
+	"time"
 
 	"sketchdb.cozycole.net/internal/fileStore"
+	"sketchdb.cozycole.net/internal/metrics"
 )
 
 const (
@@ -25,6 +27,21 @@ const (
 	SmallProfileWidth     = 88
 )
 
+var pipelineDuration = metrics.Default.NewHistogramVec(
+	"sketchdb_image_pipeline_duration_seconds",
+	"Time taken by each stage of the image pipeline.",
+	[]float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	"stage", "type", "result",
+)
+
+func observeStage(stage string, imgType ImageType, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	pipelineDuration.Observe(time.Since(start).Seconds(), stage, string(imgType), result)
+}
+
 // Given a src image, saves cropped/covered images based on small / medium / large dimensions
 // to imgStore
 //
@@ -41,12 +58,16 @@ func RunImagePipeline(
 	imgStore fileStore.FileStorageInterface,
 	cropBorders bool,
 ) error {
+	start := time.Now()
 	variants, err := CreateImageVariants(src, maxSize, imgType, cropBorders)
+	observeStage("process", imgType, start, err)
 	if err != nil {
 		return err
 	}
 
+	start = time.Now()
 	err = SaveImageVariants(imgStore, prefix, imgName, variants)
+	observeStage("save", imgType, start, err)
 	if err != nil {
 		return err
 	}
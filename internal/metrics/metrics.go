@@ -0,0 +1,425 @@
+// Package metrics is a small Prometheus compatible metrics registry. It
+// supports counters, gauges and histograms with labels, plus metrics that
+// are collected when scraped, and writes them in the Prometheus text
+// exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default is the registry package level metrics are registered with
+var Default = NewRegistry()
+
+// DefaultBuckets are histogram buckets in seconds suited to request
+// latencies
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+type sample struct {
+	suffix      string
+	labelNames  []string
+	labelValues []string
+	value       float64
+}
+
+type metric interface {
+	samples() []sample
+}
+
+type entry struct {
+	name, help, kind string
+	metric           metric
+}
+
+type Registry struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{entries: map[string]*entry{}}
+}
+
+// register adds a metric, registering the same name twice is a programming
+// error so it panics
+func (r *Registry) register(name, help, kind string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[name]; ok {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.entries[name] = &entry{name: name, help: help, kind: kind, metric: m}
+}
+
+// WriteTo writes every metric in the text exposition format, sorted by
+// name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	entries := make([]*entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, e)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+
+	var b strings.Builder
+	for _, e := range entries {
+		samples := e.metric.samples()
+		if len(samples) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "# HELP %s %s\n", e.name, escapeHelp(e.help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", e.name, e.kind)
+		for _, s := range samples {
+			b.WriteString(e.name)
+			b.WriteString(s.suffix)
+			writeLabels(&b, s.labelNames, s.labelValues)
+			b.WriteByte(' ')
+			b.WriteString(formatFloat(s.value))
+			b.WriteByte('\n')
+		}
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Handler serves the registry's metrics to a Prometheus scraper
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+func writeLabels(b *strings.Builder, names, values []string) {
+	if len(names) == 0 {
+		return
+	}
+
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// series holds the children of a labeled metric keyed by their label
+// values
+type series[T any] struct {
+	labelNames []string
+	mu         sync.Mutex
+	children   map[string]*T
+	values     map[string][]string
+}
+
+func (s *series[T]) init(labelNames []string) {
+	s.labelNames = labelNames
+	s.children = map[string]*T{}
+	s.values = map[string][]string{}
+}
+
+// child returns the child for the label values, creating it with init if
+// it doesn't exist yet. The series lock must be held.
+func (s *series[T]) child(labelValues []string, init func() *T) *T {
+	if len(labelValues) != len(s.labelNames) {
+		panic(fmt.Sprintf("metrics: got %d label values for labels %v", len(labelValues), s.labelNames))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+	c, ok := s.children[key]
+	if !ok {
+		c = init()
+		s.children[key] = c
+		s.values[key] = slices.Clone(labelValues)
+	}
+	return c
+}
+
+// sortedKeys returns the child keys in a stable order for output
+func (s *series[T]) sortedKeys() []string {
+	keys := make([]string, 0, len(s.children))
+	for k := range s.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	series[float64]
+}
+
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{}
+	c.init(labelNames)
+	r.register(name, help, kindCounter, c)
+	return c
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increases the counter by v, counters can't go down so a negative v
+// panics
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("metrics: counter decreased")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.child(labelValues, func() *float64 { return new(float64) }) += v
+}
+
+func (c *CounterVec) samples() []sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var samples []sample
+	for _, k := range c.sortedKeys() {
+		samples = append(samples, sample{
+			labelNames:  c.labelNames,
+			labelValues: c.values[k],
+			value:       *c.children[k],
+		})
+	}
+	return samples
+}
+
+// GaugeVec is a gauge partitioned by labels
+type GaugeVec struct {
+	series[float64]
+}
+
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{}
+	g.init(labelNames)
+	r.register(name, help, kindGauge, g)
+	return g
+}
+
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.child(labelValues, func() *float64 { return new(float64) }) = v
+}
+
+func (g *GaugeVec) Add(v float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.child(labelValues, func() *float64 { return new(float64) }) += v
+}
+
+func (g *GaugeVec) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+func (g *GaugeVec) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *GaugeVec) samples() []sample {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var samples []sample
+	for _, k := range g.sortedKeys() {
+		samples = append(samples, sample{
+			labelNames:  g.labelNames,
+			labelValues: g.values[k],
+			value:       *g.children[k],
+		})
+	}
+	return samples
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec counts observations into buckets, partitioned by labels
+type HistogramVec struct {
+	series[histogram]
+	buckets []float64
+}
+
+// NewHistogramVec registers a histogram with the given upper bucket
+// bounds, a +Inf bucket is always added
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	h := &HistogramVec{buckets: buckets}
+	h.init(labelNames)
+	r.register(name, help, kindHistogram, h)
+	return h
+}
+
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := h.child(labelValues, func() *histogram {
+		return &histogram{counts: make([]uint64, len(h.buckets))}
+	})
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			c.counts[i]++
+		}
+	}
+	c.sum += v
+	c.count++
+}
+
+func (h *HistogramVec) samples() []sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	bucketLabels := append(slices.Clone(h.labelNames), "le")
+
+	var samples []sample
+	for _, k := range h.sortedKeys() {
+		c := h.children[k]
+		values := h.values[k]
+
+		for i, upper := range h.buckets {
+			samples = append(samples, sample{
+				suffix:      "_bucket",
+				labelNames:  bucketLabels,
+				labelValues: append(slices.Clone(values), formatFloat(upper)),
+				value:       float64(c.counts[i]),
+			})
+		}
+
+		samples = append(samples,
+			sample{
+				suffix:      "_bucket",
+				labelNames:  bucketLabels,
+				labelValues: append(slices.Clone(values), "+Inf"),
+				value:       float64(c.count),
+			},
+			sample{suffix: "_sum", labelNames: h.labelNames, labelValues: values, value: c.sum},
+			sample{suffix: "_count", labelNames: h.labelNames, labelValues: values, value: float64(c.count)},
+		)
+	}
+	return samples
+}
+
+// Sample is a value reported by a collected metric
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// collected is a metric whose value is read when it's scraped
+type collected struct {
+	labelNames []string
+	collect    func() ([]Sample, error)
+}
+
+func (c *collected) samples() []sample {
+	values, err := c.collect()
+	if err != nil {
+		// a failed collection is left out of the scrape rather than
+		// reported as zero
+		return nil
+	}
+
+	// collectors often build samples from a map, sort them so scrapes
+	// are stable
+	sort.Slice(values, func(i, j int) bool {
+		return slices.Compare(values[i].LabelValues, values[j].LabelValues) < 0
+	})
+
+	samples := make([]sample, 0, len(values))
+	for _, v := range values {
+		samples = append(samples, sample{
+			labelNames:  c.labelNames,
+			labelValues: v.LabelValues,
+			value:       v.Value,
+		})
+	}
+	return samples
+}
+
+// NewGaugeFunc registers a gauge whose value is read from fn on each
+// scrape
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(name, help, kindGauge, &collected{
+		collect: func() ([]Sample, error) {
+			return []Sample{{Value: fn()}}, nil
+		},
+	})
+}
+
+// NewCounterFunc registers a counter whose value is read from fn on each
+// scrape, fn must never return less than it did before
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(name, help, kindCounter, &collected{
+		collect: func() ([]Sample, error) {
+			return []Sample{{Value: fn()}}, nil
+		},
+	})
+}
+
+// NewGaugeCollector registers a labeled gauge whose samples are returned
+// by collect on each scrape, e.g. row counts from the database
+func (r *Registry) NewGaugeCollector(name, help string, labelNames []string, collect func() ([]Sample, error)) {
+	r.register(name, help, kindGauge, &collected{labelNames: labelNames, collect: collect})
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sketchdb.cozycole.net/internal/assert"
+)
+
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestCounterVec(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("requests_total", "Requests served.", "method", "status")
+
+	c.Inc("GET", "200")
+	c.Inc("GET", "200")
+	c.Add(3, "POST", "500")
+
+	assert.Equal(t, scrape(t, r), `# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{method="GET",status="200"} 2
+requests_total{method="POST",status="500"} 3
+`)
+}
+
+func TestGaugeVec(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGaugeVec("in_flight", "In flight requests.")
+
+	g.Inc()
+	g.Inc()
+	g.Dec()
+	assert.StringContains(t, scrape(t, r), "\nin_flight 1\n")
+
+	g.Set(7.5)
+	assert.StringContains(t, scrape(t, r), "\nin_flight 7.5\n")
+}
+
+func TestHistogramVec(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("duration_seconds", "Durations.", []float64{1, 0.1}, "stage")
+
+	h.Observe(0.05, "save")
+	h.Observe(0.5, "save")
+	h.Observe(2, "save")
+
+	assert.Equal(t, scrape(t, r), `# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{stage="save",le="0.1"} 1
+duration_seconds_bucket{stage="save",le="1"} 2
+duration_seconds_bucket{stage="save",le="+Inf"} 3
+duration_seconds_sum{stage="save"} 2.55
+duration_seconds_count{stage="save"} 3
+`)
+}
+
+func TestCollectors(t *testing.T) {
+	r := NewRegistry()
+
+	conns := 4.0
+	r.NewGaugeFunc("pool_conns", "Open connections.", func() float64 { return conns })
+	r.NewCounterFunc("pool_acquires_total", "Acquires.", func() float64 { return 10 })
+
+	var collectErr error
+	r.NewGaugeCollector("jobs", "Jobs by status.", []string{"status"}, func() ([]Sample, error) {
+		if collectErr != nil {
+			return nil, collectErr
+		}
+		return []Sample{
+			{LabelValues: []string{"failed"}, Value: 1},
+			{LabelValues: []string{"pending"}, Value: 12},
+		}, nil
+	})
+
+	out := scrape(t, r)
+	assert.StringContains(t, out, "# TYPE jobs gauge\njobs{status=\"failed\"} 1\njobs{status=\"pending\"} 12\n")
+	assert.StringContains(t, out, "# TYPE pool_acquires_total counter\npool_acquires_total 10\n")
+	assert.StringContains(t, out, "\npool_conns 4\n")
+
+	// metrics are written sorted by name
+	assert.Equal(t, strings.Index(out, "jobs") < strings.Index(out, "pool_acquires_total"), true)
+
+	// a failed collection leaves the metric out
+	collectErr = errors.New("db down")
+	conns = 5
+	out = scrape(t, r)
+	assert.Equal(t, strings.Contains(out, "jobs"), false)
+	assert.StringContains(t, out, "\npool_conns 5\n")
+}
+
+func TestLabelEscaping(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("errors_total", "Errors by\nmessage.", "msg")
+	c.Inc("say \"hi\"\\\n")
+
+	out := scrape(t, r)
+	assert.StringContains(t, out, "# HELP errors_total Errors by\\nmessage.\n")
+	assert.StringContains(t, out, `errors_total{msg="say \"hi\"\\\n"} 1`)
+}
+
+func TestDuplicateRegistration(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("a_total", "A.")
+
+	defer func() {
+		assert.Equal(t, recover() != nil, true)
+	}()
+	r.NewGaugeVec("a_total", "A again.")
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("a_total", "A.").Inc()
+
+	rr := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, rr.Code, http.StatusOK)
+	assert.StringContains(t, rr.Header().Get("Content-Type"), "text/plain; version=0.0.4")
+	assert.StringContains(t, rr.Body.String(), "a_total 1\n")
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// audited entity types
+const (
+	AuditSketch     = "sketch"
+	AuditCastMember = "cast_member"
+	AuditQuotes     = "quotes"
+	AuditPerson     = "person"
+	AuditShow       = "show"
+	AuditCharacter  = "character"
+	AuditCreator    = "creator"
+	AuditSeries     = "series"
+	AuditRecurring  = "recurring"
+	AuditTag        = "tag"
+)
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	AuditRevert = "revert"
+)
+
+func IsAuditEntity(entityType string) bool {
+	switch entityType {
+	case AuditSketch, AuditCastMember, AuditQuotes, AuditPerson, AuditShow,
+		AuditCharacter, AuditCreator, AuditSeries, AuditRecurring, AuditTag:
+		return true
+	}
+	return false
+}
+
+// AuditEntry records a single write to an entity. Before and After are
+// JSON snapshots of the entity, Before is null for creates and After for
+// deletes. Changes maps each changed top level field to its before and
+// after values.
+type AuditEntry struct {
+	ID         *int            `json:"id"`
+	ActorID    *int            `json:"actorId"`
+	ActorName  *string         `json:"actorName"`
+	EntityType *string         `json:"entityType"`
+	EntityID   *int            `json:"entityId"`
+	Action     *string         `json:"action"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	Changes    json.RawMessage `json:"changes"`
+	CreatedAt  *time.Time      `json:"createdAt"`
+}
+
+type AuditModelInterface interface {
+	Get(id int) (*AuditEntry, error)
+	GetForActor(actorId int, f *Filter) ([]*AuditEntry, Metadata, error)
+	GetForEntity(entityType string, entityId int, f *Filter) ([]*AuditEntry, Metadata, error)
+	Insert(entry *AuditEntry) error
+}
+
+type AuditModel struct {
+	DB *pgxpool.Pool
+}
+
+const auditColumns = `
+	a.id, a.actor_id, u.username, a.entity_type, a.entity_id, a.action,
+	a.before, a.after, a.changes, a.created_at
+`
+
+func (e *AuditEntry) scan(row pgx.Row, extra ...any) error {
+	var before, after, changes []byte
+	dest := append(extra,
+		&e.ID, &e.ActorID, &e.ActorName, &e.EntityType, &e.EntityID, &e.Action,
+		&before, &after, &changes, &e.CreatedAt,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+
+	e.Before, e.After, e.Changes = before, after, changes
+	return nil
+}
+
+func (m *AuditModel) Get(id int) (*AuditEntry, error) {
+	stmt := `
+		SELECT ` + auditColumns + `
+		FROM audit_log as a
+		LEFT JOIN users as u ON a.actor_id = u.id
+		WHERE a.id = $1
+	`
+
+	e := &AuditEntry{}
+	err := e.scan(m.DB.QueryRow(context.Background(), stmt, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// GetForActor returns the writes made by a user, newest first
+func (m *AuditModel) GetForActor(actorId int, f *Filter) ([]*AuditEntry, Metadata, error) {
+	stmt := `
+		SELECT count(*) OVER(), ` + auditColumns + `
+		FROM audit_log as a
+		LEFT JOIN users as u ON a.actor_id = u.id
+		WHERE a.actor_id = $1
+		ORDER BY a.id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	return m.query(f, stmt, actorId, f.Limit(), f.Offset())
+}
+
+// GetForEntity returns an entity's history, newest first
+func (m *AuditModel) GetForEntity(entityType string, entityId int, f *Filter) ([]*AuditEntry, Metadata, error) {
+	stmt := `
+		SELECT count(*) OVER(), ` + auditColumns + `
+		FROM audit_log as a
+		LEFT JOIN users as u ON a.actor_id = u.id
+		WHERE a.entity_type = $1 AND a.entity_id = $2
+		ORDER BY a.id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	return m.query(f, stmt, entityType, entityId, f.Limit(), f.Offset())
+}
+
+func (m *AuditModel) Insert(e *AuditEntry) error {
+	stmt := `
+		INSERT INTO audit_log (actor_id, entity_type, entity_id, action, before, after, changes)
+		VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, '{}'::jsonb))
+		RETURNING id, created_at
+	`
+
+	return m.DB.QueryRow(
+		context.Background(), stmt,
+		e.ActorID, e.EntityType, e.EntityID, e.Action,
+		jsonArg(e.Before), jsonArg(e.After), jsonArg(e.Changes),
+	).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (m *AuditModel) query(f *Filter, stmt string, args ...any) ([]*AuditEntry, Metadata, error) {
+	rows, err := m.DB.Query(context.Background(), stmt, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	entries := []*AuditEntry{}
+	for rows.Next() {
+		e := &AuditEntry{}
+		if err := e.scan(rows, &totalCount); err != nil {
+			return nil, Metadata{}, err
+		}
+		entries = append(entries, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return entries, calculateMetadata(totalCount, f.Page, f.PageSize), nil
+}
+
+// jsonArg passes a JSON document to a jsonb column, a nil document is
+// stored as NULL rather than the JSON null literal
+func jsonArg(doc json.RawMessage) any {
+	if doc == nil {
+		return nil
+	}
+	return string(doc)
+}
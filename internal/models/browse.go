@@ -0,0 +1,181 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BrowseSection is a row of sketches on the browse page, the sketches are
+// the first page of results for Filter. A section with a start or end date
+// is only shown between them.
+type BrowseSection struct {
+	ID        *int       `json:"id"`
+	Title     *string    `json:"title"`
+	Filter    *Filter    `json:"filter"`
+	Position  *int       `json:"position"`
+	StartsAt  *time.Time `json:"startsAt"`
+	EndsAt    *time.Time `json:"endsAt"`
+	CreatedAt *time.Time `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt"`
+}
+
+type BrowseSectionModelInterface interface {
+	Delete(id int) error
+	Get(id int) (*BrowseSection, error)
+	GetVisible(at time.Time) ([]*BrowseSection, error)
+	Insert(section *BrowseSection) error
+	List() ([]*BrowseSection, error)
+	Update(section *BrowseSection) error
+	UpdatePositions(sectionIds []int) error
+}
+
+type BrowseSectionModel struct {
+	DB *pgxpool.Pool
+}
+
+const browseSectionColumns = `
+	id, title, filter, position, starts_at, ends_at, created_at, updated_at
+`
+
+func (s *BrowseSection) scanFields() []any {
+	return []any{
+		&s.ID, &s.Title, &s.Filter, &s.Position,
+		&s.StartsAt, &s.EndsAt, &s.CreatedAt, &s.UpdatedAt,
+	}
+}
+
+func (m *BrowseSectionModel) Delete(id int) error {
+	stmt := `DELETE FROM browse_sections WHERE id = $1`
+
+	result, err := m.DB.Exec(context.Background(), stmt, id)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *BrowseSectionModel) Get(id int) (*BrowseSection, error) {
+	stmt := `SELECT ` + browseSectionColumns + ` FROM browse_sections WHERE id = $1`
+
+	s := &BrowseSection{}
+	err := m.DB.QueryRow(context.Background(), stmt, id).Scan(s.scanFields()...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetVisible returns the sections scheduled at the given time in
+// display order
+func (m *BrowseSectionModel) GetVisible(at time.Time) ([]*BrowseSection, error) {
+	stmt := `
+		SELECT ` + browseSectionColumns + `
+		FROM browse_sections
+		WHERE (starts_at IS NULL OR starts_at <= $1)
+		AND (ends_at IS NULL OR ends_at > $1)
+		ORDER BY position, id
+	`
+
+	return m.query(stmt, at)
+}
+
+// Insert adds a section, if no position is given it goes last
+func (m *BrowseSectionModel) Insert(s *BrowseSection) error {
+	stmt := `
+		INSERT INTO browse_sections (title, filter, position, starts_at, ends_at)
+		VALUES ($1, $2, COALESCE($3, (
+			SELECT COALESCE(MAX(position), 0) + 1 FROM browse_sections
+		)), $4, $5)
+		RETURNING id, position, created_at, updated_at
+	`
+
+	return m.DB.QueryRow(
+		context.Background(), stmt,
+		s.Title, s.Filter, s.Position, s.StartsAt, s.EndsAt,
+	).Scan(&s.ID, &s.Position, &s.CreatedAt, &s.UpdatedAt)
+}
+
+// List returns every section, including scheduled and expired ones
+func (m *BrowseSectionModel) List() ([]*BrowseSection, error) {
+	stmt := `
+		SELECT ` + browseSectionColumns + `
+		FROM browse_sections
+		ORDER BY position, id
+	`
+
+	return m.query(stmt)
+}
+
+func (m *BrowseSectionModel) Update(s *BrowseSection) error {
+	stmt := `
+		UPDATE browse_sections
+		SET title = $1, filter = $2, position = $3,
+		starts_at = $4, ends_at = $5, updated_at = now()
+		WHERE id = $6
+		RETURNING updated_at
+	`
+
+	err := m.DB.QueryRow(
+		context.Background(), stmt,
+		s.Title, s.Filter, s.Position, s.StartsAt, s.EndsAt, s.ID,
+	).Scan(&s.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (m *BrowseSectionModel) UpdatePositions(sectionIds []int) error {
+	stmt := `
+		UPDATE browse_sections as b
+		SET position = data.pos
+		FROM (
+			SELECT * FROM unnest($1::int[]) WITH ORDINALITY
+		) as data(id, pos)
+		WHERE b.id = data.id
+	`
+
+	_, err := m.DB.Exec(context.Background(), stmt, sectionIds)
+	return err
+}
+
+func (m *BrowseSectionModel) query(stmt string, args ...any) ([]*BrowseSection, error) {
+	rows, err := m.DB.Query(context.Background(), stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sections := []*BrowseSection{}
+	for rows.Next() {
+		s := &BrowseSection{}
+		err := rows.Scan(s.scanFields()...)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sections, nil
+}
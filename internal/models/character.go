@@ -31,13 +31,16 @@ type CharacterRef struct {
 
 type CharacterModelInterface interface {
 	Delete(id int) error
+	DeleteWithDependents(id int, reassignTo *int, check DependentsCheck) (Dependents, error)
 	Exists(id int) (bool, error)
 	Get(filter *Filter) ([]*Character, error)
 	GetById(id int) (*Character, error)
 	GetCharactersRefs(ids []int) ([]*CharacterRef, error)
 	GetCount(filter *Filter) (int, error)
+	GetRedirect(id int) (int, error)
 	Insert(character *Character) (int, error)
 	List(f *Filter) ([]*CharacterRef, Metadata, error)
+	Merge(fromId int, into *Character, dryRun bool) (*MergeResult, error)
 	Search(search string) ([]*Character, error)
 	SearchCount(query string) (int, error)
 	Update(character *Character) error
@@ -240,6 +243,25 @@ func (m *CharacterModel) Delete(id int) error {
 	return err
 }
 
+var characterDependents = []dependentRef{
+	{
+		name:  "castMembers",
+		count: `SELECT count(*) FROM cast_members WHERE character_id = $1`,
+		reassign: append(duplicateCastStmts("character_id", "person_id"),
+			`UPDATE cast_members SET character_id = $2 WHERE character_id = $1`),
+		detach: []string{`UPDATE cast_members SET character_id = NULL WHERE character_id = $1`},
+	},
+	browseSectionsRef("characterIds"),
+	mergeRedirectsRef("character"),
+}
+
+// DeleteWithDependents deletes a character, moving the portrayals and
+// browse sections referencing it to the character reassignTo or, when
+// it's nil, dropping the references
+func (m *CharacterModel) DeleteWithDependents(id int, reassignTo *int, check DependentsCheck) (Dependents, error) {
+	return deleteWithDependents(m.DB, "character", characterDependents, id, reassignTo, check)
+}
+
 func (m *CharacterModel) Insert(character *Character) (int, error) {
 	stmt := `
 	INSERT INTO character (name, aliases, character_type, slug, img_name, person_id)
@@ -467,3 +489,60 @@ func (m *CharacterModel) SearchCount(query string) (int, error) {
 	}
 	return count, nil
 }
+
+// Merge moves the cast members and browse sections referencing character
+// fromId onto into, saves into's aliases and image, deletes fromId and
+// leaves a redirect from it. With dryRun set nothing is changed and the
+// result counts the rows that would be.
+func (m *CharacterModel) Merge(fromId int, into *Character, dryRun bool) (*MergeResult, error) {
+	intoId := *into.ID
+	result := &MergeResult{}
+
+	err := mergeTx(m.DB, dryRun, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		result.DuplicateCast, err = foldDuplicateCast(ctx, tx, "character_id", "person_id", fromId, intoId)
+		if err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, `UPDATE cast_members SET character_id = $2 WHERE character_id = $1`, fromId, intoId)
+		if err != nil {
+			return fmt.Errorf("repoint cast members: %w", err)
+		}
+		result.CastMembers = int(tag.RowsAffected())
+
+		result.BrowseSections, err = repointBrowseSections(ctx, tx, "characterIds", fromId, intoId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE character SET aliases = $1, img_name = $2 WHERE id = $3`,
+			into.Aliases, into.Image, intoId)
+		if err != nil {
+			return fmt.Errorf("update merged character: %w", err)
+		}
+
+		var fromSlug string
+		err = tx.QueryRow(ctx, `DELETE FROM character WHERE id = $1 RETURNING slug`, fromId).Scan(&fromSlug)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNoRecord
+			}
+			return fmt.Errorf("delete merged character: %w", err)
+		}
+
+		result.Redirects, err = addMergeRedirect(ctx, tx, "character", fromId, fromSlug, intoId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetRedirect returns the id of the character a merged character id now
+// redirects to
+func (m *CharacterModel) GetRedirect(id int) (int, error) {
+	return getMergeRedirect(m.DB, "character", id)
+}
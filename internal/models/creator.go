@@ -44,6 +44,7 @@ type CreatorRef struct {
 
 type CreatorModelInterface interface {
 	Delete(id int) error
+	DeleteWithDependents(id int, reassignTo *int, check DependentsCheck) (Dependents, error)
 	Exists(id int) (bool, error)
 	GetById(id int) (*Creator, error)
 	GetCast(id int) ([]*Person, error)
@@ -74,6 +75,34 @@ func (m *CreatorModel) Delete(id int) error {
 	return nil
 }
 
+var creatorDependents = []dependentRef{
+	{
+		name:  "sketches",
+		count: `SELECT count(*) FROM sketch_creator_rel WHERE creator_id = $1`,
+		reassign: []string{
+			`DELETE FROM sketch_creator_rel WHERE creator_id = $1 AND sketch_id IN (
+				SELECT sketch_id FROM sketch_creator_rel WHERE creator_id = $2
+			)`,
+			`UPDATE sketch_creator_rel SET creator_id = $2 WHERE creator_id = $1`,
+		},
+		detach: []string{`DELETE FROM sketch_creator_rel WHERE creator_id = $1`},
+	},
+	{
+		name:     "groupings",
+		count:    `SELECT count(*) FROM sketch_grouping WHERE creator_id = $1`,
+		reassign: []string{`UPDATE sketch_grouping SET creator_id = $2 WHERE creator_id = $1`},
+		detach:   []string{`UPDATE sketch_grouping SET creator_id = NULL WHERE creator_id = $1`},
+	},
+	browseSectionsRef("creatorIds"),
+}
+
+// DeleteWithDependents deletes a creator, moving their sketches and
+// groupings to the creator reassignTo or, when it's nil, leaving the
+// sketches without the creator
+func (m *CreatorModel) DeleteWithDependents(id int, reassignTo *int, check DependentsCheck) (Dependents, error) {
+	return deleteWithDependents(m.DB, "creator", creatorDependents, id, reassignTo, check)
+}
+
 func (m *CreatorModel) List(filter *Filter) ([]*CreatorRef, Metadata, error) {
 	query := `SELECT count(*) OVER(), c.id, c.name, c.slug, c.profile_img%s
 			FROM creator as c
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Dependents counts the rows referencing a row, keyed by what they are
+type Dependents map[string]int
+
+func (d Dependents) Total() int {
+	total := 0
+	for _, n := range d {
+		total += n
+	}
+	return total
+}
+
+// dependentRef is one kind of row referencing the row being deleted. Each
+// statement takes the deleted id as $1, reassign statements take the id
+// references move to as $2. A nil reassign can only be detached.
+type dependentRef struct {
+	name     string
+	count    string
+	reassign []string
+	detach   []string
+}
+
+// browseSectionsRef covers the browse sections filtering on an id in the
+// list filterKey
+func browseSectionsRef(filterKey string) dependentRef {
+	where := fmt.Sprintf(`filter->'%s' @> jsonb_build_array($1::int)`, filterKey)
+	return dependentRef{
+		name:  "browseSections",
+		count: `SELECT count(*) FROM browse_sections WHERE ` + where,
+		reassign: []string{fmt.Sprintf(`
+			UPDATE browse_sections SET filter = jsonb_set(filter, '{%[1]s}', (
+				SELECT jsonb_agg(DISTINCT CASE WHEN e::int = $1 THEN $2 ELSE e::int END)
+				FROM jsonb_array_elements_text(filter->'%[1]s') AS e
+			)), updated_at = now()
+			WHERE %[2]s`, filterKey, where)},
+		detach: []string{fmt.Sprintf(`
+			UPDATE browse_sections SET filter = jsonb_set(filter, '{%[1]s}', COALESCE((
+				SELECT jsonb_agg(e::int)
+				FROM jsonb_array_elements_text(filter->'%[1]s') AS e
+				WHERE e::int <> $1
+			), '[]'::jsonb)), updated_at = now()
+			WHERE %[2]s`, filterKey, where)},
+	}
+}
+
+// homeSlotsRef covers the home page slots in section, a row that's
+// reassigned to one already in the section loses its slot
+func homeSlotsRef(section string) dependentRef {
+	return dependentRef{
+		name:  "homeSlots",
+		count: fmt.Sprintf(`SELECT count(*) FROM home_slots WHERE section = '%s' AND entity_id = $1`, section),
+		reassign: []string{
+			fmt.Sprintf(`
+				DELETE FROM home_slots
+				WHERE section = '%[1]s' AND entity_id = $1
+				AND EXISTS (
+					SELECT 1 FROM home_slots
+					WHERE section = '%[1]s' AND entity_id = $2
+				)`, section),
+			fmt.Sprintf(`UPDATE home_slots SET entity_id = $2 WHERE section = '%s' AND entity_id = $1`, section),
+		},
+		detach: []string{
+			fmt.Sprintf(`DELETE FROM home_slots WHERE section = '%s' AND entity_id = $1`, section),
+		},
+	}
+}
+
+// mergeRedirectsRef covers the merged rows redirecting to the deleted row
+func mergeRedirectsRef(entityType string) dependentRef {
+	where := fmt.Sprintf(`entity_type = '%s' AND to_id = $1`, entityType)
+	return dependentRef{
+		name:     "redirects",
+		count:    `SELECT count(*) FROM merge_redirects WHERE ` + where,
+		reassign: []string{`UPDATE merge_redirects SET to_id = $2 WHERE ` + where},
+		detach:   []string{`DELETE FROM merge_redirects WHERE ` + where},
+	}
+}
+
+// DependentsCheck decides whether a delete goes ahead given the dependents
+// counted in its transaction, an error rolls the delete back
+type DependentsCheck func(Dependents) error
+
+// deleteWithDependents deletes the row with id from table after moving its
+// dependents to reassignTo, or detaching them when reassignTo is nil. The
+// row is locked before its dependents are counted and passed to check, so
+// nothing can start referencing it in between. The refs run in order, so a
+// ref can rely on the ones before it.
+func deleteWithDependents(db *pgxpool.Pool, table string, refs []dependentRef, id int, reassignTo *int, check DependentsCheck) (Dependents, error) {
+	ctx := context.Background()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var locked int
+	err = tx.QueryRow(ctx, fmt.Sprintf(`SELECT id FROM %s WHERE id = $1 FOR UPDATE`, table), id).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, fmt.Errorf("lock %s: %w", table, err)
+	}
+
+	dependents := Dependents{}
+	for _, ref := range refs {
+		var n int
+		err := tx.QueryRow(ctx, ref.count, id).Scan(&n)
+		if err != nil {
+			return nil, fmt.Errorf("count %s: %w", ref.name, err)
+		}
+		dependents[ref.name] += n
+	}
+
+	if check != nil {
+		err = check(dependents)
+		if err != nil {
+			return dependents, err
+		}
+	}
+
+	for _, ref := range refs {
+		stmts, args := ref.detach, []any{id}
+		if reassignTo != nil {
+			if ref.reassign == nil {
+				return dependents, fmt.Errorf("%s can't be reassigned", ref.name)
+			}
+			stmts, args = ref.reassign, []any{id, *reassignTo}
+		}
+
+		for _, stmt := range stmts {
+			_, err := tx.Exec(ctx, stmt, args...)
+			if err != nil {
+				return dependents, fmt.Errorf("move %s: %w", ref.name, err)
+			}
+		}
+	}
+
+	tag, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, table), id)
+	if err != nil {
+		return dependents, fmt.Errorf("delete %s: %w", table, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return dependents, ErrNoRecord
+	}
+
+	return dependents, tx.Commit(ctx)
+}
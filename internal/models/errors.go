@@ -14,4 +14,6 @@ var (
 	ErrNoEpisode              = errors.New("models: episode does not exist")
 	ErrNoCreator              = errors.New("models: creator does not exist")
 	ErrNoSketch               = errors.New("models: sketch does not exist")
+	ErrDuplicateSlug          = errors.New("models: duplicate slug")
+	ErrDuplicateListItem      = errors.New("models: sketch is already in the list")
 )
@@ -7,17 +7,18 @@ import (
 )
 
 type Filter struct {
-	Page         int
-	PageSize     int
-	Query        string
-	Type         string
-	CharacterIDs []int
-	CreatorIDs   []int
-	PersonIDs    []int
-	SketchIDs    []int
-	ShowIDs      []int
-	TagIDs       []int
-	SortBy       string
+	Page         int    `json:"page"`
+	PageSize     int    `json:"pageSize"`
+	Query        string `json:"query"`
+	Type         string `json:"type"`
+	CharacterIDs []int  `json:"characterIds"`
+	CreatorIDs   []int  `json:"creatorIds"`
+	PersonIDs    []int  `json:"personIds"`
+	SketchIDs    []int  `json:"sketchIds"`
+	ShowIDs      []int  `json:"showIds"`
+	TagIDs       []int  `json:"tagIds"`
+	ListID       int    `json:"listId"`
+	SortBy       string `json:"sortBy"`
 }
 
 func (f Filter) Limit() int {
@@ -37,6 +38,16 @@ var sortMap = map[string]string{
 	"za":      "sketch_title DESC",
 }
 
+// ListSort orders the sketches of a filter with a ListID (the id of a
+// user's sketch list) by their position in the list
+const ListSort = "list"
+
+// IsSortOption reports whether sort is a known sketch sort order
+func IsSortOption(sort string) bool {
+	_, ok := sortMap[sort]
+	return ok
+}
+
 func (f *Filter) Params() url.Values {
 	params := url.Values{}
 
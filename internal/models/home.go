@@ -0,0 +1,256 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// homepage sections an editor can curate
+const (
+	HomeFeaturedSketches = "featured_sketches"
+	HomeSpotlightPeople  = "spotlight_people"
+	HomeShows            = "shows"
+	HomeRecurring        = "recurring"
+)
+
+var HomeSections = []string{
+	HomeFeaturedSketches, HomeSpotlightPeople, HomeShows, HomeRecurring,
+}
+
+// HomeSlot places an entity (sketch, person, show or recurring bit
+// depending on the section) on the homepage. A slot with a start or end
+// date is only shown between them.
+type HomeSlot struct {
+	ID        *int       `json:"id"`
+	Section   *string    `json:"section"`
+	EntityID  *int       `json:"entityId"`
+	Position  *int       `json:"position"`
+	StartsAt  *time.Time `json:"startsAt"`
+	EndsAt    *time.Time `json:"endsAt"`
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+type HomeSlotModelInterface interface {
+	Delete(id int) error
+	EntityExists(section string, id int) (bool, error)
+	Get(id int) (*HomeSlot, error)
+	GetActiveIDs(section string, at time.Time) ([]int, error)
+	GetPopularIDs(section string, limit int) ([]int, error)
+	Insert(slot *HomeSlot) error
+	List(section string) ([]*HomeSlot, error)
+	Update(slot *HomeSlot) error
+	UpdatePositions(slotIds []int) error
+}
+
+type HomeSlotModel struct {
+	DB *pgxpool.Pool
+}
+
+// the table each section's entity_id refers to
+var homeSectionTables = map[string]string{
+	HomeFeaturedSketches: "sketch",
+	HomeSpotlightPeople:  "person",
+	HomeShows:            "show",
+	HomeRecurring:        "recurring",
+}
+
+func IsHomeSection(section string) bool {
+	_, ok := homeSectionTables[section]
+	return ok
+}
+
+func (m *HomeSlotModel) Delete(id int) error {
+	stmt := `DELETE FROM home_slots WHERE id = $1`
+
+	result, err := m.DB.Exec(context.Background(), stmt, id)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *HomeSlotModel) EntityExists(section string, id int) (bool, error) {
+	table, ok := homeSectionTables[section]
+	if !ok {
+		return false, nil
+	}
+
+	stmt := `SELECT EXISTS (SELECT 1 FROM ` + table + ` WHERE id = $1)`
+
+	var exists bool
+	err := m.DB.QueryRow(context.Background(), stmt, id).Scan(&exists)
+	return exists, err
+}
+
+func (m *HomeSlotModel) Get(id int) (*HomeSlot, error) {
+	stmt := `
+		SELECT id, section, entity_id, position, starts_at, ends_at, created_at
+		FROM home_slots
+		WHERE id = $1
+	`
+
+	s := &HomeSlot{}
+	err := m.DB.QueryRow(context.Background(), stmt, id).Scan(
+		&s.ID, &s.Section, &s.EntityID, &s.Position,
+		&s.StartsAt, &s.EndsAt, &s.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetActiveIDs returns the entity ids of a section's slots that are
+// scheduled at the given time, in slot order
+func (m *HomeSlotModel) GetActiveIDs(section string, at time.Time) ([]int, error) {
+	stmt := `
+		SELECT entity_id
+		FROM home_slots
+		WHERE section = $1
+		AND (starts_at IS NULL OR starts_at <= $2)
+		AND (ends_at IS NULL OR ends_at > $2)
+		ORDER BY position, id
+	`
+
+	rows, err := m.DB.Query(context.Background(), stmt, section, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// GetPopularIDs returns the ids of the most popular entities for a
+// section. Recurring bits have no score of their own so they're ranked by
+// the total score of their sketches.
+func (m *HomeSlotModel) GetPopularIDs(section string, limit int) ([]int, error) {
+	var stmt string
+	switch section {
+	case HomeFeaturedSketches, HomeSpotlightPeople, HomeShows:
+		stmt = `
+			SELECT id FROM ` + homeSectionTables[section] + `
+			ORDER BY popularity_score DESC NULLS LAST, id
+			LIMIT $1
+		`
+	case HomeRecurring:
+		stmt = `
+			SELECT r.id
+			FROM recurring as r
+			JOIN sketch as s ON s.recurring_id = r.id
+			GROUP BY r.id
+			ORDER BY SUM(COALESCE(s.popularity_score, 0)) DESC, r.id
+			LIMIT $1
+		`
+	default:
+		return nil, nil
+	}
+
+	rows, err := m.DB.Query(context.Background(), stmt, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// Insert adds a slot, if no position is given it goes at the end of
+// its section
+func (m *HomeSlotModel) Insert(s *HomeSlot) error {
+	stmt := `
+		INSERT INTO home_slots (section, entity_id, position, starts_at, ends_at)
+		VALUES ($1, $2, COALESCE($3, (
+			SELECT COALESCE(MAX(position), 0) + 1 FROM home_slots WHERE section = $1
+		)), $4, $5)
+		RETURNING id, position, created_at
+	`
+
+	return m.DB.QueryRow(
+		context.Background(), stmt,
+		s.Section, s.EntityID, s.Position, s.StartsAt, s.EndsAt,
+	).Scan(&s.ID, &s.Position, &s.CreatedAt)
+}
+
+// List returns every slot, including scheduled and expired ones, of a
+// section or of all sections if section is empty
+func (m *HomeSlotModel) List(section string) ([]*HomeSlot, error) {
+	stmt := `
+		SELECT id, section, entity_id, position, starts_at, ends_at, created_at
+		FROM home_slots
+		WHERE $1 = '' OR section = $1
+		ORDER BY section, position, id
+	`
+
+	rows, err := m.DB.Query(context.Background(), stmt, section)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	slots := []*HomeSlot{}
+	for rows.Next() {
+		s := &HomeSlot{}
+		err := rows.Scan(
+			&s.ID, &s.Section, &s.EntityID, &s.Position,
+			&s.StartsAt, &s.EndsAt, &s.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		slots = append(slots, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+}
+
+func (m *HomeSlotModel) Update(s *HomeSlot) error {
+	stmt := `
+		UPDATE home_slots
+		SET entity_id = $1, position = $2, starts_at = $3, ends_at = $4
+		WHERE id = $5
+	`
+
+	result, err := m.DB.Exec(
+		context.Background(), stmt,
+		s.EntityID, s.Position, s.StartsAt, s.EndsAt, s.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *HomeSlotModel) UpdatePositions(slotIds []int) error {
+	stmt := `
+		UPDATE home_slots as h
+		SET position = data.pos
+		FROM (
+			SELECT * FROM unnest($1::int[]) WITH ORDINALITY
+		) as data(id, pos)
+		WHERE h.id = data.id
+	`
+
+	_, err := m.DB.Exec(context.Background(), stmt, slotIds)
+	return err
+}
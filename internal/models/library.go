@@ -0,0 +1,286 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RatedSketch is a sketch a user has rated
+type RatedSketch struct {
+	Sketch  *SketchRef `json:"sketch"`
+	Rating  *int       `json:"rating"`
+	RatedAt *time.Time `json:"ratedAt"`
+}
+
+// LikedSketch is a sketch a user has liked
+type LikedSketch struct {
+	Sketch  *SketchRef `json:"sketch"`
+	LikedAt *time.Time `json:"likedAt"`
+}
+
+// LikedQuote is a quote a user has liked along with the sketch it's from
+type LikedQuote struct {
+	Quote   *Quote     `json:"quote"`
+	Sketch  *SketchRef `json:"sketch"`
+	LikedAt *time.Time `json:"likedAt"`
+}
+
+type LibraryModelInterface interface {
+	GetLikedQuotes(userId int, f *Filter) ([]*LikedQuote, Metadata, error)
+	GetLikedSketches(userId int, f *Filter) ([]*LikedSketch, Metadata, error)
+	GetRatings(userId int, f *Filter) ([]*RatedSketch, Metadata, error)
+}
+
+type LibraryModel struct {
+	DB *pgxpool.Pool
+}
+
+// sort options for the library lists, ratings can also be sorted by the
+// rating given
+var librarySorts = map[string]string{
+	"recent": "created_at DESC",
+	"oldest": "created_at ASC",
+}
+
+var ratingSorts = map[string]string{
+	"recent": "r.created_at DESC",
+	"oldest": "r.created_at ASC",
+	"rating": "r.rating DESC, r.created_at DESC",
+	"lowest": "r.rating ASC, r.created_at DESC",
+}
+
+func IsLibrarySort(sort string) bool {
+	_, ok := librarySorts[sort]
+	return ok
+}
+
+func IsRatingSort(sort string) bool {
+	_, ok := ratingSorts[sort]
+	return ok
+}
+
+// sketch columns shared by the library queries, a sketch with more than one
+// creator is listed under the first
+const librarySketchColumns = `
+	v.id, v.title, v.sketch_number, v.slug, v.thumbnail_name,
+	v.upload_date, v.rating,
+	c.id, c.name, c.slug, c.profile_img,
+	sh.id, sh.name, sh.profile_img, sh.slug,
+	se.id, se.season_number,
+	e.id, e.episode_number
+`
+
+const librarySketchJoins = `
+	LEFT JOIN LATERAL (
+		SELECT creator_id FROM sketch_creator_rel
+		WHERE sketch_id = v.id
+		ORDER BY creator_id
+		LIMIT 1
+	) as vcr ON true
+	LEFT JOIN creator as c ON vcr.creator_id = c.id
+	LEFT JOIN episode as e ON v.episode_id = e.id
+	LEFT JOIN season as se ON e.season_id = se.id
+	LEFT JOIN show as sh ON se.show_id = sh.id
+`
+
+type librarySketch struct {
+	v  SketchRef
+	c  CreatorRef
+	sh ShowRef
+	se SeasonRef
+	ep EpisodeRef
+}
+
+func (l *librarySketch) scanFields() []any {
+	return []any{
+		&l.v.ID, &l.v.Title, &l.v.Number, &l.v.Slug, &l.v.Thumbnail,
+		&l.v.UploadDate, &l.v.Rating,
+		&l.c.ID, &l.c.Name, &l.c.Slug, &l.c.ProfileImage,
+		&l.sh.ID, &l.sh.Name, &l.sh.ProfileImg, &l.sh.Slug,
+		&l.se.ID, &l.se.Number,
+		&l.ep.ID, &l.ep.Number,
+	}
+}
+
+func (l *librarySketch) ref() *SketchRef {
+	v := l.v
+	if l.c.ID != nil {
+		v.Creator = &l.c
+	}
+
+	if l.ep.ID != nil {
+		l.se.Show = &l.sh
+		l.ep.Season = &l.se
+		v.Episode = &l.ep
+	}
+
+	return &v
+}
+
+// libraryLimit returns the limit for a page of results, a filter without a
+// page size returns every row
+func libraryLimit(f *Filter) any {
+	if f.PageSize < 1 {
+		return nil
+	}
+	return f.PageSize
+}
+
+func libraryOffset(f *Filter) int {
+	if f.PageSize < 1 || f.Page < 1 {
+		return 0
+	}
+	return f.Offset()
+}
+
+func (m *LibraryModel) GetRatings(userId int, f *Filter) ([]*RatedSketch, Metadata, error) {
+	sort, ok := ratingSorts[f.SortBy]
+	if !ok {
+		sort = ratingSorts["recent"]
+	}
+
+	stmt := fmt.Sprintf(`
+		SELECT count(*) OVER(), r.rating, r.created_at, %s
+		FROM sketch_rating as r
+		JOIN sketch as v ON r.sketch_id = v.id
+		%s
+		WHERE r.user_id = $1
+		ORDER BY %s, v.id
+		LIMIT $2 OFFSET $3
+	`, librarySketchColumns, librarySketchJoins, sort)
+
+	rows, err := m.DB.Query(
+		context.Background(), stmt,
+		userId, libraryLimit(f), libraryOffset(f),
+	)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	ratings := []*RatedSketch{}
+	for rows.Next() {
+		r := &RatedSketch{}
+		s := &librarySketch{}
+		dest := append([]any{&totalCount, &r.Rating, &r.RatedAt}, s.scanFields()...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, Metadata{}, err
+		}
+		r.Sketch = s.ref()
+		ratings = append(ratings, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return ratings, libraryMetadata(totalCount, f), nil
+}
+
+func (m *LibraryModel) GetLikedSketches(userId int, f *Filter) ([]*LikedSketch, Metadata, error) {
+	sort, ok := librarySorts[f.SortBy]
+	if !ok {
+		sort = librarySorts["recent"]
+	}
+
+	stmt := fmt.Sprintf(`
+		SELECT count(*) OVER(), l.created_at, %s
+		FROM likes as l
+		JOIN sketch as v ON l.sketch_id = v.id
+		%s
+		WHERE l.user_id = $1
+		ORDER BY l.%s, v.id
+		LIMIT $2 OFFSET $3
+	`, librarySketchColumns, librarySketchJoins, sort)
+
+	rows, err := m.DB.Query(
+		context.Background(), stmt,
+		userId, libraryLimit(f), libraryOffset(f),
+	)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	likes := []*LikedSketch{}
+	for rows.Next() {
+		l := &LikedSketch{}
+		s := &librarySketch{}
+		dest := append([]any{&totalCount, &l.LikedAt}, s.scanFields()...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, Metadata{}, err
+		}
+		l.Sketch = s.ref()
+		likes = append(likes, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return likes, libraryMetadata(totalCount, f), nil
+}
+
+func (m *LibraryModel) GetLikedQuotes(userId int, f *Filter) ([]*LikedQuote, Metadata, error) {
+	sort, ok := librarySorts[f.SortBy]
+	if !ok {
+		sort = librarySorts["recent"]
+	}
+
+	stmt := fmt.Sprintf(`
+		SELECT count(*) OVER(), ql.created_at,
+		q.id, q.text, q.type, q.funny, q.start_time_ms, q.end_time_ms,
+		%s
+		FROM quote_likes as ql
+		JOIN quote as q ON ql.quote_id = q.id
+		JOIN sketch as v ON q.sketch_id = v.id
+		%s
+		WHERE ql.user_id = $1
+		ORDER BY ql.%s, q.id
+		LIMIT $2 OFFSET $3
+	`, librarySketchColumns, librarySketchJoins, sort)
+
+	rows, err := m.DB.Query(
+		context.Background(), stmt,
+		userId, libraryLimit(f), libraryOffset(f),
+	)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	quotes := []*LikedQuote{}
+	for rows.Next() {
+		q := &Quote{}
+		l := &LikedQuote{Quote: q}
+		s := &librarySketch{}
+		dest := append([]any{
+			&totalCount, &l.LikedAt,
+			&q.ID, &q.Text, &q.Type, &q.Funny, &q.StartTimeMs, &q.EndTimeMs,
+		}, s.scanFields()...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, Metadata{}, err
+		}
+		l.Sketch = s.ref()
+		quotes = append(quotes, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return quotes, libraryMetadata(totalCount, f), nil
+}
+
+func libraryMetadata(totalCount int, f *Filter) Metadata {
+	if f.PageSize < 1 {
+		return calculateMetadata(totalCount, 1, max(totalCount, 1))
+	}
+	return calculateMetadata(totalCount, f.Page, f.PageSize)
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// the stores a media deletion can target
+const (
+	MediaStore   = "media"
+	ArchiveStore = "archive"
+)
+
+type MediaDeletion struct {
+	ID        *int       `json:"id"`
+	Store     *string    `json:"store"`
+	ObjectKey *string    `json:"objectKey"`
+	UploadID  *string    `json:"-"`
+	Attempts  *int       `json:"attempts"`
+	Error     *string    `json:"error"`
+	RunAfter  *time.Time `json:"runAfter"`
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+type MediaDeletionModelInterface interface {
+	Claim(limit int, lease time.Duration) ([]*MediaDeletion, error)
+	Complete(ids []int) error
+	CountPending() (int, error)
+	Fail(ids []int, errMsg string, retryAt time.Time) error
+}
+
+// MediaDeletionModel is the queue of storage objects to delete. Rows are
+// added by the deletes that orphan the objects, inside their transaction.
+type MediaDeletionModel struct {
+	DB *pgxpool.Pool
+}
+
+// Claim takes up to limit due deletions and pushes their run_after back by
+// lease, so they're retried if the claiming worker dies before completing
+// or failing them
+func (m *MediaDeletionModel) Claim(limit int, lease time.Duration) ([]*MediaDeletion, error) {
+	stmt := `
+		UPDATE media_deletions
+		SET attempts = attempts + 1,
+		run_after = now() + $2 * interval '1 second'
+		WHERE id IN (
+			SELECT id
+			FROM media_deletions
+			WHERE run_after <= now()
+			ORDER BY run_after, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT $1
+		)
+		RETURNING id, store, object_key, upload_id, attempts, error,
+		run_after, created_at
+	`
+
+	rows, err := m.DB.Query(context.Background(), stmt, limit, int(lease.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deletions := []*MediaDeletion{}
+	for rows.Next() {
+		d := &MediaDeletion{}
+		err := rows.Scan(
+			&d.ID, &d.Store, &d.ObjectKey, &d.UploadID, &d.Attempts,
+			&d.Error, &d.RunAfter, &d.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deletions = append(deletions, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deletions, nil
+}
+
+func (m *MediaDeletionModel) Complete(ids []int) error {
+	stmt := `DELETE FROM media_deletions WHERE id = ANY($1)`
+	_, err := m.DB.Exec(context.Background(), stmt, ids)
+	return err
+}
+
+func (m *MediaDeletionModel) CountPending() (int, error) {
+	var count int
+	stmt := `SELECT count(*) FROM media_deletions`
+	err := m.DB.QueryRow(context.Background(), stmt).Scan(&count)
+	return count, err
+}
+
+func (m *MediaDeletionModel) Fail(ids []int, errMsg string, retryAt time.Time) error {
+	stmt := `
+		UPDATE media_deletions
+		SET error = $2, run_after = $3
+		WHERE id = ANY($1)
+	`
+	_, err := m.DB.Exec(context.Background(), stmt, ids, errMsg, retryAt)
+	return err
+}
+
+// videoObjects selects the (store, key) pairs of the hot and cold copies
+// of the sketch videos matching where, which can use $1
+func videoObjects(where string) string {
+	return fmt.Sprintf(`
+		SELECT 'media', hot_s3_key FROM sketch_video
+		WHERE %[1]s AND hot_s3_key IS NOT NULL
+		UNION ALL
+		SELECT 'archive', cold_s3_key FROM sketch_video
+		WHERE %[1]s AND cold_s3_key IS NOT NULL`, where)
+}
+
+// screenshotObjects selects the (store, key) pairs of the cast screenshots
+// matching where, which can use $1
+func screenshotObjects(where string) string {
+	return fmt.Sprintf(`
+		SELECT 'media', 'cast_auto_screenshots/profile/' || profile_img
+		FROM cast_auto_screenshots
+		WHERE %[1]s AND profile_img IS NOT NULL
+		UNION ALL
+		SELECT 'media', 'cast_auto_screenshots/thumbnail/' || thumbnail_img
+		FROM cast_auto_screenshots
+		WHERE %[1]s AND thumbnail_img IS NOT NULL`, where)
+}
+
+// queueObjects queues the objects selected by query for deletion. It runs
+// in the transaction removing the rows that point at the objects, before
+// they're gone.
+func queueObjects(ctx context.Context, tx pgx.Tx, query string, args ...any) error {
+	stmt := `INSERT INTO media_deletions (store, object_key) ` + query
+	_, err := tx.Exec(ctx, stmt, args...)
+	if err != nil {
+		return fmt.Errorf("queue media deletions: %w", err)
+	}
+	return nil
+}
+
+// queueSketchMedia queues the videos and cast screenshots of a sketch, and
+// aborts of its multipart uploads still in progress
+func queueSketchMedia(ctx context.Context, tx pgx.Tx, sketchId int) error {
+	query := videoObjects("sketch_id = $1") + " UNION ALL " + screenshotObjects("sketch_id = $1")
+	err := queueObjects(ctx, tx, query, sketchId)
+	if err != nil {
+		return err
+	}
+
+	stmt := `
+		INSERT INTO media_deletions (store, object_key, upload_id)
+		SELECT 'media', s3_key, upload_id FROM video_uploads
+		WHERE sketch_id = $1
+	`
+	_, err = tx.Exec(ctx, stmt, sketchId)
+	if err != nil {
+		return fmt.Errorf("queue upload aborts: %w", err)
+	}
+	return nil
+}
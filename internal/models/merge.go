@@ -0,0 +1,150 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MergeResult counts the rows a merge re-pointed from the merged row to
+// the row it was merged into
+type MergeResult struct {
+	CastMembers    int `json:"castMembers"`
+	DuplicateCast  int `json:"duplicateCast"`
+	Characters     int `json:"characters"`
+	HomeSlots      int `json:"homeSlots"`
+	BrowseSections int `json:"browseSections"`
+	Redirects      int `json:"redirects"`
+}
+
+// mergeTx runs fn in a transaction, a dry run rolls it back so the result
+// reports the rows that would change without changing them
+func mergeTx(db *pgxpool.Pool, dryRun bool, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	ctx := context.Background()
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = fn(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return nil
+	}
+	return tx.Commit(ctx)
+}
+
+// duplicateCastStmts fold the cast rows of $1 into the rows of $2 they'd
+// duplicate once re-pointed: a row crediting $2 on the same sketch with the
+// same other column. The duplicate's quotes and tags move onto the kept
+// row, then it's deleted, the last statement's row count is the number
+// folded. column is the cast_members column being re-pointed.
+func duplicateCastStmts(column, other string) []string {
+	dupes := fmt.Sprintf(`
+		WITH dupes AS (
+			SELECT DISTINCT ON (f.id) f.id AS drop_id, k.id AS keep_id
+			FROM cast_members as f
+			JOIN cast_members as k ON k.sketch_id = f.sketch_id
+			AND k.%[1]s = $2 AND k.%[2]s IS NOT DISTINCT FROM f.%[2]s
+			WHERE f.%[1]s = $1
+			ORDER BY f.id, k.id
+		)`, column, other)
+
+	return []string{
+		dupes + `
+		INSERT INTO quote_cast_rel (quote_id, cast_id)
+		SELECT q.quote_id, d.keep_id
+		FROM quote_cast_rel as q JOIN dupes as d ON q.cast_id = d.drop_id
+		ON CONFLICT DO NOTHING`,
+		dupes + `
+		INSERT INTO cast_tags_rel (cast_id, tag_id)
+		SELECT d.keep_id, t.tag_id
+		FROM cast_tags_rel as t JOIN dupes as d ON t.cast_id = d.drop_id
+		ON CONFLICT DO NOTHING`,
+		dupes + `
+		DELETE FROM quote_cast_rel WHERE cast_id IN (SELECT drop_id FROM dupes)`,
+		dupes + `
+		DELETE FROM cast_members WHERE id IN (SELECT drop_id FROM dupes)`,
+	}
+}
+
+// foldDuplicateCast runs duplicateCastStmts, returning the number of cast
+// rows folded
+func foldDuplicateCast(ctx context.Context, tx pgx.Tx, column, other string, fromId, toId int) (int, error) {
+	folded := 0
+	for _, stmt := range duplicateCastStmts(column, other) {
+		tag, err := tx.Exec(ctx, stmt, fromId, toId)
+		if err != nil {
+			return 0, fmt.Errorf("fold duplicate cast members: %w", err)
+		}
+		folded = int(tag.RowsAffected())
+	}
+	return folded, nil
+}
+
+// repointBrowseSections swaps fromId for toId in the id list filterKey of
+// every browse section filter, dropping the duplicate if both are listed
+func repointBrowseSections(ctx context.Context, tx pgx.Tx, filterKey string, fromId, toId int) (int, error) {
+	stmt := `
+		UPDATE browse_sections SET filter = jsonb_set(filter, ARRAY[$1::text], (
+			SELECT jsonb_agg(DISTINCT CASE WHEN e::int = $2 THEN $3 ELSE e::int END)
+			FROM jsonb_array_elements_text(filter->$1) AS e
+		)), updated_at = now()
+		WHERE filter->$1 @> jsonb_build_array($2::int)
+	`
+
+	tag, err := tx.Exec(ctx, stmt, filterKey, fromId, toId)
+	if err != nil {
+		return 0, fmt.Errorf("repoint browse sections: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// addMergeRedirect points fromId, and anything already redirecting to it,
+// at toId. Returns the number of earlier redirects that were re-pointed.
+func addMergeRedirect(ctx context.Context, tx pgx.Tx, entityType string, fromId int, fromSlug string, toId int) (int, error) {
+	stmt := `
+		UPDATE merge_redirects SET to_id = $3
+		WHERE entity_type = $1 AND to_id = $2
+	`
+	tag, err := tx.Exec(ctx, stmt, entityType, fromId, toId)
+	if err != nil {
+		return 0, fmt.Errorf("repoint redirects: %w", err)
+	}
+
+	stmt = `
+		INSERT INTO merge_redirects (entity_type, from_id, from_slug, to_id)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err = tx.Exec(ctx, stmt, entityType, fromId, fromSlug, toId)
+	if err != nil {
+		return 0, fmt.Errorf("insert redirect: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// getMergeRedirect returns the id a merged row now redirects to
+func getMergeRedirect(db *pgxpool.Pool, entityType string, id int) (int, error) {
+	stmt := `
+		SELECT to_id FROM merge_redirects
+		WHERE entity_type = $1 AND from_id = $2
+	`
+
+	var toId int
+	err := db.QueryRow(context.Background(), stmt, entityType, id).Scan(&toId)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrNoRecord
+		}
+		return 0, err
+	}
+	return toId, nil
+}
@@ -46,6 +46,7 @@ type PersonStats struct {
 
 type PersonModelInterface interface {
 	Delete(id int) error
+	DeleteWithDependents(id int, reassignTo *int, check DependentsCheck) (Dependents, error)
 	Exists(id int) (bool, error)
 	Get(filter *Filter) ([]*Person, error)
 	GetById(id int) (*Person, error)
@@ -54,8 +55,10 @@ type PersonModelInterface interface {
 	GetPeople(ids []int) ([]*Person, error)
 	GetPersonRefs(ids []int) ([]*PersonRef, error)
 	GetPersonStats(id int) (*PersonStats, error)
+	GetRedirect(id int) (int, error)
 	Insert(person *Person) (int, error)
 	List(f *Filter) ([]*PersonRef, Metadata, error)
+	Merge(fromId int, into *Person, dryRun bool) (*MergeResult, error)
 	Search(query string) ([]*Person, error)
 	SearchCount(query string) (int, error)
 	Update(person *Person) error
@@ -75,6 +78,31 @@ func (m *PersonModel) Delete(id int) error {
 	return err
 }
 
+var personDependents = []dependentRef{
+	{
+		name:  "castMembers",
+		count: `SELECT count(*) FROM cast_members WHERE person_id = $1`,
+		reassign: append(duplicateCastStmts("person_id", "character_id"),
+			`UPDATE cast_members SET person_id = $2 WHERE person_id = $1`),
+		detach: []string{`UPDATE cast_members SET person_id = NULL WHERE person_id = $1`},
+	},
+	{
+		name:     "characters",
+		count:    `SELECT count(*) FROM character WHERE person_id = $1`,
+		reassign: []string{`UPDATE character SET person_id = $2 WHERE person_id = $1`},
+		detach:   []string{`UPDATE character SET person_id = NULL WHERE person_id = $1`},
+	},
+	homeSlotsRef("spotlight_people"),
+	browseSectionsRef("personIds"),
+	mergeRedirectsRef("person"),
+}
+
+// DeleteWithDependents deletes a person, moving what references them to
+// the person reassignTo or, when it's nil, dropping the references
+func (m *PersonModel) DeleteWithDependents(id int, reassignTo *int, check DependentsCheck) (Dependents, error) {
+	return deleteWithDependents(m.DB, "person", personDependents, id, reassignTo, check)
+}
+
 func (m *PersonModel) GetPersonStats(id int) (*PersonStats, error) {
 	stmt := `
 		SELECT
@@ -608,3 +636,88 @@ func (m *PersonModel) Update(person *Person) error {
 	)
 	return err
 }
+
+// Merge moves everything that references person fromId onto into, saves
+// into's aliases and profile image, deletes fromId and leaves a redirect
+// from it. With dryRun set nothing is changed and the result counts the
+// rows that would be.
+func (m *PersonModel) Merge(fromId int, into *Person, dryRun bool) (*MergeResult, error) {
+	intoId := *into.ID
+	result := &MergeResult{}
+
+	err := mergeTx(m.DB, dryRun, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		result.DuplicateCast, err = foldDuplicateCast(ctx, tx, "person_id", "character_id", fromId, intoId)
+		if err != nil {
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, `UPDATE cast_members SET person_id = $2 WHERE person_id = $1`, fromId, intoId)
+		if err != nil {
+			return fmt.Errorf("repoint cast members: %w", err)
+		}
+		result.CastMembers = int(tag.RowsAffected())
+
+		tag, err = tx.Exec(ctx, `UPDATE character SET person_id = $2 WHERE person_id = $1`, fromId, intoId)
+		if err != nil {
+			return fmt.Errorf("repoint characters: %w", err)
+		}
+		result.Characters = int(tag.RowsAffected())
+
+		// a person already in the spotlight keeps their slot, the
+		// merged person's slot is dropped
+		tag, err = tx.Exec(ctx, `
+			DELETE FROM home_slots
+			WHERE section = 'spotlight_people' AND entity_id = $1
+			AND EXISTS (
+				SELECT 1 FROM home_slots
+				WHERE section = 'spotlight_people' AND entity_id = $2
+			)`, fromId, intoId)
+		if err != nil {
+			return fmt.Errorf("remove home slots: %w", err)
+		}
+		result.HomeSlots = int(tag.RowsAffected())
+
+		tag, err = tx.Exec(ctx, `
+			UPDATE home_slots SET entity_id = $2
+			WHERE section = 'spotlight_people' AND entity_id = $1`, fromId, intoId)
+		if err != nil {
+			return fmt.Errorf("repoint home slots: %w", err)
+		}
+		result.HomeSlots += int(tag.RowsAffected())
+
+		result.BrowseSections, err = repointBrowseSections(ctx, tx, "personIds", fromId, intoId)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE person SET aliases = $1, profile_img = $2 WHERE id = $3`,
+			into.Alias, into.ProfileImg, intoId)
+		if err != nil {
+			return fmt.Errorf("update merged person: %w", err)
+		}
+
+		var fromSlug string
+		err = tx.QueryRow(ctx, `DELETE FROM person WHERE id = $1 RETURNING slug`, fromId).Scan(&fromSlug)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrNoRecord
+			}
+			return fmt.Errorf("delete merged person: %w", err)
+		}
+
+		result.Redirects, err = addMergeRedirect(ctx, tx, "person", fromId, fromSlug, intoId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetRedirect returns the id of the person a merged person id now
+// redirects to
+func (m *PersonModel) GetRedirect(id int) (int, error) {
+	return getMergeRedirect(m.DB, "person", id)
+}
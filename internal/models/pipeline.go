@@ -2,17 +2,38 @@ package models
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const (
+	PipelinePending = "pending"
+	PipelineRunning = "running"
+	PipelineDone    = "done"
+	PipelineFailed  = "failed"
+)
+
 type PipelineJob struct {
-	ID     *int    `json:"id"`
-	Status *string `json:"status"`
-	Error  *string `json:"error"`
+	ID         *int       `json:"id"`
+	VideoID    *int       `json:"videoId"`
+	Status     *string    `json:"status"`
+	Error      *string    `json:"error"`
+	Attempts   *int       `json:"attempts"`
+	RunAfter   *time.Time `json:"runAfter"`
+	StartedAt  *time.Time `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt"`
+	CreatedAt  *time.Time `json:"createdAt"`
 }
 
 type PipelineModelInterface interface {
+	ClaimNext(staleAfter time.Duration) (*PipelineJob, error)
+	Complete(id int) error
+	CountByStatus() (map[string]int, error)
+	Fail(id int, errMsg string, retryAt *time.Time) error
+	GetByVideo(videoId int) ([]*PipelineJob, error)
 	Insert(int, *PipelineJob) error
 }
 
@@ -37,6 +58,149 @@ func (m *PipelineModel) Insert(videoId int, pipeline *PipelineJob) error {
 		return err
 	}
 	pipeline.ID = &id
+	pipeline.VideoID = &videoId
 
 	return nil
 }
+
+// ClaimNext locks the oldest runnable job and marks it as running. Jobs left
+// in the running state for longer than staleAfter (e.g. a worker crashed
+// mid-job) are considered runnable again. Returns ErrNoRecord if there is
+// nothing to do.
+func (m *PipelineModel) ClaimNext(staleAfter time.Duration) (*PipelineJob, error) {
+	stmt := `
+		UPDATE pipeline_jobs
+		SET status = 'running', attempts = attempts + 1,
+		started_at = now(), finished_at = NULL
+		WHERE id = (
+			SELECT id
+			FROM pipeline_jobs
+			WHERE video_id IS NOT NULL
+			AND (
+				(status = 'pending' AND COALESCE(run_after, created_at) <= now())
+				OR (status = 'running' AND started_at < now() - $1 * interval '1 second')
+			)
+			ORDER BY COALESCE(run_after, created_at), id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, video_id, status, error, attempts, run_after,
+		started_at, finished_at, created_at
+	`
+
+	job := &PipelineJob{}
+	err := m.DB.QueryRow(context.Background(), stmt, int(staleAfter.Seconds())).Scan(
+		&job.ID, &job.VideoID, &job.Status, &job.Error, &job.Attempts,
+		&job.RunAfter, &job.StartedAt, &job.FinishedAt, &job.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (m *PipelineModel) Complete(id int) error {
+	stmt := `
+		UPDATE pipeline_jobs
+		SET status = 'done', error = NULL, finished_at = now()
+		WHERE id = $1
+	`
+	_, err := m.DB.Exec(context.Background(), stmt, id)
+	return err
+}
+
+// Fail records the error for a job. If retryAt is defined the job is put back
+// into the pending state to be picked up again at that time, otherwise it is
+// marked as permanently failed.
+func (m *PipelineModel) Fail(id int, errMsg string, retryAt *time.Time) error {
+	stmt := `
+		UPDATE pipeline_jobs
+		SET status = 'failed', error = $2, finished_at = now()
+		WHERE id = $1
+	`
+	args := []any{id, errMsg}
+
+	if retryAt != nil {
+		stmt = `
+			UPDATE pipeline_jobs
+			SET status = 'pending', error = $2, run_after = $3
+			WHERE id = $1
+		`
+		args = append(args, *retryAt)
+	}
+
+	_, err := m.DB.Exec(context.Background(), stmt, args...)
+	return err
+}
+
+// CountByStatus returns the number of jobs in each status, statuses without
+// any jobs are included with a count of zero
+func (m *PipelineModel) CountByStatus() (map[string]int, error) {
+	stmt := `SELECT status, count(*)::int FROM pipeline_jobs GROUP BY status`
+
+	rows, err := m.DB.Query(context.Background(), stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{
+		PipelinePending: 0,
+		PipelineRunning: 0,
+		PipelineDone:    0,
+		PipelineFailed:  0,
+	}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func (m *PipelineModel) GetByVideo(videoId int) ([]*PipelineJob, error) {
+	stmt := `
+		SELECT id, video_id, status, error, attempts, run_after,
+		started_at, finished_at, created_at
+		FROM pipeline_jobs
+		WHERE video_id = $1
+		ORDER BY id ASC
+	`
+
+	rows, err := m.DB.Query(context.Background(), stmt, videoId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*PipelineJob{}
+	for rows.Next() {
+		job := &PipelineJob{}
+		err := rows.Scan(
+			&job.ID, &job.VideoID, &job.Status, &job.Error, &job.Attempts,
+			&job.RunAfter, &job.StartedAt, &job.FinishedAt, &job.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
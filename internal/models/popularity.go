@@ -0,0 +1,236 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// entities with a popularity_score column
+const (
+	PopularitySketch    = "sketch"
+	PopularityPerson    = "person"
+	PopularityCharacter = "character"
+	PopularityCreator   = "creator"
+	PopularityShow      = "show"
+)
+
+// SketchSignals are the engagement numbers a sketch's score is computed
+// from. Likes and Ratings are counts where each like/rating is weighted by
+// its age (1 when new, halving every half life).
+type SketchSignals struct {
+	SketchID    int
+	Likes       float64
+	Ratings     float64
+	RatingCount int
+	RatingSum   float64
+	QuoteLikes  int
+}
+
+// SketchLink relates an entity (person, character, creator or show) to a
+// sketch it appears in
+type SketchLink struct {
+	EntityID int
+	SketchID int
+}
+
+type PopularityModelInterface interface {
+	GetScores(entity string) (map[int]float64, error)
+	GetSketchLinks(entity string) ([]SketchLink, error)
+	GetSketchSignals(halfLife time.Duration) ([]*SketchSignals, error)
+	UpdateScores(scores map[string]map[int]float64) error
+}
+
+type PopularityModel struct {
+	DB *pgxpool.Pool
+}
+
+var sketchLinkQueries = map[string]string{
+	PopularityPerson: `
+		SELECT DISTINCT person_id, sketch_id FROM cast_members
+		WHERE person_id IS NOT NULL
+	`,
+	PopularityCharacter: `
+		SELECT DISTINCT character_id, sketch_id FROM cast_members
+		WHERE character_id IS NOT NULL
+	`,
+	PopularityCreator: `
+		SELECT creator_id, sketch_id FROM sketch_creator_rel
+		WHERE creator_id IS NOT NULL AND sketch_id IS NOT NULL
+	`,
+	PopularityShow: `
+		SELECT se.show_id, s.id
+		FROM sketch as s
+		JOIN episode as e ON s.episode_id = e.id
+		JOIN season as se ON e.season_id = se.id
+		WHERE se.show_id IS NOT NULL
+		UNION
+		SELECT g.show_id, s.id
+		FROM sketch as s
+		JOIN sketch_grouping as g ON s.grouping_id = g.id
+		WHERE g.show_id IS NOT NULL
+	`,
+}
+
+func popularityTable(entity string) (string, error) {
+	switch entity {
+	case PopularitySketch, PopularityPerson, PopularityCharacter, PopularityCreator, PopularityShow:
+		return entity, nil
+	}
+	return "", fmt.Errorf("unknown popularity entity %q", entity)
+}
+
+func (m *PopularityModel) GetSketchSignals(halfLife time.Duration) ([]*SketchSignals, error) {
+	// exp() errors on underflow, very old likes are clamped to ~0 instead
+	stmt := `
+		SELECT s.id, COALESCE(l.decayed, 0), COALESCE(r.decayed, 0),
+		COALESCE(r.count, 0), COALESCE(r.total, 0), COALESCE(q.count, 0)
+		FROM sketch as s
+		LEFT JOIN (
+			SELECT sketch_id, SUM(exp(GREATEST(
+				-ln(2) * extract(epoch FROM now() - created_at)::float8 / $1, -700
+			))) as decayed
+			FROM likes
+			GROUP BY sketch_id
+		) as l ON l.sketch_id = s.id
+		LEFT JOIN (
+			SELECT sketch_id, SUM(exp(GREATEST(
+				-ln(2) * extract(epoch FROM now() - created_at)::float8 / $1, -700
+			))) as decayed,
+			COUNT(*)::int as count, SUM(rating)::float8 as total
+			FROM sketch_rating
+			GROUP BY sketch_id
+		) as r ON r.sketch_id = s.id
+		LEFT JOIN (
+			SELECT qu.sketch_id, COUNT(*)::int as count
+			FROM quote_likes as ql
+			JOIN quote as qu ON qu.id = ql.quote_id
+			GROUP BY qu.sketch_id
+		) as q ON q.sketch_id = s.id
+		ORDER BY s.id
+	`
+
+	rows, err := m.DB.Query(context.Background(), stmt, halfLife.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	signals := []*SketchSignals{}
+	for rows.Next() {
+		s := &SketchSignals{}
+		err := rows.Scan(
+			&s.SketchID, &s.Likes, &s.Ratings, &s.RatingCount, &s.RatingSum, &s.QuoteLikes,
+		)
+		if err != nil {
+			return nil, err
+		}
+		signals = append(signals, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return signals, nil
+}
+
+func (m *PopularityModel) GetSketchLinks(entity string) ([]SketchLink, error) {
+	stmt, ok := sketchLinkQueries[entity]
+	if !ok {
+		return nil, fmt.Errorf("no sketch links for popularity entity %q", entity)
+	}
+
+	rows, err := m.DB.Query(context.Background(), stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []SketchLink{}
+	for rows.Next() {
+		var l SketchLink
+		err := rows.Scan(&l.EntityID, &l.SketchID)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// GetScores returns the current popularity score of every row of the entity
+func (m *PopularityModel) GetScores(entity string) (map[int]float64, error) {
+	table, err := popularityTable(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := fmt.Sprintf(`SELECT id, COALESCE(popularity_score, 0)::float8 FROM %s`, table)
+	rows, err := m.DB.Query(context.Background(), stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := map[int]float64{}
+	for rows.Next() {
+		var id int
+		var score float64
+		err := rows.Scan(&id, &score)
+		if err != nil {
+			return nil, err
+		}
+		scores[id] = score
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return scores, nil
+}
+
+// UpdateScores writes the scores of every entity in a single transaction
+func (m *PopularityModel) UpdateScores(scores map[string]map[int]float64) error {
+	ctx := context.Background()
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for entity, entityScores := range scores {
+		table, err := popularityTable(entity)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int, 0, len(entityScores))
+		values := make([]float64, 0, len(entityScores))
+		for id, score := range entityScores {
+			ids = append(ids, id)
+			values = append(values, score)
+		}
+
+		stmt := fmt.Sprintf(`
+			UPDATE %s as t SET popularity_score = u.score
+			FROM unnest($1::int[], $2::float8[]) as u(id, score)
+			WHERE t.id = u.id
+		`, table)
+
+		_, err = tx.Exec(ctx, stmt, ids, values)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
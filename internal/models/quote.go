@@ -27,6 +27,7 @@ type TranscriptLine struct {
 	ID         *int    `json:"id"`
 	LineNumber *int    `json:"lineNumber"`
 	Text       *string `json:"text"`
+	Speaker    *string `json:"speaker"`
 	StartMs    *int    `json:"startMs"`
 	EndMs      *int    `json:"endMs"`
 }
@@ -38,7 +39,9 @@ type QuoteModelInterface interface {
 	DeleteQuoteLike(int, int) error
 	GetBySketch(int, *int) ([]*Quote, error)
 	GetTranscriptBySketch(int) ([]*TranscriptLine, error)
+	InsertQuote(sketchId int, quote *Quote, castIds []int) error
 	InsertQuoteLike(int, int) error
+	ReplaceTranscript(int, []*TranscriptLine) error
 }
 
 type QuoteModel struct {
@@ -236,10 +239,10 @@ func (m *QuoteModel) GetBySketch(sketchId int, userId *int) ([]*Quote, error) {
 
 func (m *QuoteModel) GetTranscriptBySketch(sketchId int) ([]*TranscriptLine, error) {
 	stmt := `
-		SELECT id, line_number, text, start_ms, end_ms
+		SELECT id, line_number, text, speaker, start_ms, end_ms
 		FROM transcription_lines
 		WHERE sketch_id = $1
-		ORDER BY id
+		ORDER BY line_number, id
 	`
 
 	rows, err := m.DB.Query(context.Background(), stmt, sketchId)
@@ -251,7 +254,7 @@ func (m *QuoteModel) GetTranscriptBySketch(sketchId int) ([]*TranscriptLine, err
 	for rows.Next() {
 		l := TranscriptLine{}
 		err = rows.Scan(
-			&l.ID, &l.LineNumber, &l.Text, &l.StartMs, &l.EndMs,
+			&l.ID, &l.LineNumber, &l.Text, &l.Speaker, &l.StartMs, &l.EndMs,
 		)
 		if err != nil {
 			return nil, err
@@ -267,6 +270,48 @@ func (m *QuoteModel) GetTranscriptBySketch(sketchId int) ([]*TranscriptLine, err
 	return lines, nil
 }
 
+// ReplaceTranscript deletes all of a sketch's transcript lines and inserts
+// the given ones in their place. Line numbers are assigned from the slice
+// order and the inserted ids are set on the lines.
+func (m *QuoteModel) ReplaceTranscript(sketchId int, lines []*TranscriptLine) error {
+	ctx := context.Background()
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, "DELETE FROM transcription_lines WHERE sketch_id = $1", sketchId)
+	if err != nil {
+		return fmt.Errorf("failed to delete transcript: %w", err)
+	}
+
+	stmt := `
+		INSERT INTO transcription_lines (sketch_id, line_number, text, speaker, start_ms, end_ms)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+	for i, l := range lines {
+		lineNumber := i + 1
+		var id int
+		err = tx.QueryRow(
+			ctx, stmt, sketchId, lineNumber, l.Text, l.Speaker, l.StartMs, l.EndMs,
+		).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("failed to insert transcript line %d: %w", lineNumber, err)
+		}
+		l.ID = &id
+		l.LineNumber = &lineNumber
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
 func (m *QuoteModel) DeleteQuoteLike(quoteId, userId int) error {
 	stmt := `
 		DELETE FROM quote_likes
@@ -277,6 +322,37 @@ func (m *QuoteModel) DeleteQuoteLike(quoteId, userId int) error {
 	return err
 }
 
+// InsertQuote adds a quote to a sketch linked to the cast members castIds,
+// setting quote.ID. The quote and its cast are saved together or not at all.
+func (m *QuoteModel) InsertQuote(sketchId int, quote *Quote, castIds []int) error {
+	ctx := context.Background()
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := insertQuote(ctx, tx, quote, sketchId)
+	if err != nil {
+		return fmt.Errorf("failed to insert quote: %w", err)
+	}
+
+	if len(castIds) > 0 {
+		err = insertQuoteCastAssociations(ctx, tx, id, castIds)
+		if err != nil {
+			return fmt.Errorf("failed to insert quote cast associations: %w", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	quote.ID = &id
+	return nil
+}
+
 func (m *QuoteModel) InsertQuoteLike(quoteId, userId int) error {
 	stmt := `
 		INSERT INTO quote_likes (quote_id, user_id)
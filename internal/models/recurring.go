@@ -27,7 +27,10 @@ type RecurringRef struct {
 
 type RecurringModelInterface interface {
 	Delete(id int) error
+	DeleteWithDependents(id int, reassignTo *int, check DependentsCheck) (Dependents, error)
+	Exists(id int) (bool, error)
 	GetById(id int) (*Recurring, error)
+	GetRecurringRefs(ids []int) ([]*RecurringRef, error)
 	Insert(*Recurring) (int, error)
 	List(f *Filter) ([]*RecurringRef, Metadata, error)
 	Search(string) ([]*Recurring, error)
@@ -47,6 +50,37 @@ func (m *RecurringModel) Delete(id int) error {
 	return err
 }
 
+var recurringDependents = []dependentRef{
+	{
+		name:     "sketches",
+		count:    `SELECT count(*) FROM sketch WHERE recurring_id = $1`,
+		reassign: []string{`UPDATE sketch SET recurring_id = $2 WHERE recurring_id = $1`},
+		detach:   []string{`UPDATE sketch SET recurring_id = NULL WHERE recurring_id = $1`},
+	},
+	homeSlotsRef("recurring"),
+}
+
+// DeleteWithDependents deletes a recurring sketch, moving its sketches to
+// the recurring reassignTo or, when it's nil, leaving them standalone
+func (m *RecurringModel) DeleteWithDependents(id int, reassignTo *int, check DependentsCheck) (Dependents, error) {
+	return deleteWithDependents(m.DB, "recurring", recurringDependents, id, reassignTo, check)
+}
+
+func (m *RecurringModel) Exists(id int) (bool, error) {
+	stmt := `SELECT id FROM recurring WHERE id = $1`
+	row := m.DB.QueryRow(context.Background(), stmt, id)
+
+	err := row.Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		} else {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (m *RecurringModel) GetById(id int) (*Recurring, error) {
 	stmt := `
 		SELECT r.id, r.slug, r.title, r.description, r.thumbnail_name,
@@ -119,6 +153,38 @@ func (m *RecurringModel) GetById(id int) (*Recurring, error) {
 	return s, nil
 }
 
+func (m *RecurringModel) GetRecurringRefs(ids []int) ([]*RecurringRef, error) {
+	if len(ids) < 1 {
+		return nil, nil
+	}
+
+	stmt := `SELECT id, slug, title, thumbnail_name
+			FROM recurring
+			WHERE id = ANY($1)`
+
+	rows, err := m.DB.Query(context.Background(), stmt, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refs := []*RecurringRef{}
+	for rows.Next() {
+		r := &RecurringRef{}
+		err := rows.Scan(&r.ID, &r.Slug, &r.Title, &r.ThumbnailName)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return refs, nil
+}
+
 func (m *RecurringModel) Insert(recurring *Recurring) (int, error) {
 	stmt := `
 		INSERT INTO recurring (slug, title, description, thumbnail_name)
@@ -1,18 +1,29 @@
 package models
 
 type Repositories struct {
-	Cast       CastModelInterface
-	Categories CategoryInterface
-	Characters CharacterModelInterface
-	Creators   CreatorModelInterface
-	Quotes     QuoteModelInterface
-	People     PersonModelInterface
-	Pipeline   PipelineModelInterface
-	Profile    ProfileModelInterface
-	Recurring  RecurringModelInterface
-	Shows      ShowModelInterface
-	Series     SeriesModelInterface
-	Tags       TagModelInterface
-	Users      UserModelInterface
-	Sketches   SketchModelInterface
+	Audit          AuditModelInterface
+	BrowseSections BrowseSectionModelInterface
+	Cast           CastModelInterface
+	Categories     CategoryInterface
+	Characters     CharacterModelInterface
+	Creators       CreatorModelInterface
+	Quotes         QuoteModelInterface
+	Library        LibraryModelInterface
+	MediaDeletions MediaDeletionModelInterface
+	People         PersonModelInterface
+	Pipeline       PipelineModelInterface
+	Popularity     PopularityModelInterface
+	HomeSlots      HomeSlotModelInterface
+	Profile        ProfileModelInterface
+	Recurring      RecurringModelInterface
+	Shows          ShowModelInterface
+	Series         SeriesModelInterface
+	Tags           TagModelInterface
+	Tokens         TokenModelInterface
+	UserTokens     UserTokenModelInterface
+	Users          UserModelInterface
+	SketchLists    SketchListModelInterface
+	Sketches       SketchModelInterface
+	VideoUploads   VideoUploadModelInterface
+	Wiki           WikiModelInterface
 }
@@ -27,6 +27,8 @@ type SeriesRef struct {
 
 type SeriesModelInterface interface {
 	Delete(id int) error
+	DeleteWithDependents(id int, reassignTo *int, check DependentsCheck) (Dependents, error)
+	Exists(id int) (bool, error)
 	GetById(id int) (*Series, error)
 	Insert(*Series) (int, error)
 	List(f *Filter) ([]*SeriesRef, Metadata, error)
@@ -47,6 +49,36 @@ func (m *SeriesModel) Delete(id int) error {
 	return err
 }
 
+var seriesDependents = []dependentRef{
+	{
+		name:     "sketches",
+		count:    `SELECT count(*) FROM sketch WHERE series_id = $1`,
+		reassign: []string{`UPDATE sketch SET series_id = $2 WHERE series_id = $1`},
+		detach:   []string{`UPDATE sketch SET series_id = NULL, part_number = NULL WHERE series_id = $1`},
+	},
+}
+
+// DeleteWithDependents deletes a series, moving its sketches to the series
+// reassignTo or, when it's nil, taking them out of any series
+func (m *SeriesModel) DeleteWithDependents(id int, reassignTo *int, check DependentsCheck) (Dependents, error) {
+	return deleteWithDependents(m.DB, "series", seriesDependents, id, reassignTo, check)
+}
+
+func (m *SeriesModel) Exists(id int) (bool, error) {
+	stmt := `SELECT id FROM series WHERE id = $1`
+	row := m.DB.QueryRow(context.Background(), stmt, id)
+
+	err := row.Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		} else {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (m *SeriesModel) GetById(id int) (*Series, error) {
 	stmt := `
 		SELECT s.id, s.slug, s.title, s.description, s.thumbnail_name,
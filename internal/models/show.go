@@ -36,6 +36,7 @@ type ShowModelInterface interface {
 	Delete(show *Show) error
 	DeleteEpisode(episodeId int) error
 	DeleteSeason(seasonid int) error
+	DeleteWithDependents(id int, check DependentsCheck) (Dependents, error)
 	EpisodeExists(id int) (bool, error)
 	Get(filter *Filter) ([]*Show, error)
 	GetById(id int) (*Show, error)
@@ -330,6 +331,57 @@ func (m *ShowModel) Delete(show *Show) error {
 	return nil
 }
 
+// a show's seasons and episodes can't sensibly move to another show, so
+// its dependents are only ever detached: sketches leave their episodes and
+// groupings, then the groupings, episodes and seasons go with the show
+var showDependents = []dependentRef{
+	{
+		name: "sketches",
+		count: `
+			SELECT count(*) FROM sketch as sk
+			JOIN episode as e ON sk.episode_id = e.id
+			JOIN season as se ON e.season_id = se.id
+			WHERE se.show_id = $1`,
+		detach: []string{`
+			UPDATE sketch SET episode_id = NULL, episode_start = NULL, sketch_number = NULL
+			WHERE episode_id IN (
+				SELECT e.id FROM episode as e
+				JOIN season as se ON e.season_id = se.id
+				WHERE se.show_id = $1
+			)`},
+	},
+	{
+		name:  "groupings",
+		count: `SELECT count(*) FROM sketch_grouping WHERE show_id = $1`,
+		detach: []string{
+			`UPDATE sketch SET grouping_id = NULL
+			WHERE grouping_id IN (SELECT id FROM sketch_grouping WHERE show_id = $1)`,
+			`DELETE FROM sketch_grouping WHERE show_id = $1`,
+		},
+	},
+	{
+		name: "episodes",
+		count: `
+			SELECT count(*) FROM episode as e
+			JOIN season as se ON e.season_id = se.id
+			WHERE se.show_id = $1`,
+		detach: []string{`DELETE FROM episode WHERE season_id IN (SELECT id FROM season WHERE show_id = $1)`},
+	},
+	{
+		name:   "seasons",
+		count:  `SELECT count(*) FROM season WHERE show_id = $1`,
+		detach: []string{`DELETE FROM season WHERE show_id = $1`},
+	},
+	homeSlotsRef("shows"),
+	browseSectionsRef("showIds"),
+}
+
+// DeleteWithDependents deletes a show along with its seasons, episodes and
+// groupings, keeping the sketches that were in them
+func (m *ShowModel) DeleteWithDependents(id int, check DependentsCheck) (Dependents, error) {
+	return deleteWithDependents(m.DB, "show", showDependents, id, nil, check)
+}
+
 func (m *ShowModel) DeleteEpisode(episodeId int) error {
 	stmt := `
 		DELETE FROM episode
@@ -4,8 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"maps"
-	"slices"
 	"strings"
 	"time"
 
@@ -56,54 +54,129 @@ type SketchRef struct {
 }
 
 type SketchVideo struct {
-	ID           *int           `json:"id"`
-	HotS3Key     *string        `json:"hotS3Key"`
-	ColdS3Key    *string        `json:"coldS3Key"`
-	ArchivedAt   *time.Time     `json:"archivedAt"`
-	PipelineJobs []*PipelineJob `json:"jobs"`
+	ID            *int           `json:"id"`
+	SketchID      *int           `json:"sketchId"`
+	HotS3Key      *string        `json:"hotS3Key"`
+	ColdS3Key     *string        `json:"coldS3Key"`
+	ArchivedAt    *time.Time     `json:"archivedAt"`
+	HotRestoredAt *time.Time     `json:"hotRestoredAt"`
+	IsPrimary     *bool          `json:"isPrimary"`
+	Source        *string        `json:"source"`
+	Width         *int           `json:"width"`
+	Height        *int           `json:"height"`
+	SizeBytes     *int64         `json:"sizeBytes"`
+	ContentType   *string        `json:"contentType"`
+	Container     *string        `json:"container"`
+	DurationMs    *int64         `json:"durationMs"`
+	VideoCodec    *string        `json:"videoCodec"`
+	AudioCodec    *string        `json:"audioCodec"`
+	ProbedAt      *time.Time     `json:"probedAt"`
+	CreatedAt     *time.Time     `json:"createdAt"`
+	PipelineJobs  []*PipelineJob `json:"jobs"`
+}
+
+const sketchVideoColumns = `
+	v.id, v.sketch_id, v.hot_s3_key, v.cold_s3_key, v.archived_at, v.hot_restored_at,
+	v.is_primary, v.source, v.width, v.height, v.size_bytes, v.content_type,
+	v.container, v.duration_ms, v.video_codec, v.audio_codec, v.probed_at, v.created_at
+`
+
+// scanFields returns the scan destinations matching sketchVideoColumns
+func (v *SketchVideo) scanFields() []any {
+	return []any{
+		&v.ID, &v.SketchID, &v.HotS3Key, &v.ColdS3Key, &v.ArchivedAt, &v.HotRestoredAt,
+		&v.IsPrimary, &v.Source, &v.Width, &v.Height, &v.SizeBytes, &v.ContentType,
+		&v.Container, &v.DurationMs, &v.VideoCodec, &v.AudioCodec, &v.ProbedAt, &v.CreatedAt,
+	}
 }
 
 type SketchModelInterface interface {
+	BackfillDuration(sketchId, duration int) error
 	BatchUpdateTags(sketchId int, tags []*Tag) error
 	Delete(id int) error
 	DeleteScreenshots(id int) error
+	DeleteVideo(id int) error
+	EvictVideo(id int) error
 	Exists(id int) (bool, error)
 	Get(filter *Filter) ([]*SketchRef, Metadata, error)
 	GetById(id int) (*Sketch, error)
 	GetByUserLikes(id int) ([]*SketchRef, error)
 	GetCount(filter *Filter) (int, error)
-	GetFeatured() ([]*Sketch, error)
+	GetFeatured(ids []int) ([]*Sketch, error)
+	GetVideo(id int) (*SketchVideo, error)
 	GetVideos(int) ([]*SketchVideo, error)
+	GetVideosToArchive(limit int) ([]*SketchVideo, error)
+	GetVideosToEvict(cutoff time.Time, limit int) ([]*SketchVideo, error)
 	HasLike(sketchId, userId int) (bool, error)
 	Insert(sketch *Sketch) (int, error)
 	InsertSketchCreatorRelation(sketchId, creatorId int) error
 	InsertSketchVideo(sketchId int, video *SketchVideo) error
 	SearchCount(query string) (int, error)
+	SetPrimaryVideo(sketchId, videoId int) error
 	SyncSketchCreators(sketchID int, creatorIDs []int) error
 	Update(sketch *Sketch) error
 	UpdateCreatorRelation(sketchId, creatorId int) error
+	UpdateVideo(video *SketchVideo) error
+	UpdateVideoProbe(video *SketchVideo) error
+	UpdateVideoStorage(video *SketchVideo) error
 }
 
 type SketchModel struct {
 	DB *pgxpool.Pool
 }
 
+// Delete deletes a sketch and queues its videos, screenshots and open
+// uploads for deletion from storage in the same transaction
 func (m *SketchModel) Delete(id int) error {
+	ctx := context.Background()
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = queueSketchMedia(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
 	stmt := `
 		DELETE from sketch
 		WHERE id = $1
 	`
-	_, err := m.DB.Exec(context.Background(), stmt, id)
-	return err
+	_, err = tx.Exec(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
+// DeleteScreenshots deletes the cast screenshots of a sketch and queues
+// their images for deletion from storage
 func (m *SketchModel) DeleteScreenshots(id int) error {
+	ctx := context.Background()
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = queueObjects(ctx, tx, screenshotObjects("sketch_id = $1"), id)
+	if err != nil {
+		return err
+	}
+
 	stmt := `
 		DELETE from cast_auto_screenshots
 		WHERE sketch_id = $1
 	`
-	_, err := m.DB.Exec(context.Background(), stmt, id)
-	return err
+	_, err = tx.Exec(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (m *SketchModel) Exists(id int) (bool, error) {
@@ -294,6 +367,15 @@ func determineFields(filter *Filter, args *Arguements) string {
 		}
 	}
 
+	if filter.ListID != 0 {
+		args.ArgIndex++
+		args.Args = append(args.Args, filter.ListID)
+		rankParam += fmt.Sprintf(`
+		, (SELECT position FROM sketch_list_items
+			WHERE list_id = $%d AND sketch_id = v.id) as list_position
+		`, args.ArgIndex)
+	}
+
 	fields := fmt.Sprintf(baseFields, castThumbnailClause, rankParam)
 
 	return fields
@@ -389,6 +471,15 @@ func determineConditions(filter *Filter, args *Arguements) string {
 		clause += fmt.Sprintf(" AND vt.tag_id IN (%s)", strings.Join(tagPlaceholders, ","))
 	}
 
+	if filter.ListID != 0 {
+		args.ArgIndex++
+		clause += fmt.Sprintf(
+			" AND v.id IN (SELECT sketch_id FROM sketch_list_items WHERE list_id = $%d)",
+			args.ArgIndex,
+		)
+		args.Args = append(args.Args, filter.ListID)
+	}
+
 	// NOTE: People filter use AND operation
 	if len(filter.PersonIDs) > 0 {
 		peoplePlaceholders := []string{}
@@ -429,6 +520,10 @@ func determineSort(filter *Filter, args *Arguements) string {
 		sort = val
 	}
 
+	if filter.ListID != 0 && (filter.SortBy == "" || filter.SortBy == ListSort) {
+		sort = "list_position ASC, sketch_id ASC"
+	}
+
 	sort = fmt.Sprintf(" ORDER BY %s", sort)
 	sort += fmt.Sprintf(" LIMIT $%d OFFSET $%d", args.ArgIndex+1, args.ArgIndex+2)
 	args.ArgIndex += 2
@@ -722,7 +817,13 @@ func (m *SketchModel) GetCount(filter *Filter) (int, error) {
 	return count, nil
 }
 
-func (m *SketchModel) GetFeatured() ([]*Sketch, error) {
+// GetFeatured returns the sketches with the given ids, along with their
+// cast, in the order of ids
+func (m *SketchModel) GetFeatured(ids []int) ([]*Sketch, error) {
+	if len(ids) < 1 {
+		return nil, ErrNoRecord
+	}
+
 	stmt := `
 		SELECT v.id, v.title, v.slug, v.thumbnail_name, 
 			v.upload_date, v.rating,
@@ -734,8 +835,6 @@ func (m *SketchModel) GetFeatured() ([]*Sketch, error) {
 			cm.id, cm.position, cm.thumbnail_name, cm.character_name,
 			ch.id, ch.slug, ch.name, ch.img_name
 		FROM sketch AS v
-		JOIN sketch_tags as vt ON v.id = vt.sketch_id
-		JOIN tags as t ON vt.tag_id = t.id
 		LEFT JOIN sketch_creator_rel as vcr ON v.id = vcr.sketch_id
 		LEFT JOIN creator as c ON vcr.creator_id = c.id
 		LEFT JOIN episode as e ON v.episode_id = e.id
@@ -744,11 +843,11 @@ func (m *SketchModel) GetFeatured() ([]*Sketch, error) {
 		LEFT JOIN cast_members as cm ON v.id = cm.sketch_id
 		LEFT JOIN person as p ON cm.person_id = p.id
 		LEFT JOIN character as ch ON cm.character_id = ch.id
-		WHERE t.name = 'Featured'
-		ORDER BY v.title, cm.position
+		WHERE v.id = ANY($1)
+		ORDER BY v.id, cm.position
 	`
 
-	rows, err := m.DB.Query(context.Background(), stmt)
+	rows, err := m.DB.Query(context.Background(), stmt, ids)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrNoRecord
@@ -756,6 +855,7 @@ func (m *SketchModel) GetFeatured() ([]*Sketch, error) {
 			return nil, err
 		}
 	}
+	defer rows.Close()
 
 	sketchMap := make(map[int]*Sketch)
 	hasRows := false
@@ -820,7 +920,13 @@ func (m *SketchModel) GetFeatured() ([]*Sketch, error) {
 		return nil, err
 	}
 
-	sketches := slices.Collect(maps.Values(sketchMap))
+	sketches := []*Sketch{}
+	for _, id := range ids {
+		if v, ok := sketchMap[id]; ok {
+			sketches = append(sketches, v)
+			delete(sketchMap, id)
+		}
+	}
 
 	return sketches, nil
 }
@@ -891,38 +997,277 @@ func (m *SketchModel) GetByUserLikes(userId int) ([]*SketchRef, error) {
 	return sketches, nil
 }
 
-// NOTE: This function doesn't currently account for multiple videos assigned
-// to a single sketch! Implement later...
+func (m *SketchModel) GetVideo(id int) (*SketchVideo, error) {
+	stmt := `SELECT ` + sketchVideoColumns + ` FROM sketch_video as v WHERE v.id = $1`
+
+	v := &SketchVideo{}
+	err := m.DB.QueryRow(context.Background(), stmt, id).Scan(v.scanFields()...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return v, nil
+}
+
 func (m *SketchModel) GetVideos(sketchId int) ([]*SketchVideo, error) {
 	stmt := `
-		SELECT v.id, v.hot_s3_key, v.cold_s3_key, v.archived_at,
-		p.id, p.status, p.error
+		SELECT ` + sketchVideoColumns + `,
+		p.id, p.video_id, p.status, p.error, p.attempts, p.run_after,
+		p.started_at, p.finished_at, p.created_at
 		FROM sketch_video as v
 		LEFT JOIN pipeline_jobs as p ON v.id = p.video_id
 		WHERE v.sketch_id = $1
-		ORDER BY v.id asc
-		LIMIT 1
+		ORDER BY v.is_primary desc, v.id asc, p.id asc
 	`
 
-	row := m.DB.QueryRow(context.Background(), stmt, sketchId)
+	rows, err := m.DB.Query(context.Background(), stmt, sketchId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	videos := []*SketchVideo{}
+	videoMap := map[int]*SketchVideo{}
+	for rows.Next() {
+		v := &SketchVideo{}
+		p := &PipelineJob{}
+		err := rows.Scan(append(
+			v.scanFields(), &p.ID, &p.VideoID, &p.Status, &p.Error, &p.Attempts,
+			&p.RunAfter, &p.StartedAt, &p.FinishedAt, &p.CreatedAt,
+		)...)
+		if err != nil {
+			return nil, err
+		}
+
+		if stored, ok := videoMap[*v.ID]; ok {
+			v = stored
+		} else {
+			v.PipelineJobs = []*PipelineJob{}
+			videoMap[*v.ID] = v
+			videos = append(videos, v)
+		}
 
-	v := SketchVideo{}
-	p := PipelineJob{}
-	err := row.Scan(&v.ID, &v.HotS3Key, &v.ColdS3Key, &v.ArchivedAt,
-		&p.ID, &p.Status, &p.Error)
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		if p.ID != nil {
+			v.PipelineJobs = append(v.PipelineJobs, p)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
+	return videos, nil
+}
+
+// GetVideosToArchive returns hot videos without a cold copy whose pipeline
+// has finished, i.e. at least one job is done and none are still queued
+func (m *SketchModel) GetVideosToArchive(limit int) ([]*SketchVideo, error) {
+	stmt := `
+		SELECT ` + sketchVideoColumns + `
+		FROM sketch_video as v
+		WHERE v.hot_s3_key IS NOT NULL
+		AND v.cold_s3_key IS NULL
+		AND EXISTS (
+			SELECT 1 FROM pipeline_jobs p
+			WHERE p.video_id = v.id AND p.status = 'done'
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM pipeline_jobs p
+			WHERE p.video_id = v.id AND p.status IN ('pending', 'running')
+		)
+		ORDER BY v.id
+		LIMIT $1
+	`
+	return m.queryVideos(stmt, limit)
+}
+
+// GetVideosToEvict returns archived videos still in hot storage that were
+// archived (or restored to hot) before cutoff and have no queued jobs
+func (m *SketchModel) GetVideosToEvict(cutoff time.Time, limit int) ([]*SketchVideo, error) {
+	stmt := `
+		SELECT ` + sketchVideoColumns + `
+		FROM sketch_video as v
+		WHERE v.hot_s3_key IS NOT NULL
+		AND v.cold_s3_key IS NOT NULL
+		AND GREATEST(v.archived_at, v.hot_restored_at) < $1
+		AND NOT EXISTS (
+			SELECT 1 FROM pipeline_jobs p
+			WHERE p.video_id = v.id AND p.status IN ('pending', 'running')
+		)
+		ORDER BY v.id
+		LIMIT $2
+	`
+	return m.queryVideos(stmt, cutoff, limit)
+}
+
+func (m *SketchModel) queryVideos(stmt string, args ...any) ([]*SketchVideo, error) {
+	rows, err := m.DB.Query(context.Background(), stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
 	videos := []*SketchVideo{}
-	if v.ID != nil {
-		v.PipelineJobs = []*PipelineJob{&p}
-		videos = append(videos, &v)
+	for rows.Next() {
+		v := &SketchVideo{}
+		err := rows.Scan(v.scanFields()...)
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
 	return videos, nil
 }
 
+// UpdateVideoProbe stores the results of probing the video file
+func (m *SketchModel) UpdateVideoProbe(video *SketchVideo) error {
+	stmt := `
+		UPDATE sketch_video
+		SET size_bytes = $1, content_type = $2, container = $3, duration_ms = $4,
+		video_codec = $5, audio_codec = $6, width = $7, height = $8, probed_at = $9
+		WHERE id = $10
+	`
+	_, err := m.DB.Exec(
+		context.Background(), stmt, video.SizeBytes, video.ContentType,
+		video.Container, video.DurationMs, video.VideoCodec, video.AudioCodec,
+		video.Width, video.Height, video.ProbedAt, video.ID,
+	)
+	return err
+}
+
+// BackfillDuration sets the duration (in seconds) of a sketch that doesn't
+// have one
+func (m *SketchModel) BackfillDuration(sketchId, duration int) error {
+	stmt := `
+		UPDATE sketch SET duration = $1
+		WHERE id = $2 AND (duration IS NULL OR duration = 0)
+	`
+	_, err := m.DB.Exec(context.Background(), stmt, duration, sketchId)
+	return err
+}
+
+// UpdateVideo updates the editable metadata of a video version
+func (m *SketchModel) UpdateVideo(video *SketchVideo) error {
+	stmt := `
+		UPDATE sketch_video
+		SET source = $1, width = $2, height = $3
+		WHERE id = $4
+	`
+	_, err := m.DB.Exec(
+		context.Background(), stmt, video.Source, video.Width, video.Height, video.ID,
+	)
+	return err
+}
+
+// SetPrimaryVideo makes videoId the only primary video of the sketch
+func (m *SketchModel) SetPrimaryVideo(sketchId, videoId int) error {
+	ctx := context.Background()
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE sketch_video SET is_primary = false WHERE sketch_id = $1 AND is_primary`,
+		sketchId,
+	)
+	if err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE sketch_video SET is_primary = true WHERE id = $1 AND sketch_id = $2`,
+		videoId, sketchId,
+	)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteVideo deletes a video version and queues its hot and cold copies
+// for deletion from storage
+func (m *SketchModel) DeleteVideo(id int) error {
+	ctx := context.Background()
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = queueObjects(ctx, tx, videoObjects("id = $1"), id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM sketch_video WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// EvictVideo drops the hot copy of an archived video, queueing it for
+// deletion from storage
+func (m *SketchModel) EvictVideo(id int) error {
+	ctx := context.Background()
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		SELECT 'media', hot_s3_key FROM sketch_video
+		WHERE id = $1 AND hot_s3_key IS NOT NULL AND cold_s3_key IS NOT NULL`
+	err = queueObjects(ctx, tx, query, id)
+	if err != nil {
+		return err
+	}
+
+	stmt := `
+		UPDATE sketch_video SET hot_s3_key = NULL
+		WHERE id = $1 AND cold_s3_key IS NOT NULL
+	`
+	tag, err := tx.Exec(ctx, stmt, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (m *SketchModel) UpdateVideoStorage(video *SketchVideo) error {
+	stmt := `
+		UPDATE sketch_video
+		SET hot_s3_key = $1, cold_s3_key = $2, archived_at = $3, hot_restored_at = $4
+		WHERE id = $5
+	`
+	_, err := m.DB.Exec(
+		context.Background(), stmt, video.HotS3Key, video.ColdS3Key,
+		video.ArchivedAt, video.HotRestoredAt, video.ID,
+	)
+	return err
+}
+
 func (m *SketchModel) HasLike(sketchId, userId int) (bool, error) {
 	var exists bool
 
@@ -982,22 +1327,34 @@ func (m *SketchModel) InsertSketchCreatorRelation(sketchId, creatorId int) error
 	return err
 }
 
+// InsertSketchVideo adds a video version, it is made the primary if the
+// sketch doesn't have one yet
 func (m *SketchModel) InsertSketchVideo(sketchId int, video *SketchVideo) error {
 	stmt := `
-		INSERT INTO sketch_video (sketch_id, hot_s3_key, cold_s3_key, archived_at)
-		VALUES ($1,$2,$3,$4)
-		RETURNING id;
+		INSERT INTO sketch_video (
+			sketch_id, hot_s3_key, cold_s3_key, archived_at, source, width, height,
+			size_bytes, content_type, container, duration_ms, video_codec, audio_codec,
+			probed_at, is_primary
+		)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14, NOT EXISTS (
+			SELECT 1 FROM sketch_video WHERE sketch_id = $1 AND is_primary
+		))
+		RETURNING id, is_primary, created_at;
 	`
 
 	row := m.DB.QueryRow(context.Background(), stmt, sketchId,
-		video.HotS3Key, video.ColdS3Key, video.ArchivedAt)
+		video.HotS3Key, video.ColdS3Key, video.ArchivedAt,
+		video.Source, video.Width, video.Height, video.SizeBytes, video.ContentType,
+		video.Container, video.DurationMs, video.VideoCodec, video.AudioCodec,
+		video.ProbedAt)
 
 	var id int
-	err := row.Scan(&id)
+	err := row.Scan(&id, &video.IsPrimary, &video.CreatedAt)
 	if err != nil {
 		return err
 	}
 	video.ID = &id
+	video.SketchID = &sketchId
 
 	return nil
 }
@@ -0,0 +1,323 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	ListPublic   = "public"
+	ListPrivate  = "private"
+	ListUnlisted = "unlisted"
+)
+
+func IsListVisibility(visibility string) bool {
+	switch visibility {
+	case ListPublic, ListPrivate, ListUnlisted:
+		return true
+	}
+	return false
+}
+
+// SketchList is a user's named, ordered collection of sketches
+type SketchList struct {
+	ID          *int       `json:"id"`
+	UserID      *int       `json:"userId"`
+	Username    *string    `json:"username"`
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	Visibility  *string    `json:"visibility"`
+	Slug        *string    `json:"slug"`
+	ItemCount   *int       `json:"itemCount"`
+	CreatedAt   *time.Time `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+}
+
+type SketchListItem struct {
+	ListID    *int       `json:"listId"`
+	SketchID  *int       `json:"sketchId"`
+	Position  *int       `json:"position"`
+	Note      *string    `json:"note"`
+	CreatedAt *time.Time `json:"createdAt"`
+}
+
+type SketchListModelInterface interface {
+	AddItem(item *SketchListItem) error
+	Delete(id int) error
+	GetById(id int) (*SketchList, error)
+	GetBySlug(slug string) (*SketchList, error)
+	GetByUser(userId int, visibilities []string) ([]*SketchList, error)
+	GetItems(listId int) ([]*SketchListItem, error)
+	Insert(list *SketchList) error
+	RemoveItem(listId, sketchId int) error
+	Update(list *SketchList) error
+	UpdateItemNote(listId, sketchId int, note *string) error
+	UpdatePositions(listId int, sketchIds []int) error
+}
+
+type SketchListModel struct {
+	DB *pgxpool.Pool
+}
+
+const sketchListColumns = `
+	l.id, l.user_id, u.username, l.title, l.description, l.visibility, l.slug,
+	(SELECT count(*) FROM sketch_list_items WHERE list_id = l.id)::int,
+	l.created_at, l.updated_at
+`
+
+func (l *SketchList) scanFields() []any {
+	return []any{
+		&l.ID, &l.UserID, &l.Username, &l.Title, &l.Description, &l.Visibility,
+		&l.Slug, &l.ItemCount, &l.CreatedAt, &l.UpdatedAt,
+	}
+}
+
+// AddItem appends a sketch to the end of a list
+func (m *SketchListModel) AddItem(item *SketchListItem) error {
+	stmt := `
+		INSERT INTO sketch_list_items (list_id, sketch_id, position, note)
+		VALUES ($1, $2, (
+			SELECT COALESCE(MAX(position), 0) + 1
+			FROM sketch_list_items WHERE list_id = $1
+		), $3)
+		RETURNING position, created_at
+	`
+
+	err := m.DB.QueryRow(
+		context.Background(), stmt,
+		item.ListID, item.SketchID, item.Note,
+	).Scan(&item.Position, &item.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), `violates unique constraint "sketch_list_items_pkey"`) {
+			return ErrDuplicateListItem
+		}
+		if strings.Contains(err.Error(), `violates foreign key constraint "sketch_list_items_sketch_id_fkey"`) {
+			return ErrNoSketch
+		}
+		return err
+	}
+
+	return m.touch(safeDeref(item.ListID))
+}
+
+func (m *SketchListModel) Delete(id int) error {
+	stmt := `DELETE FROM sketch_lists WHERE id = $1`
+
+	result, err := m.DB.Exec(context.Background(), stmt, id)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *SketchListModel) GetById(id int) (*SketchList, error) {
+	stmt := `
+		SELECT ` + sketchListColumns + `
+		FROM sketch_lists as l
+		JOIN users as u ON l.user_id = u.id
+		WHERE l.id = $1
+	`
+
+	return m.getOne(stmt, id)
+}
+
+func (m *SketchListModel) GetBySlug(slug string) (*SketchList, error) {
+	stmt := `
+		SELECT ` + sketchListColumns + `
+		FROM sketch_lists as l
+		JOIN users as u ON l.user_id = u.id
+		WHERE l.slug = $1
+	`
+
+	return m.getOne(stmt, slug)
+}
+
+// GetByUser returns a user's lists with one of the given visibilities,
+// most recently updated first
+func (m *SketchListModel) GetByUser(userId int, visibilities []string) ([]*SketchList, error) {
+	stmt := `
+		SELECT ` + sketchListColumns + `
+		FROM sketch_lists as l
+		JOIN users as u ON l.user_id = u.id
+		WHERE l.user_id = $1 AND l.visibility = ANY($2)
+		ORDER BY l.updated_at DESC, l.id DESC
+	`
+
+	rows, err := m.DB.Query(context.Background(), stmt, userId, visibilities)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []*SketchList{}
+	for rows.Next() {
+		l := &SketchList{}
+		if err := rows.Scan(l.scanFields()...); err != nil {
+			return nil, err
+		}
+		lists = append(lists, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lists, nil
+}
+
+// GetItems returns the items of a list in list order
+func (m *SketchListModel) GetItems(listId int) ([]*SketchListItem, error) {
+	stmt := `
+		SELECT list_id, sketch_id, position, note, created_at
+		FROM sketch_list_items
+		WHERE list_id = $1
+		ORDER BY position, sketch_id
+	`
+
+	rows, err := m.DB.Query(context.Background(), stmt, listId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*SketchListItem{}
+	for rows.Next() {
+		i := &SketchListItem{}
+		err := rows.Scan(&i.ListID, &i.SketchID, &i.Position, &i.Note, &i.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (m *SketchListModel) Insert(l *SketchList) error {
+	stmt := `
+		INSERT INTO sketch_lists (user_id, title, description, visibility, slug)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := m.DB.QueryRow(
+		context.Background(), stmt,
+		l.UserID, l.Title, l.Description, l.Visibility, l.Slug,
+	).Scan(&l.ID, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), `violates unique constraint "sketch_lists_slug_key"`) {
+			return ErrDuplicateSlug
+		}
+		return err
+	}
+
+	l.ItemCount = ptr(0)
+	return nil
+}
+
+func (m *SketchListModel) RemoveItem(listId, sketchId int) error {
+	stmt := `DELETE FROM sketch_list_items WHERE list_id = $1 AND sketch_id = $2`
+
+	result, err := m.DB.Exec(context.Background(), stmt, listId, sketchId)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return m.touch(listId)
+}
+
+func (m *SketchListModel) Update(l *SketchList) error {
+	stmt := `
+		UPDATE sketch_lists
+		SET title = $1, description = $2, visibility = $3, updated_at = now()
+		WHERE id = $4
+		RETURNING updated_at
+	`
+
+	err := m.DB.QueryRow(
+		context.Background(), stmt,
+		l.Title, l.Description, l.Visibility, l.ID,
+	).Scan(&l.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (m *SketchListModel) UpdateItemNote(listId, sketchId int, note *string) error {
+	stmt := `
+		UPDATE sketch_list_items SET note = $1
+		WHERE list_id = $2 AND sketch_id = $3
+	`
+
+	result, err := m.DB.Exec(context.Background(), stmt, note, listId, sketchId)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return m.touch(listId)
+}
+
+func (m *SketchListModel) UpdatePositions(listId int, sketchIds []int) error {
+	stmt := `
+		UPDATE sketch_list_items as i
+		SET position = data.pos
+		FROM (
+			SELECT * FROM unnest($2::int[]) WITH ORDINALITY
+		) as data(sketch_id, pos)
+		WHERE i.list_id = $1 AND i.sketch_id = data.sketch_id
+	`
+
+	_, err := m.DB.Exec(context.Background(), stmt, listId, sketchIds)
+	if err != nil {
+		return err
+	}
+
+	return m.touch(listId)
+}
+
+func (m *SketchListModel) getOne(stmt string, arg any) (*SketchList, error) {
+	l := &SketchList{}
+	err := m.DB.QueryRow(context.Background(), stmt, arg).Scan(l.scanFields()...)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// touch bumps a list's updated_at when its items change
+func (m *SketchListModel) touch(listId int) error {
+	stmt := `UPDATE sketch_lists SET updated_at = now() WHERE id = $1`
+	_, err := m.DB.Exec(context.Background(), stmt, listId)
+	return err
+}
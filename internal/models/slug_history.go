@@ -0,0 +1,103 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// sluggable entities, each is also the name of its table and the first
+// segment of its page urls
+var sluggableTables = map[string]bool{
+	"sketch":    true,
+	"person":    true,
+	"character": true,
+	"creator":   true,
+	"show":      true,
+	"season":    true,
+	"episode":   true,
+	"series":    true,
+	"recurring": true,
+}
+
+func IsSluggable(entityType string) bool {
+	return sluggableTables[entityType]
+}
+
+type SlugHistoryModelInterface interface {
+	CurrentSlug(entityType string, id int) (string, error)
+	FindBySlug(entityType, slug string) (int, error)
+}
+
+// SlugHistoryModel reads the slugs entities had before being renamed, the
+// history itself is written by a trigger on each sluggable table
+type SlugHistoryModel struct {
+	DB *pgxpool.Pool
+}
+
+// CurrentSlug returns an entity's slug, which can be empty for seasons and
+// episodes
+func (m *SlugHistoryModel) CurrentSlug(entityType string, id int) (string, error) {
+	if !IsSluggable(entityType) {
+		return "", fmt.Errorf("%s doesn't have a slug", entityType)
+	}
+
+	stmt := fmt.Sprintf(`SELECT COALESCE(slug, '') FROM %s WHERE id = $1`, entityType)
+
+	var slug string
+	err := m.DB.QueryRow(context.Background(), stmt, id).Scan(&slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrNoRecord
+		}
+		return "", err
+	}
+	return slug, nil
+}
+
+// FindBySlug returns the id of the entity that has or most recently had
+// slug. Slugs aren't unique, so a current slug shared by several entities
+// isn't found.
+func (m *SlugHistoryModel) FindBySlug(entityType, slug string) (int, error) {
+	if !IsSluggable(entityType) {
+		return 0, fmt.Errorf("%s doesn't have a slug", entityType)
+	}
+
+	ctx := context.Background()
+	stmt := fmt.Sprintf(`SELECT id FROM %s WHERE slug = $1 LIMIT 2`, entityType)
+	rows, err := m.DB.Query(ctx, stmt, slug)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(ids) {
+	case 1:
+		return ids[0], nil
+	case 2:
+		return 0, ErrNoRecord
+	}
+
+	stmt = `
+		SELECT entity_id FROM slug_history
+		WHERE entity_type = $1 AND slug = $2
+		ORDER BY replaced_at DESC
+		LIMIT 1
+	`
+
+	var id int
+	err = m.DB.QueryRow(ctx, stmt, entityType, slug).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrNoRecord
+		}
+		return 0, err
+	}
+	return id, nil
+}
@@ -5,21 +5,50 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
+	"slices"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// API token scopes. Reads (GET requests) need ScopeRead, writes need the
+// scope of the area they change.
+const (
+	ScopeRead        = "read"
+	ScopeSketchWrite = "sketch:write"
+	ScopeMediaUpload = "media:upload"
+	ScopeUserWrite   = "user:write"
+	ScopeAdmin       = "admin"
+)
+
+var TokenScopes = []string{
+	ScopeRead, ScopeSketchWrite, ScopeMediaUpload, ScopeUserWrite, ScopeAdmin,
+}
+
+func IsTokenScope(scope string) bool {
+	return slices.Contains(TokenScopes, scope)
+}
+
 type TokenModel struct {
 	DB *pgxpool.Pool
 }
 
+// Token is a bearer token for the API, only the hash of the plaintext is
+// stored so a token can't be shown again after it's created
 type Token struct {
-	ID         int
-	UserID     int
-	CreatedAt  time.Time
-	LastUsedAt *time.Time
-	secret     password // reusing your existing struct
+	ID         int        `json:"id"`
+	UserID     int        `json:"userId"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+func (t *Token) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope)
 }
 
 func generateToken() (plaintext string, hash []byte, err error) {
@@ -34,22 +63,135 @@ func generateToken() (plaintext string, hash []byte, err error) {
 }
 
 type TokenModelInterface interface {
-	Insert(userId int) (string, error)
+	Delete(id, userId int) error
+	DeleteAllForUser(userId int) error
+	GetForUser(userId int) ([]*Token, error)
+	GetUser(plaintext string) (*User, *Token, error)
+	Insert(token *Token) (string, error)
 }
 
-func (m *TokenModel) Insert(userID int) (string, error) {
+// Insert saves a new token and returns its plaintext
+func (m *TokenModel) Insert(token *Token) (string, error) {
 	plaintext, hash, err := generateToken()
 	if err != nil {
 		return "", err
 	}
 
-	_, err = m.DB.Exec(context.Background(), `
-        INSERT INTO token (user_id, token_hash)
-        VALUES ($1, $2)
-    `, userID, hash)
+	stmt := `
+		INSERT INTO token (user_id, token_hash, name, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err = m.DB.QueryRow(
+		context.Background(), stmt,
+		token.UserID, hash, token.Name, token.Scopes, token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
 	if err != nil {
 		return "", err
 	}
 
 	return plaintext, nil
 }
+
+// Delete revokes one of a user's tokens
+func (m *TokenModel) Delete(id, userId int) error {
+	stmt := `DELETE FROM token WHERE id = $1 AND user_id = $2`
+
+	result, err := m.DB.Exec(context.Background(), stmt, id, userId)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// DeleteAllForUser revokes every token of a user
+func (m *TokenModel) DeleteAllForUser(userId int) error {
+	stmt := `DELETE FROM token WHERE user_id = $1`
+
+	_, err := m.DB.Exec(context.Background(), stmt, userId)
+	return err
+}
+
+func (m *TokenModel) GetForUser(userId int) ([]*Token, error) {
+	stmt := `
+		SELECT id, user_id, name, scopes, created_at, expires_at, last_used_at
+		FROM token
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`
+
+	rows, err := m.DB.Query(context.Background(), stmt, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*Token{}
+	for rows.Next() {
+		t := &Token{}
+		err := rows.Scan(
+			&t.ID, &t.UserID, &t.Name, &t.Scopes,
+			&t.CreatedAt, &t.ExpiresAt, &t.LastUsedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// GetUser returns the token for plaintext and the user that owns it, an
+// expired token is treated as missing. Using a token updates its
+// last_used_at, at most once a minute to keep writes down.
+func (m *TokenModel) GetUser(plaintext string) (*User, *Token, error) {
+	sum := sha256.Sum256([]byte(plaintext))
+
+	query := `
+		SELECT u.id, u.created_at, u.username, u.email, u.password_hash, u.activated, u.role,
+		t.id, t.user_id, t.name, t.scopes, t.created_at, t.expires_at, t.last_used_at
+		FROM users as u
+		JOIN token as t ON u.id = t.user_id
+		WHERE t.token_hash = $1
+		AND (t.expires_at IS NULL OR t.expires_at > now())
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	user := &User{}
+	t := &Token{}
+	err := m.DB.QueryRow(ctx, query, sum[:]).Scan(
+		&user.ID, &user.CreatedAt, &user.Username, &user.Email,
+		&user.Password.hash, &user.Activated, &user.Role,
+		&t.ID, &t.UserID, &t.Name, &t.Scopes, &t.CreatedAt, &t.ExpiresAt, &t.LastUsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, ErrNoRecord
+		}
+		return nil, nil, err
+	}
+
+	if t.LastUsedAt == nil || time.Since(*t.LastUsedAt) > time.Minute {
+		now := time.Now()
+		_, err = m.DB.Exec(ctx, `UPDATE token SET last_used_at = $1 WHERE id = $2`, now, t.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		t.LastUsedAt = &now
+	}
+
+	return user, t, nil
+}
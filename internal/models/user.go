@@ -2,7 +2,6 @@ package models
 
 import (
 	"context"
-	"crypto/sha256"
 	"errors"
 	"strings"
 	"time"
@@ -66,12 +65,14 @@ type UserModelInterface interface {
 	AddLike(userId, sketchId int) error
 	Authenticate(username, password string) (int, error)
 	DeleteRating(userId, sketchId int) error
+	Activate(id int) error
+	GetByEmail(email string) (*User, error)
 	GetById(id int) (*User, error)
-	GetByToken(plaintext string) (*User, error)
 	GetByUsername(username string) (*User, error)
 	GetUserSketchInfo(userId, sketchId int) (*UserSketchInfo, error)
 	Insert(user *User) error
 	RemoveLike(userId, sketchId int) error
+	ResetPassword(user *User) error
 	UpdateRating(userId, sketchId, rating int) error
 }
 
@@ -210,11 +211,26 @@ func (m *UserModel) GetByUsername(username string) (*User, error) {
 	return &user, nil
 }
 
-func (m *UserModel) GetById(id int) (*User, error) {
+func (m *UserModel) Activate(id int) error {
+	stmt := `UPDATE users SET activated = true WHERE id = $1`
+
+	result, err := m.DB.Exec(context.Background(), stmt, id)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+func (m *UserModel) GetByEmail(email string) (*User, error) {
 	query := `
 		SELECT id, created_at, username, email, password_hash, activated, role
 		FROM users
-		WHERE id = $1
+		WHERE email = $1
 	`
 
 	var user User
@@ -223,7 +239,7 @@ func (m *UserModel) GetById(id int) (*User, error) {
 	defer cancel()
 
 	password := password{}
-	err := m.DB.QueryRow(ctx, query, id).Scan(
+	err := m.DB.QueryRow(ctx, query, email).Scan(
 		&user.ID,
 		&user.CreatedAt,
 		&user.Username,
@@ -245,15 +261,11 @@ func (m *UserModel) GetById(id int) (*User, error) {
 	return &user, nil
 }
 
-func (m *UserModel) GetByToken(plaintext string) (*User, error) {
-	sum := sha256.Sum256([]byte(plaintext))
-	hash := sum[:]
-
+func (m *UserModel) GetById(id int) (*User, error) {
 	query := `
-		SELECT u.id, u.created_at, u.username, u.email, u.password_hash, u.activated, u.role
-		FROM users as u
-		JOIN token as t ON u.id = t.user_id
-		WHERE t.token_hash = $1
+		SELECT id, created_at, username, email, password_hash, activated, role
+		FROM users
+		WHERE id = $1
 	`
 
 	var user User
@@ -262,7 +274,7 @@ func (m *UserModel) GetByToken(plaintext string) (*User, error) {
 	defer cancel()
 
 	password := password{}
-	err := m.DB.QueryRow(ctx, query, hash).Scan(
+	err := m.DB.QueryRow(ctx, query, id).Scan(
 		&user.ID,
 		&user.CreatedAt,
 		&user.Username,
@@ -327,3 +339,38 @@ func (m *UserModel) RemoveLike(userId, sketchId int) error {
 	}
 	return nil
 }
+
+// ResetPassword saves the hash set with user.Password.Set, deleting the
+// user's password reset tokens and revoking their API tokens with it
+func (m *UserModel) ResetPassword(user *User) error {
+	ctx := context.Background()
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	stmt := `UPDATE users SET password_hash = $1 WHERE id = $2`
+	result, err := tx.Exec(ctx, stmt, user.Password.hash, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrNoRecord
+	}
+
+	stmt = `DELETE FROM user_tokens WHERE scope = $1 AND user_id = $2`
+	_, err = tx.Exec(ctx, stmt, ScopePasswordReset, user.ID)
+	if err != nil {
+		return err
+	}
+
+	stmt = `DELETE FROM token WHERE user_id = $1`
+	_, err = tx.Exec(ctx, stmt, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// scopes of the tokens emailed to users
+const (
+	ScopeActivation    = "activation"
+	ScopePasswordReset = "password_reset"
+)
+
+// UserToken is a single use token that is emailed to a user. Like bearer
+// tokens only the hash is stored, Plaintext is only set on creation.
+type UserToken struct {
+	Plaintext string
+	UserID    int
+	Scope     string
+	Expiry    time.Time
+}
+
+type UserTokenModelInterface interface {
+	DeleteAllForUser(scope string, userId int) error
+	GetUser(scope, plaintext string) (*User, error)
+	New(userId int, ttl time.Duration, scope string) (*UserToken, error)
+}
+
+type UserTokenModel struct {
+	DB *pgxpool.Pool
+}
+
+func (m *UserTokenModel) New(userId int, ttl time.Duration, scope string) (*UserToken, error) {
+	plaintext, hash, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	token := &UserToken{
+		Plaintext: plaintext,
+		UserID:    userId,
+		Scope:     scope,
+		Expiry:    time.Now().Add(ttl),
+	}
+
+	stmt := `
+		INSERT INTO user_tokens (hash, user_id, scope, expiry)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err = m.DB.Exec(context.Background(), stmt, hash, userId, scope, token.Expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// GetUser returns the user a token of the scope was issued to, expired
+// tokens are treated as missing
+func (m *UserTokenModel) GetUser(scope, plaintext string) (*User, error) {
+	sum := sha256.Sum256([]byte(plaintext))
+
+	query := `
+		SELECT u.id, u.created_at, u.username, u.email, u.password_hash, u.activated, u.role
+		FROM users as u
+		JOIN user_tokens as t ON u.id = t.user_id
+		WHERE t.hash = $1 AND t.scope = $2 AND t.expiry > now()
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var user User
+	err := m.DB.QueryRow(ctx, query, sum[:], scope).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Username,
+		&user.Email,
+		&user.Password.hash,
+		&user.Activated,
+		&user.Role,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (m *UserTokenModel) DeleteAllForUser(scope string, userId int) error {
+	stmt := `DELETE FROM user_tokens WHERE scope = $1 AND user_id = $2`
+
+	_, err := m.DB.Exec(context.Background(), stmt, scope, userId)
+	return err
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// VideoUpload is an in progress multipart upload of a sketch video. The row
+// is deleted once the upload is completed or aborted.
+type VideoUpload struct {
+	ID          *int       `json:"id"`
+	SketchID    *int       `json:"sketchId"`
+	S3Key       *string    `json:"s3Key"`
+	UploadID    *string    `json:"-"`
+	FileName    *string    `json:"fileName"`
+	ContentType *string    `json:"contentType"`
+	FileSize    *int64     `json:"fileSize"`
+	PartSize    *int64     `json:"partSize"`
+	CreatedAt   *time.Time `json:"createdAt"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+}
+
+// PartCount is the number of parts the file is split into
+func (u *VideoUpload) PartCount() int {
+	size, partSize := safeDeref(u.FileSize), safeDeref(u.PartSize)
+	if partSize <= 0 {
+		return 0
+	}
+	return int((size + partSize - 1) / partSize)
+}
+
+type VideoUploadModelInterface interface {
+	Delete(id int) error
+	Discard(id int) error
+	Get(id int) (*VideoUpload, error)
+	GetBySketch(sketchId int) ([]*VideoUpload, error)
+	GetStale(cutoff time.Time) ([]*VideoUpload, error)
+	Insert(upload *VideoUpload) error
+	Touch(id int) error
+}
+
+type VideoUploadModel struct {
+	DB *pgxpool.Pool
+}
+
+const videoUploadColumns = `
+	id, sketch_id, s3_key, upload_id, file_name, content_type,
+	file_size, part_size, created_at, updated_at
+`
+
+func scanVideoUpload(row pgx.Row) (*VideoUpload, error) {
+	u := &VideoUpload{}
+	err := row.Scan(
+		&u.ID, &u.SketchID, &u.S3Key, &u.UploadID, &u.FileName, &u.ContentType,
+		&u.FileSize, &u.PartSize, &u.CreatedAt, &u.UpdatedAt,
+	)
+	return u, err
+}
+
+func (m *VideoUploadModel) Insert(u *VideoUpload) error {
+	stmt := `
+		INSERT INTO video_uploads (
+			sketch_id, s3_key, upload_id, file_name, content_type, file_size, part_size
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+
+	return m.DB.QueryRow(
+		context.Background(), stmt, u.SketchID, u.S3Key, u.UploadID,
+		u.FileName, u.ContentType, u.FileSize, u.PartSize,
+	).Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt)
+}
+
+func (m *VideoUploadModel) Get(id int) (*VideoUpload, error) {
+	stmt := `SELECT ` + videoUploadColumns + ` FROM video_uploads WHERE id = $1`
+
+	u, err := scanVideoUpload(m.DB.QueryRow(context.Background(), stmt, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (m *VideoUploadModel) GetBySketch(sketchId int) ([]*VideoUpload, error) {
+	stmt := `
+		SELECT ` + videoUploadColumns + `
+		FROM video_uploads
+		WHERE sketch_id = $1
+		ORDER BY id
+	`
+	return m.query(stmt, sketchId)
+}
+
+// GetStale returns uploads that haven't had a part presigned since cutoff
+func (m *VideoUploadModel) GetStale(cutoff time.Time) ([]*VideoUpload, error) {
+	stmt := `
+		SELECT ` + videoUploadColumns + `
+		FROM video_uploads
+		WHERE updated_at < $1
+		ORDER BY id
+	`
+	return m.query(stmt, cutoff)
+}
+
+func (m *VideoUploadModel) query(stmt string, args ...any) ([]*VideoUpload, error) {
+	rows, err := m.DB.Query(context.Background(), stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	uploads := []*VideoUpload{}
+	for rows.Next() {
+		u, err := scanVideoUpload(rows)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return uploads, nil
+}
+
+// Touch marks the upload as active so it isn't swept as stale
+func (m *VideoUploadModel) Touch(id int) error {
+	stmt := `UPDATE video_uploads SET updated_at = now() WHERE id = $1`
+	_, err := m.DB.Exec(context.Background(), stmt, id)
+	return err
+}
+
+func (m *VideoUploadModel) Delete(id int) error {
+	stmt := `DELETE FROM video_uploads WHERE id = $1`
+	_, err := m.DB.Exec(context.Background(), stmt, id)
+	return err
+}
+
+// Discard deletes a completed upload that won't become a video, queueing
+// its assembled object for deletion. An object a video already points at
+// is left alone.
+func (m *VideoUploadModel) Discard(id int) error {
+	ctx := context.Background()
+	tx, err := m.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		SELECT 'media', u.s3_key FROM video_uploads as u
+		WHERE u.id = $1 AND NOT EXISTS (
+			SELECT 1 FROM sketch_video as v WHERE v.hot_s3_key = u.s3_key
+		)`
+	err = queueObjects(ctx, tx, query, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `DELETE FROM video_uploads WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	WikiPerson = "person"
+	WikiShow   = "show"
+)
+
+// WikiExtract is a cached Wikipedia intro extract keyed by the page name
+// stored in person.wiki_page / show.wiki_page
+type WikiExtract struct {
+	Page       *string    `json:"page"`
+	Title      *string    `json:"title"`
+	Extract    *string    `json:"extract"`
+	Redirected *bool      `json:"redirected"`
+	Missing    *bool      `json:"missing"`
+	FetchedAt  *time.Time `json:"fetchedAt"`
+}
+
+// WikiLink is a row that references a Wikipedia page along with the text
+// that gets populated from its extract (person.description or show.about)
+type WikiLink struct {
+	EntityType *string `json:"entityType"`
+	EntityID   *int    `json:"entityId"`
+	Name       *string `json:"name"`
+	Page       *string `json:"page"`
+	Text       *string `json:"-"`
+}
+
+type WikiModelInterface interface {
+	Get(page string) (*WikiExtract, error)
+	GetLinks() ([]*WikiLink, error)
+	UpdateLinkText(link *WikiLink, text string) error
+	Upsert(extract *WikiExtract) error
+}
+
+type WikiModel struct {
+	DB *pgxpool.Pool
+}
+
+func (m *WikiModel) Get(page string) (*WikiExtract, error) {
+	stmt := `
+		SELECT page, title, extract, redirected, missing, fetched_at
+		FROM wiki_extracts
+		WHERE page = $1
+	`
+
+	e := &WikiExtract{}
+	err := m.DB.QueryRow(context.Background(), stmt, page).Scan(
+		&e.Page, &e.Title, &e.Extract, &e.Redirected, &e.Missing, &e.FetchedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNoRecord
+		}
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (m *WikiModel) Upsert(e *WikiExtract) error {
+	stmt := `
+		INSERT INTO wiki_extracts (page, title, extract, redirected, missing, fetched_at)
+		VALUES ($1, $2, $3, COALESCE($4, false), COALESCE($5, false), now())
+		ON CONFLICT (page) DO UPDATE
+		SET title = EXCLUDED.title, extract = EXCLUDED.extract,
+		redirected = EXCLUDED.redirected, missing = EXCLUDED.missing,
+		fetched_at = EXCLUDED.fetched_at
+		RETURNING fetched_at
+	`
+
+	return m.DB.QueryRow(
+		context.Background(), stmt,
+		e.Page, e.Title, e.Extract, e.Redirected, e.Missing,
+	).Scan(&e.FetchedAt)
+}
+
+// GetLinks returns every person and show with a wiki page
+func (m *WikiModel) GetLinks() ([]*WikiLink, error) {
+	stmt := `
+		SELECT 'person', id, CONCAT(first, ' ', last), wiki_page, description
+		FROM person
+		WHERE wiki_page IS NOT NULL AND wiki_page <> ''
+		UNION ALL
+		SELECT 'show', id, name, wiki_page, about
+		FROM show
+		WHERE wiki_page IS NOT NULL AND wiki_page <> ''
+		ORDER BY 1, 2
+	`
+
+	rows, err := m.DB.Query(context.Background(), stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []*WikiLink{}
+	for rows.Next() {
+		l := &WikiLink{}
+		err := rows.Scan(&l.EntityType, &l.EntityID, &l.Name, &l.Page, &l.Text)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (m *WikiModel) UpdateLinkText(link *WikiLink, text string) error {
+	var stmt string
+	switch safeDeref(link.EntityType) {
+	case WikiPerson:
+		stmt = `UPDATE person SET description = $1 WHERE id = $2`
+	case WikiShow:
+		stmt = `UPDATE show SET about = $1 WHERE id = $2`
+	default:
+		return fmt.Errorf("unknown wiki link entity type %q", safeDeref(link.EntityType))
+	}
+
+	_, err := m.DB.Exec(context.Background(), stmt, text, link.EntityID)
+	return err
+}
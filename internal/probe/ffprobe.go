@@ -0,0 +1,99 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+type ffprobeOutput struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+		CodecName string `json:"codec_name"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+	} `json:"streams"`
+}
+
+// FFProbe runs the ffprobe binary at path on input (a file path or url)
+func FFProbe(ctx context.Context, path, input string) (*Info, error) {
+	cmd := exec.CommandContext(ctx, path,
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format", "-show_streams",
+		input,
+	)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseFFProbe(out)
+}
+
+func parseFFProbe(data []byte) (*Info, error) {
+	var out ffprobeOutput
+	err := json.Unmarshal(data, &out)
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+
+	info := &Info{Container: ffprobeContainer(out.Format.FormatName)}
+
+	if out.Format.Duration != "" {
+		seconds, err := strconv.ParseFloat(out.Format.Duration, 64)
+		if err == nil {
+			info.DurationMs = int64(math.Round(seconds * 1000))
+		}
+	}
+
+	for _, s := range out.Streams {
+		switch s.CodecType {
+		case "video":
+			// cover art is reported as a video stream, keep the first
+			if info.VideoCodec == "" {
+				info.VideoCodec = s.CodecName
+				info.Width = s.Width
+				info.Height = s.Height
+			}
+		case "audio":
+			if info.AudioCodec == "" {
+				info.AudioCodec = s.CodecName
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// ffprobeContainer maps ffprobe's comma separated demuxer names onto our
+// container names
+func ffprobeContainer(formatName string) string {
+	names := strings.Split(formatName, ",")
+	for _, name := range names {
+		switch name {
+		case "mp4":
+			return ContainerMP4
+		case "matroska":
+			return ContainerMatroska
+		}
+	}
+
+	if len(names) > 0 && names[0] != "" {
+		return names[0]
+	}
+	return ""
+}
@@ -0,0 +1,393 @@
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// EBML element ids used to find the segment info and tracks
+const (
+	idEBML          = 0x1A45DFA3
+	idDocType       = 0x4282
+	idSegment       = 0x18538067
+	idSeekHead      = 0x114D9B74
+	idSeek          = 0x4DBB
+	idSeekID        = 0x53AB
+	idSeekPosition  = 0x53AC
+	idInfo          = 0x1549A966
+	idTimecodeScale = 0x2AD7B1
+	idDuration      = 0x4489
+	idTracks        = 0x1654AE6B
+	idTrackEntry    = 0xAE
+	idTrackType     = 0x83
+	idCodecID       = 0x86
+	idVideo         = 0xE0
+	idPixelWidth    = 0xB0
+	idPixelHeight   = 0xBA
+	idCluster       = 0x1F43B675
+)
+
+// unknownSize marks an element whose size isn't known up front (live
+// streams), it extends to the end of its parent
+const unknownSize = -1
+
+// maxElementSize caps the size of the elements read into memory
+const maxElementSize = 16 << 20
+
+type element struct {
+	id     uint64
+	offset int64 // start of the data
+	size   int64
+}
+
+// readVint reads an EBML variable length integer from buf. With keepMarker
+// the length marker bit is kept, as it is for element ids.
+func readVint(buf []byte, keepMarker bool) (uint64, int, error) {
+	if len(buf) == 0 || buf[0] == 0 {
+		return 0, 0, ErrMalformed
+	}
+
+	length := 1
+	for mask := byte(0x80); buf[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+
+	if length > 8 || length > len(buf) {
+		return 0, 0, ErrMalformed
+	}
+
+	value := uint64(buf[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+	for _, b := range buf[1:length] {
+		value = value<<8 | uint64(b)
+	}
+
+	return value, length, nil
+}
+
+func readElement(r io.ReaderAt, offset int64) (*element, error) {
+	buf := make([]byte, 12)
+	n, err := r.ReadAt(buf, offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	buf = buf[:n]
+
+	id, idLen, err := readVint(buf, true)
+	if err != nil {
+		return nil, err
+	}
+
+	size, sizeLen, err := readVint(buf[idLen:], false)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &element{id: id, offset: offset + int64(idLen+sizeLen), size: int64(size)}
+	if size == 1<<(7*sizeLen)-1 {
+		e.size = unknownSize
+	}
+	return e, nil
+}
+
+// elements parses the children of an element held in memory
+func elements(data []byte) ([]*element, error) {
+	var elems []*element
+	for offset := 0; offset < len(data); {
+		id, idLen, err := readVint(data[offset:], true)
+		if err != nil {
+			return nil, err
+		}
+
+		size, sizeLen, err := readVint(data[offset+idLen:], false)
+		if err != nil {
+			return nil, err
+		}
+
+		start := offset + idLen + sizeLen
+		if size > uint64(len(data)-start) {
+			return nil, fmt.Errorf("%w: element %x has invalid size", ErrMalformed, id)
+		}
+
+		elems = append(elems, &element{id: id, offset: int64(start), size: int64(size)})
+		offset = start + int(size)
+	}
+	return elems, nil
+}
+
+func readBody(r io.ReaderAt, e *element) ([]byte, error) {
+	if e.size == unknownSize || e.size > maxElementSize {
+		return nil, fmt.Errorf("%w: element %x too large", ErrMalformed, e.id)
+	}
+
+	data := make([]byte, e.size)
+	_, err := r.ReadAt(data, e.offset)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return data, nil
+}
+
+func parseMatroska(r io.ReaderAt, size int64) (*Info, error) {
+	header, err := readElement(r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{Container: ContainerMatroska}
+
+	body, err := readBody(r, header)
+	if err != nil {
+		return nil, err
+	}
+
+	elems, err := elements(body)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range elems {
+		if e.id == idDocType && string(body[e.offset:e.offset+e.size]) == "webm" {
+			info.Container = ContainerWebM
+		}
+	}
+
+	segment, err := readElement(r, header.offset+header.size)
+	if err != nil {
+		return nil, err
+	}
+
+	if segment.id != idSegment {
+		return nil, fmt.Errorf("%w: no segment", ErrMalformed)
+	}
+
+	end := size
+	if segment.size != unknownSize && segment.offset+segment.size < end {
+		end = segment.offset + segment.size
+	}
+
+	var infoElem, tracksElem *element
+	seeks := map[uint64]int64{}
+
+	for offset := segment.offset; offset < end && (infoElem == nil || tracksElem == nil); {
+		e, err := readElement(r, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		switch e.id {
+		case idInfo:
+			infoElem = e
+		case idTracks:
+			tracksElem = e
+		case idSeekHead:
+			data, err := readBody(r, e)
+			if err != nil {
+				return nil, err
+			}
+			parseSeekHead(data, seeks)
+		}
+
+		// clusters hold the frames, jump past them using the seek head
+		// rather than walking every cluster
+		if e.id == idCluster || e.size == unknownSize {
+			break
+		}
+		offset = e.offset + e.size
+	}
+
+	if infoElem == nil {
+		infoElem = seekTo(r, segment.offset, seeks[idInfo], idInfo)
+	}
+	if tracksElem == nil {
+		tracksElem = seekTo(r, segment.offset, seeks[idTracks], idTracks)
+	}
+
+	if infoElem == nil {
+		return nil, fmt.Errorf("%w: no segment info", ErrMalformed)
+	}
+
+	data, err := readBody(r, infoElem)
+	if err != nil {
+		return nil, err
+	}
+	err = parseSegmentInfo(data, info)
+	if err != nil {
+		return nil, err
+	}
+
+	if tracksElem != nil {
+		data, err := readBody(r, tracksElem)
+		if err != nil {
+			return nil, err
+		}
+		err = parseTracks(data, info)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return info, nil
+}
+
+func parseSeekHead(data []byte, seeks map[uint64]int64) {
+	elems, err := elements(data)
+	if err != nil {
+		return
+	}
+
+	for _, seek := range elems {
+		if seek.id != idSeek {
+			continue
+		}
+
+		body := data[seek.offset : seek.offset+seek.size]
+		fields, err := elements(body)
+		if err != nil {
+			continue
+		}
+
+		var id uint64
+		var pos int64 = -1
+		for _, f := range fields {
+			value := body[f.offset : f.offset+f.size]
+			switch f.id {
+			case idSeekID:
+				id = readUint(value)
+			case idSeekPosition:
+				pos = int64(readUint(value))
+			}
+		}
+
+		if id != 0 && pos >= 0 {
+			seeks[id] = pos
+		}
+	}
+}
+
+// seekTo reads the element at a seek head position (relative to the
+// segment data), returning nil unless it has the expected id
+func seekTo(r io.ReaderAt, segmentOffset, position int64, id uint64) *element {
+	if position <= 0 {
+		return nil
+	}
+
+	e, err := readElement(r, segmentOffset+position)
+	if err != nil || e.id != id {
+		return nil
+	}
+	return e
+}
+
+func parseSegmentInfo(data []byte, info *Info) error {
+	elems, err := elements(data)
+	if err != nil {
+		return err
+	}
+
+	// durations are in timecode units, nanoseconds * scale
+	scale := uint64(1_000_000)
+	var duration float64
+	for _, e := range elems {
+		value := data[e.offset : e.offset+e.size]
+		switch e.id {
+		case idTimecodeScale:
+			scale = readUint(value)
+		case idDuration:
+			duration = readFloat(value)
+		}
+	}
+
+	info.DurationMs = int64(math.Round(duration * float64(scale) / 1e6))
+	return nil
+}
+
+func parseTracks(data []byte, info *Info) error {
+	entries, err := elements(data)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.id != idTrackEntry {
+			continue
+		}
+
+		body := data[entry.offset : entry.offset+entry.size]
+		fields, err := elements(body)
+		if err != nil {
+			return err
+		}
+
+		var trackType uint64
+		var codec string
+		var width, height int
+		for _, f := range fields {
+			value := body[f.offset : f.offset+f.size]
+			switch f.id {
+			case idTrackType:
+				trackType = readUint(value)
+			case idCodecID:
+				codec = string(value)
+			case idVideo:
+				width, height = parseVideoSettings(value)
+			}
+		}
+
+		switch trackType {
+		case 1:
+			if info.VideoCodec == "" {
+				info.VideoCodec = codecName(codec)
+				info.Width, info.Height = width, height
+			}
+		case 2:
+			if info.AudioCodec == "" {
+				info.AudioCodec = codecName(codec)
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseVideoSettings(data []byte) (int, int) {
+	elems, err := elements(data)
+	if err != nil {
+		return 0, 0
+	}
+
+	var width, height int
+	for _, e := range elems {
+		value := data[e.offset : e.offset+e.size]
+		switch e.id {
+		case idPixelWidth:
+			width = int(readUint(value))
+		case idPixelHeight:
+			height = int(readUint(value))
+		}
+	}
+	return width, height
+}
+
+func readUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func readFloat(b []byte) float64 {
+	switch len(b) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
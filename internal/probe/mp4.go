@@ -0,0 +1,249 @@
+package probe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxMoovSize caps the size of the metadata box read into memory
+const maxMoovSize = 64 << 20
+
+type box struct {
+	typ    string
+	offset int64 // start of the box header
+	header int64
+	size   int64 // including the header
+}
+
+// readBoxHeader reads the header of the box at offset, end is the offset the
+// box may not extend past
+func readBoxHeader(r io.ReaderAt, offset, end int64) (*box, error) {
+	buf := make([]byte, 16)
+	n, err := r.ReadAt(buf, offset)
+	if n < 8 {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = ErrMalformed
+		}
+		return nil, err
+	}
+
+	b := &box{
+		typ:    string(buf[4:8]),
+		offset: offset,
+		header: 8,
+		size:   int64(binary.BigEndian.Uint32(buf[:4])),
+	}
+
+	switch b.size {
+	case 0:
+		// box extends to the end of the file
+		b.size = end - offset
+	case 1:
+		if n < 16 {
+			return nil, ErrMalformed
+		}
+		b.header = 16
+		b.size = int64(binary.BigEndian.Uint64(buf[8:16]))
+	}
+
+	if b.size < b.header || offset+b.size > end {
+		return nil, fmt.Errorf("%w: box %q has invalid size", ErrMalformed, b.typ)
+	}
+
+	return b, nil
+}
+
+func parseMP4(r io.ReaderAt, size int64) (*Info, error) {
+	info := &Info{Container: ContainerMP4}
+
+	var moov *box
+	for offset := int64(0); offset < size; {
+		b, err := readBoxHeader(r, offset, size)
+		if err != nil {
+			return nil, err
+		}
+
+		switch b.typ {
+		case "ftyp":
+			brand := make([]byte, 4)
+			if _, err := r.ReadAt(brand, offset+b.header); err == nil && string(brand) == "qt  " {
+				info.Container = ContainerMOV
+			}
+		case "moov":
+			moov = b
+		}
+
+		if moov != nil {
+			break
+		}
+		offset += b.size
+	}
+
+	if moov == nil {
+		return nil, fmt.Errorf("%w: no moov box", ErrMalformed)
+	}
+
+	if moov.size > maxMoovSize {
+		return nil, fmt.Errorf("%w: moov box too large", ErrMalformed)
+	}
+
+	data := make([]byte, moov.size-moov.header)
+	_, err := r.ReadAt(data, moov.offset+moov.header)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	err = parseMoov(data, info)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// children splits the payload of a container box into its child boxes
+func children(data []byte) (map[string][][]byte, error) {
+	boxes := map[string][][]byte{}
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, ErrMalformed
+		}
+
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		typ := string(data[4:8])
+		header := uint64(8)
+
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, ErrMalformed
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			header = 16
+		}
+
+		if size < header || size > uint64(len(data)) {
+			return nil, fmt.Errorf("%w: box %q has invalid size", ErrMalformed, typ)
+		}
+
+		boxes[typ] = append(boxes[typ], data[header:size])
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+func child(data []byte, path ...string) []byte {
+	for _, typ := range path {
+		boxes, err := children(data)
+		if err != nil || len(boxes[typ]) == 0 {
+			return nil
+		}
+		data = boxes[typ][0]
+	}
+	return data
+}
+
+func parseMoov(moov []byte, info *Info) error {
+	boxes, err := children(moov)
+	if err != nil {
+		return err
+	}
+
+	if mvhd := first(boxes["mvhd"]); mvhd != nil {
+		timescale, duration, ok := parseDuration(mvhd)
+		if ok && timescale > 0 {
+			info.DurationMs = int64(duration * 1000 / timescale)
+		}
+	}
+
+	for _, trak := range boxes["trak"] {
+		hdlr := child(trak, "mdia", "hdlr")
+		if len(hdlr) < 12 {
+			continue
+		}
+
+		codec, entry := sampleEntry(child(trak, "mdia", "minf", "stbl", "stsd"))
+
+		switch string(hdlr[8:12]) {
+		case "vide":
+			if info.VideoCodec != "" {
+				continue
+			}
+			info.VideoCodec = codecName(codec)
+			info.Width, info.Height = trackDimensions(child(trak, "tkhd"))
+			// fall back to the coded size if the track header has none
+			if (info.Width == 0 || info.Height == 0) && len(entry) >= 36 {
+				info.Width = int(binary.BigEndian.Uint16(entry[32:34]))
+				info.Height = int(binary.BigEndian.Uint16(entry[34:36]))
+			}
+		case "soun":
+			if info.AudioCodec == "" {
+				info.AudioCodec = codecName(codec)
+			}
+		}
+	}
+
+	return nil
+}
+
+func first(boxes [][]byte) []byte {
+	if len(boxes) == 0 {
+		return nil
+	}
+	return boxes[0]
+}
+
+// parseDuration reads the timescale and duration of a mvhd box
+func parseDuration(mvhd []byte) (uint64, uint64, bool) {
+	if len(mvhd) < 1 {
+		return 0, 0, false
+	}
+
+	if mvhd[0] == 1 {
+		if len(mvhd) < 32 {
+			return 0, 0, false
+		}
+		return uint64(binary.BigEndian.Uint32(mvhd[20:24])), binary.BigEndian.Uint64(mvhd[24:32]), true
+	}
+
+	if len(mvhd) < 20 {
+		return 0, 0, false
+	}
+	return uint64(binary.BigEndian.Uint32(mvhd[12:16])), uint64(binary.BigEndian.Uint32(mvhd[16:20])), true
+}
+
+// trackDimensions reads the 16.16 fixed point display size of a tkhd box
+func trackDimensions(tkhd []byte) (int, int) {
+	offset := 76
+	if len(tkhd) > 0 && tkhd[0] == 1 {
+		offset = 88
+	}
+
+	if len(tkhd) < offset+8 {
+		return 0, 0
+	}
+
+	width := binary.BigEndian.Uint32(tkhd[offset : offset+4])
+	height := binary.BigEndian.Uint32(tkhd[offset+4 : offset+8])
+	return int(width >> 16), int(height >> 16)
+}
+
+// sampleEntry returns the format and body of the first entry of a stsd box
+func sampleEntry(stsd []byte) (string, []byte) {
+	// version/flags and entry count precede the entries
+	if len(stsd) < 16 {
+		return "", nil
+	}
+
+	entry := stsd[8:]
+	size := binary.BigEndian.Uint32(entry[:4])
+	if size < 8 || int(size) > len(entry) {
+		return string(entry[4:8]), nil
+	}
+
+	return string(entry[4:8]), entry[:size]
+}
@@ -0,0 +1,136 @@
+// Package probe extracts container metadata (duration, dimensions and
+// codecs) from video files, using ffprobe when it is installed and falling
+// back to a minimal MP4/WebM parser otherwise.
+package probe
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+const (
+	ContainerMP4      = "mp4"
+	ContainerMOV      = "mov"
+	ContainerWebM     = "webm"
+	ContainerMatroska = "matroska"
+)
+
+var (
+	ErrUnsupported = errors.New("probe: unsupported container")
+	ErrMalformed   = errors.New("probe: malformed file")
+)
+
+type Info struct {
+	Container  string
+	DurationMs int64
+	Width      int
+	Height     int
+	VideoCodec string
+	AudioCodec string
+}
+
+// HasVideo reports whether a video stream was found
+func (i *Info) HasVideo() bool {
+	return i.VideoCodec != "" || (i.Width > 0 && i.Height > 0)
+}
+
+// ContentType returns the mime type of the container
+func (i *Info) ContentType() string {
+	switch i.Container {
+	case ContainerMP4:
+		return "video/mp4"
+	case ContainerMOV:
+		return "video/quicktime"
+	case ContainerWebM:
+		return "video/webm"
+	case ContainerMatroska:
+		return "video/x-matroska"
+	}
+	return ""
+}
+
+// Prober runs FFProbe on the input when FFProbePath is set, otherwise (or
+// if ffprobe fails) the file is parsed with Parse.
+type Prober struct {
+	FFProbePath string
+}
+
+// Probe inspects a file. input is a path or url ffprobe can read, it may be
+// empty in which case only r is used.
+func (p *Prober) Probe(ctx context.Context, input string, r io.ReaderAt, size int64) (*Info, error) {
+	if p.FFProbePath != "" && input != "" {
+		info, err := FFProbe(ctx, p.FFProbePath, input)
+		if err == nil {
+			return info, nil
+		}
+	}
+
+	return Parse(r, size)
+}
+
+// Parse detects the container from its magic bytes and parses MP4/QuickTime
+// and WebM/Matroska files. Only the headers are read, so r can be backed by
+// range requests.
+func Parse(r io.ReaderAt, size int64) (*Info, error) {
+	head := make([]byte, 12)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	switch {
+	case len(head) >= 4 && string(head[:4]) == "\x1a\x45\xdf\xa3":
+		return parseMatroska(r, size)
+	case len(head) >= 8 && isMP4Box(string(head[4:8])):
+		return parseMP4(r, size)
+	}
+
+	return nil, ErrUnsupported
+}
+
+func isMP4Box(typ string) bool {
+	switch typ {
+	case "ftyp", "moov", "mdat", "free", "skip", "wide", "pnot":
+		return true
+	}
+	return false
+}
+
+var codecNames = map[string]string{
+	// mp4 sample entry types
+	"avc1": "h264",
+	"avc3": "h264",
+	"hvc1": "hevc",
+	"hev1": "hevc",
+	"av01": "av1",
+	"vp08": "vp8",
+	"vp09": "vp9",
+	"mp4v": "mpeg4",
+	"mp4a": "aac",
+	"Opus": "opus",
+	"ac-3": "ac3",
+	"ec-3": "eac3",
+	".mp3": "mp3",
+	// matroska codec ids
+	"V_MPEG4/ISO/AVC":  "h264",
+	"V_MPEGH/ISO/HEVC": "hevc",
+	"V_AV1":            "av1",
+	"V_VP8":            "vp8",
+	"V_VP9":            "vp9",
+	"A_OPUS":           "opus",
+	"A_VORBIS":         "vorbis",
+	"A_AAC":            "aac",
+	"A_MPEG/L3":        "mp3",
+	"A_AC3":            "ac3",
+	"A_EAC3":           "eac3",
+}
+
+// codecName maps container codec identifiers onto the names ffprobe uses
+func codecName(id string) string {
+	if name, ok := codecNames[id]; ok {
+		return name
+	}
+	return id
+}
@@ -0,0 +1,203 @@
+package probe
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	"sketchdb.cozycole.net/internal/assert"
+)
+
+func mp4Box(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	b := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(b, uint32(8+len(body)))
+	copy(b[4:], typ)
+	return append(b, body...)
+}
+
+func u32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+func mp4Track(handler, format string, width, height uint32) []byte {
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], width<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], height<<16)
+
+	hdlr := append(make([]byte, 8), handler...)
+	hdlr = append(hdlr, make([]byte, 12)...)
+
+	entry := mp4Box(format, make([]byte, 70))
+	stsd := append(append(make([]byte, 4), u32(1)...), entry...)
+
+	return mp4Box("trak",
+		mp4Box("tkhd", tkhd),
+		mp4Box("mdia",
+			mp4Box("hdlr", hdlr),
+			mp4Box("minf", mp4Box("stbl", mp4Box("stsd", stsd))),
+		),
+	)
+}
+
+func testMP4(brand string) []byte {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)   // timescale
+	binary.BigEndian.PutUint32(mvhd[16:], 95_500) // duration
+
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte(brand), u32(0)),
+		// moov after mdat, as written by encoders without faststart
+		mp4Box("mdat", make([]byte, 1024)),
+		mp4Box("moov",
+			mp4Box("mvhd", mvhd),
+			mp4Track("vide", "avc1", 1920, 1080),
+			mp4Track("soun", "mp4a", 0, 0),
+		),
+	}, nil)
+}
+
+func TestParseMP4(t *testing.T) {
+	data := testMP4("isom")
+	info, err := Parse(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.DeepEqual(t, info, &Info{
+		Container:  ContainerMP4,
+		DurationMs: 95_500,
+		Width:      1920,
+		Height:     1080,
+		VideoCodec: "h264",
+		AudioCodec: "aac",
+	})
+	assert.Equal(t, info.HasVideo(), true)
+	assert.Equal(t, info.ContentType(), "video/mp4")
+
+	data = testMP4("qt  ")
+	info, err = Parse(bytes.NewReader(data), int64(len(data)))
+	assert.Equal(t, err, nil)
+	assert.Equal(t, info.Container, ContainerMOV)
+
+	// truncated before the moov box
+	_, err = Parse(bytes.NewReader(data[:1000]), 1000)
+	assert.Equal(t, errors.Is(err, ErrMalformed), true)
+}
+
+func ebml(id uint64, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+
+	var b []byte
+	for shift := 24; shift >= 0; shift -= 8 {
+		if c := byte(id >> shift); c != 0 || len(b) > 0 {
+			b = append(b, c)
+		}
+	}
+
+	// 8 byte size
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(body)))
+	size[0] = 0x01
+	b = append(b, size...)
+	return append(b, body...)
+}
+
+func ebmlUint(id uint64, v uint64) []byte {
+	return ebml(id, binary.BigEndian.AppendUint64(nil, v))
+}
+
+func testWebM(withSeekHead bool) []byte {
+	info := ebml(idInfo,
+		ebmlUint(idTimecodeScale, 1_000_000),
+		ebml(idDuration, binary.BigEndian.AppendUint64(nil, math.Float64bits(12_345))),
+	)
+	tracks := ebml(idTracks,
+		ebml(idTrackEntry,
+			ebmlUint(idTrackType, 1),
+			ebml(idCodecID, []byte("V_VP9")),
+			ebml(idVideo, ebmlUint(idPixelWidth, 1280), ebmlUint(idPixelHeight, 720)),
+		),
+		ebml(idTrackEntry,
+			ebmlUint(idTrackType, 2),
+			ebml(idCodecID, []byte("A_OPUS")),
+		),
+	)
+	cluster := ebml(idCluster, make([]byte, 512))
+
+	var segment []byte
+	if withSeekHead {
+		// metadata after the clusters, found through the seek head
+		seekHeadLen := len(ebml(idSeekHead,
+			ebml(idSeek, ebmlUint(idSeekID, idInfo), ebmlUint(idSeekPosition, 0)),
+			ebml(idSeek, ebmlUint(idSeekID, idTracks), ebmlUint(idSeekPosition, 0)),
+		))
+		infoPos := uint64(seekHeadLen + len(cluster))
+		tracksPos := infoPos + uint64(len(info))
+		seekHead := ebml(idSeekHead,
+			ebml(idSeek, ebmlUint(idSeekID, idInfo), ebmlUint(idSeekPosition, infoPos)),
+			ebml(idSeek, ebmlUint(idSeekID, idTracks), ebmlUint(idSeekPosition, tracksPos)),
+		)
+		segment = bytes.Join([][]byte{seekHead, cluster, info, tracks}, nil)
+	} else {
+		segment = bytes.Join([][]byte{info, tracks, cluster}, nil)
+	}
+
+	return append(
+		ebml(idEBML, ebml(idDocType, []byte("webm"))),
+		ebml(idSegment, segment)...,
+	)
+}
+
+func TestParseWebM(t *testing.T) {
+	want := &Info{
+		Container:  ContainerWebM,
+		DurationMs: 12_345,
+		Width:      1280,
+		Height:     720,
+		VideoCodec: "vp9",
+		AudioCodec: "opus",
+	}
+
+	for _, withSeekHead := range []bool{false, true} {
+		data := testWebM(withSeekHead)
+		info, err := Parse(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.DeepEqual(t, info, want)
+	}
+}
+
+func TestParseUnsupported(t *testing.T) {
+	data := []byte("RIFF\x00\x00\x00\x00AVI LIST")
+	_, err := Parse(bytes.NewReader(data), int64(len(data)))
+	assert.Equal(t, err, ErrUnsupported)
+}
+
+func TestParseFFProbe(t *testing.T) {
+	out := []byte(`{
+		"streams": [
+			{"codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080},
+			{"codec_type": "audio", "codec_name": "aac"},
+			{"codec_type": "video", "codec_name": "mjpeg", "width": 300, "height": 300}
+		],
+		"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "duration": "61.0205"}
+	}`)
+
+	info, err := parseFFProbe(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.DeepEqual(t, info, &Info{
+		Container:  ContainerMP4,
+		DurationMs: 61_021,
+		Width:      1920,
+		Height:     1080,
+		VideoCodec: "h264",
+		AudioCodec: "aac",
+	})
+}
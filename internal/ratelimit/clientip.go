@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies are the networks of reverse proxies whose
+// X-Forwarded-For header is believed
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies parses a comma separated list of IPs and CIDR
+// ranges, e.g. "127.0.0.1,10.0.0.0/8"
+func ParseTrustedProxies(s string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		if strings.Contains(field, "/") {
+			prefix, err := netip.ParsePrefix(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", field, err)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", field, err)
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return proxies, nil
+}
+
+func (t TrustedProxies) trusts(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range t {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the IP of the client that made the request. The
+// X-Forwarded-For header is only used when the request came from a trusted
+// proxy, in which case the address furthest right that isn't a trusted
+// proxy is the client, anything left of it could've been set by the client.
+func (t TrustedProxies) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	remote, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	remote = remote.Unmap()
+
+	if !t.trusts(remote) {
+		return remote.String()
+	}
+
+	var forwarded []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+
+	client := remote
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !t.trusts(client) {
+			break
+		}
+	}
+
+	return client.String()
+}
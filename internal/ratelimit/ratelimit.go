@@ -0,0 +1,110 @@
+// Package ratelimit provides token bucket rate limiting keyed by an
+// arbitrary string such as a client IP or user id.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a sustained rate of Rate requests per second with bursts of up
+// to Burst requests. A zero Limit allows everything.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute returns a limit of n requests a minute with the given burst
+func PerMinute(n int, burst int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: burst}
+}
+
+func (l Limit) unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// buckets untouched for this long are refilled to burst, which is the same
+// as not having a bucket, so they're dropped from the map
+const pruneInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter tracks a token bucket for each key. It's safe for concurrent
+// use.
+type Limiter struct {
+	limit Limit
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+func New(limit Limit) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		now:     time.Now,
+		buckets: map[string]*bucket{},
+	}
+}
+
+func (l *Limiter) Limit() Limit {
+	return l.limit
+}
+
+// Allow takes a token from key's bucket. If the bucket is empty it returns
+// false along with how long until a token is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l.limit.unlimited() {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastPrune) >= pruneInterval {
+		l.prune(now)
+	}
+
+	burst := float64(l.limit.Burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	} else {
+		elapsed := now.Sub(b.last).Seconds()
+		b.tokens = math.Min(burst, b.tokens+elapsed*l.limit.Rate)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		wait := (1 - b.tokens) / l.limit.Rate
+		return false, time.Duration(math.Ceil(wait * float64(time.Second)))
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// prune drops the buckets that would have refilled by now
+func (l *Limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		refilled := b.tokens + now.Sub(b.last).Seconds()*l.limit.Rate
+		if refilled >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
+
+// Len returns the number of keys currently being tracked
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
@@ -0,0 +1,192 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sketchdb.cozycole.net/internal/assert"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestLimiter(limit Limit) (*Limiter, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	l := New(limit)
+	l.now = clock.now
+	return l, clock
+}
+
+func TestLimiterBurst(t *testing.T) {
+	l, clock := newTestLimiter(Limit{Rate: 1, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("a")
+		assert.Equal(t, ok, true)
+	}
+
+	ok, wait := l.Allow("a")
+	assert.Equal(t, ok, false)
+	assert.Equal(t, wait, time.Second)
+
+	// other keys have their own bucket
+	ok, _ = l.Allow("b")
+	assert.Equal(t, ok, true)
+
+	clock.advance(500 * time.Millisecond)
+	ok, wait = l.Allow("a")
+	assert.Equal(t, ok, false)
+	assert.Equal(t, wait, 500*time.Millisecond)
+
+	clock.advance(500 * time.Millisecond)
+	ok, _ = l.Allow("a")
+	assert.Equal(t, ok, true)
+	ok, _ = l.Allow("a")
+	assert.Equal(t, ok, false)
+}
+
+func TestLimiterRefillCapped(t *testing.T) {
+	l, clock := newTestLimiter(PerMinute(6, 2))
+
+	l.Allow("a")
+	l.Allow("a")
+	ok, wait := l.Allow("a")
+	assert.Equal(t, ok, false)
+	assert.Equal(t, wait, 10*time.Second)
+
+	// a long wait doesn't refill past the burst
+	clock.advance(time.Hour)
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if ok, _ := l.Allow("a"); ok {
+			allowed++
+		}
+	}
+	assert.Equal(t, allowed, 2)
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	l, _ := newTestLimiter(Limit{})
+
+	for i := 0; i < 100; i++ {
+		ok, _ := l.Allow("a")
+		assert.Equal(t, ok, true)
+	}
+	assert.Equal(t, l.Len(), 0)
+}
+
+func TestLimiterPrune(t *testing.T) {
+	l, clock := newTestLimiter(PerMinute(3, 5))
+
+	l.Allow("a")
+	clock.advance(pruneInterval - 10*time.Second)
+	for i := 0; i < 5; i++ {
+		l.Allow("b")
+	}
+	assert.Equal(t, l.Len(), 2)
+
+	// "a" has refilled so it's dropped, "b" is still draining
+	clock.advance(10 * time.Second)
+	l.Allow("c")
+	assert.Equal(t, l.Len(), 2)
+	_, tracked := l.buckets["a"]
+	assert.Equal(t, tracked, false)
+
+	ok, _ := l.Allow("a")
+	assert.Equal(t, ok, true)
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies("127.0.0.1, ::1,10.0.0.0/8,")
+	assert.NilError(t, err)
+	assert.Equal(t, len(proxies), 3)
+	assert.Equal(t, proxies[0].String(), "127.0.0.1/32")
+	assert.Equal(t, proxies[1].String(), "::1/128")
+	assert.Equal(t, proxies[2].String(), "10.0.0.0/8")
+
+	_, err = ParseTrustedProxies("nginx")
+	assert.Equal(t, err != nil, true)
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("127.0.0.1,10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{
+			name:       "Direct",
+			remoteAddr: "203.0.113.5:4000",
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "Untrusted proxy header ignored",
+			remoteAddr: "203.0.113.5:4000",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "Trusted proxy",
+			remoteAddr: "127.0.0.1:4000",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "Spoofed entries left of the client",
+			remoteAddr: "127.0.0.1:4000",
+			forwarded:  []string{"1.2.3.4, 198.51.100.1"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "Chained trusted proxies",
+			remoteAddr: "127.0.0.1:4000",
+			forwarded:  []string{"198.51.100.1, 10.1.2.3", "10.0.0.9"},
+			want:       "198.51.100.1",
+		},
+		{
+			name:       "Trusted proxy without header",
+			remoteAddr: "127.0.0.1:4000",
+			want:       "127.0.0.1",
+		},
+		{
+			name:       "Malformed entry",
+			remoteAddr: "127.0.0.1:4000",
+			forwarded:  []string{"198.51.100.1, garbage"},
+			want:       "127.0.0.1",
+		},
+		{
+			name:       "IPv4 mapped IPv6",
+			remoteAddr: "[::ffff:127.0.0.1]:4000",
+			forwarded:  []string{"198.51.100.1"},
+			want:       "198.51.100.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, header := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", header)
+			}
+
+			assert.Equal(t, proxies.ClientIP(r), tt.want)
+		})
+	}
+}
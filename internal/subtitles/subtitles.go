@@ -0,0 +1,308 @@
+package subtitles
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatSRT = "srt"
+	FormatVTT = "vtt"
+)
+
+var (
+	ErrUnknownFormat = errors.New("subtitles: unknown format")
+	ErrMalformed     = errors.New("subtitles: malformed file")
+)
+
+// Cue is a single timed line of a subtitle file. Multi-line cue text is
+// joined with a single space since transcript lines are displayed inline.
+type Cue struct {
+	StartMs int
+	EndMs   int
+	Speaker string
+	Text    string
+}
+
+// DetectFormat guesses the subtitle format from the file name extension,
+// falling back to sniffing the WEBVTT header. Returns an empty string if
+// neither applies.
+func DetectFormat(filename string, data []byte) string {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".srt"):
+		return FormatSRT
+	case strings.HasSuffix(lower, ".vtt"):
+		return FormatVTT
+	}
+
+	trimmed := bytes.TrimPrefix(data, []byte("\ufeff"))
+	if bytes.HasPrefix(trimmed, []byte("WEBVTT")) {
+		return FormatVTT
+	}
+
+	if timingRE.Match(data) {
+		return FormatSRT
+	}
+
+	return ""
+}
+
+func Parse(r io.Reader, format string) ([]Cue, error) {
+	switch format {
+	case FormatSRT:
+		return ParseSRT(r)
+	case FormatVTT:
+		return ParseVTT(r)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func Write(w io.Writer, format string, cues []Cue) error {
+	switch format {
+	case FormatSRT:
+		return WriteSRT(w, cues)
+	case FormatVTT:
+		return WriteVTT(w, cues)
+	}
+	return ErrUnknownFormat
+}
+
+// matches "00:00:01,000 --> 00:00:04,000" (SRT) as well as
+// "00:01.000 --> 00:04.000 line:0" (WebVTT, hours optional, trailing settings)
+var timingRE = regexp.MustCompile(
+	`(?m)^\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})`,
+)
+
+var (
+	voiceRE     = regexp.MustCompile(`<v(?:\.[^\s>]*)?\s+([^>]*)>`)
+	tagRE       = regexp.MustCompile(`<[^>]*>`)
+	assEffectRE = regexp.MustCompile(`\{\\[^}]*\}`)
+)
+
+func ParseSRT(r io.Reader) ([]Cue, error) {
+	blocks, err := readBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+
+	cues := []Cue{}
+	for _, block := range blocks {
+		// the numeric index line is optional in practice
+		if len(block) > 1 && !timingRE.MatchString(block[0]) {
+			block = block[1:]
+		}
+
+		cue, ok, err := parseCue(block)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			cues = append(cues, cue)
+		}
+	}
+
+	return cues, nil
+}
+
+func ParseVTT(r io.Reader) ([]Cue, error) {
+	blocks, err := readBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(blocks) == 0 || !strings.HasPrefix(blocks[0][0], "WEBVTT") {
+		return nil, fmt.Errorf("%w: missing WEBVTT header", ErrMalformed)
+	}
+
+	cues := []Cue{}
+	for _, block := range blocks[1:] {
+		switch {
+		case strings.HasPrefix(block[0], "NOTE"),
+			strings.HasPrefix(block[0], "STYLE"),
+			strings.HasPrefix(block[0], "REGION"):
+			continue
+		}
+
+		// optional cue identifier
+		if len(block) > 1 && !timingRE.MatchString(block[0]) {
+			block = block[1:]
+		}
+
+		cue, ok, err := parseCue(block)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			cues = append(cues, cue)
+		}
+	}
+
+	return cues, nil
+}
+
+// readBlocks splits the input into groups of non-empty lines separated
+// by blank lines.
+func readBlocks(r io.Reader) ([][]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	blocks := [][]string{}
+	current := []string{}
+	first := true
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				blocks = append(blocks, current)
+				current = []string{}
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(current) > 0 {
+		blocks = append(blocks, current)
+	}
+
+	return blocks, nil
+}
+
+// parseCue parses a block starting with a timing line. ok is false for
+// blocks that aren't cues or have no text.
+func parseCue(block []string) (Cue, bool, error) {
+	var cue Cue
+	matches := timingRE.FindStringSubmatch(block[0])
+	if matches == nil {
+		return cue, false, nil
+	}
+
+	var err error
+	cue.StartMs, err = parseTimestamp(matches[1])
+	if err != nil {
+		return cue, false, err
+	}
+
+	cue.EndMs, err = parseTimestamp(matches[2])
+	if err != nil {
+		return cue, false, err
+	}
+
+	if cue.EndMs < cue.StartMs {
+		return cue, false, fmt.Errorf("%w: cue ends before it starts: %q", ErrMalformed, block[0])
+	}
+
+	lines := []string{}
+	for _, line := range block[1:] {
+		if m := voiceRE.FindStringSubmatch(line); m != nil && cue.Speaker == "" {
+			cue.Speaker = strings.TrimSpace(m[1])
+		}
+
+		line = assEffectRE.ReplaceAllString(line, "")
+		line = tagRE.ReplaceAllString(line, "")
+		line = strings.TrimSpace(html.UnescapeString(line))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	cue.Text = strings.Join(lines, " ")
+	if cue.Text == "" {
+		return cue, false, nil
+	}
+
+	return cue, true, nil
+}
+
+// parseTimestamp converts "hh:mm:ss,mmm", "hh:mm:ss.mmm" or "mm:ss.mmm"
+// into milliseconds.
+func parseTimestamp(ts string) (int, error) {
+	ts = strings.Replace(ts, ",", ".", 1)
+	main, frac, _ := strings.Cut(ts, ".")
+
+	parts := strings.Split(main, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("%w: invalid timestamp %q", ErrMalformed, ts)
+	}
+
+	total := 0
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, fmt.Errorf("%w: invalid timestamp %q", ErrMalformed, ts)
+		}
+		total = total*60 + n
+	}
+
+	// right pad so ".5" is read as 500ms
+	for len(frac) < 3 {
+		frac += "0"
+	}
+	ms, err := strconv.Atoi(frac[:3])
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid timestamp %q", ErrMalformed, ts)
+	}
+
+	return total*1000 + ms, nil
+}
+
+func formatTimestamp(ms int, sep string) string {
+	if ms < 0 {
+		ms = 0
+	}
+	h := ms / 3_600_000
+	m := ms / 60_000 % 60
+	s := ms / 1000 % 60
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", h, m, s, sep, ms%1000)
+}
+
+// WriteSRT writes the cues in SubRip format. SRT has no notion of a
+// speaker so it is dropped.
+func WriteSRT(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	for i, c := range cues {
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n",
+			i+1,
+			formatTimestamp(c.StartMs, ","),
+			formatTimestamp(c.EndMs, ","),
+			c.Text,
+		)
+	}
+	return bw.Flush()
+}
+
+// WriteVTT writes the cues in WebVTT format, using voice spans for
+// cues with a speaker.
+func WriteVTT(w io.Writer, cues []Cue) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n\n")
+	for _, c := range cues {
+		text := html.EscapeString(c.Text)
+		if c.Speaker != "" {
+			text = fmt.Sprintf("<v %s>%s", html.EscapeString(c.Speaker), text)
+		}
+		fmt.Fprintf(bw, "%s --> %s\n%s\n\n",
+			formatTimestamp(c.StartMs, "."),
+			formatTimestamp(c.EndMs, "."),
+			text,
+		)
+	}
+	return bw.Flush()
+}
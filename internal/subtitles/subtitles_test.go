@@ -0,0 +1,105 @@
+package subtitles
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"sketchdb.cozycole.net/internal/assert"
+)
+
+func TestParseSRT(t *testing.T) {
+	input := "\ufeff1\r\n00:00:01,000 --> 00:00:04,250\r\nHello there,\r\n<i>general</i>.\r\n\r\n" +
+		"2\r\n00:01:02,5 --> 01:00:00,000\r\nTom &amp; Jerry\r\n"
+
+	cues, err := ParseSRT(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.DeepEqual(t, cues, []Cue{
+		{StartMs: 1000, EndMs: 4250, Text: "Hello there, general."},
+		{StartMs: 62500, EndMs: 3_600_000, Text: "Tom & Jerry"},
+	})
+}
+
+func TestParseVTT(t *testing.T) {
+	input := `WEBVTT - sketch
+
+NOTE this is a comment
+
+intro
+00:01.000 --> 00:02.500 align:start
+<v.loud Bob Odenkirk>I'm Bob</v>
+
+00:00:03.000 --> 00:00:05.000
+<c.yellow>and I'm David</c>
+`
+
+	cues, err := ParseVTT(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.DeepEqual(t, cues, []Cue{
+		{StartMs: 1000, EndMs: 2500, Speaker: "Bob Odenkirk", Text: "I'm Bob"},
+		{StartMs: 3000, EndMs: 5000, Text: "and I'm David"},
+	})
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   error
+	}{
+		{"Missing header", FormatVTT, "00:01.000 --> 00:02.000\nhi\n", ErrMalformed},
+		{"End before start", FormatSRT, "1\n00:00:05,000 --> 00:00:01,000\nhi\n", ErrMalformed},
+		{"Unknown format", "ass", "", ErrUnknownFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input), tt.format)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got: %v; want: %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	cues := []Cue{
+		{StartMs: 0, EndMs: 1500, Speaker: "Tim", Text: "Hi <there>"},
+		{StartMs: 3_723_004, EndMs: 3_724_000, Text: "bye"},
+	}
+
+	var buf bytes.Buffer
+	err := WriteVTT(&buf, cues)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseVTT(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.DeepEqual(t, parsed, cues)
+
+	buf.Reset()
+	err = WriteSRT(&buf, cues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, strings.Contains(buf.String(), "01:02:03,004 --> 01:02:04,000"), true)
+}
+
+func TestDetectFormat(t *testing.T) {
+	assert.Equal(t, DetectFormat("a.SRT", nil), FormatSRT)
+	assert.Equal(t, DetectFormat("a.vtt", nil), FormatVTT)
+	assert.Equal(t, DetectFormat("blob", []byte("WEBVTT\n\n")), FormatVTT)
+	assert.Equal(t, DetectFormat("blob", []byte("1\n00:00:01,000 --> 00:00:02,000\n")), FormatSRT)
+	assert.Equal(t, DetectFormat("blob", []byte("nope")), "")
+}
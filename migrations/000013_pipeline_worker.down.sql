@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_pipeline_jobs_status_run_after;
+
+ALTER TABLE pipeline_jobs
+DROP COLUMN IF EXISTS attempts,
+DROP COLUMN IF EXISTS run_after,
+DROP COLUMN IF EXISTS started_at,
+DROP COLUMN IF EXISTS finished_at;
@@ -0,0 +1,14 @@
+ALTER TABLE pipeline_jobs
+ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS run_after TIMESTAMP,
+ADD COLUMN IF NOT EXISTS started_at TIMESTAMP,
+ADD COLUMN IF NOT EXISTS finished_at TIMESTAMP;
+
+-- existing jobs were due when they were created, the default is only set
+-- after the backfill so they don't all get the migration time
+UPDATE pipeline_jobs SET run_after = COALESCE(created_at, CURRENT_TIMESTAMP) WHERE run_after IS NULL;
+
+ALTER TABLE pipeline_jobs ALTER COLUMN run_after SET DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_pipeline_jobs_status_run_after
+ON pipeline_jobs (status, run_after);
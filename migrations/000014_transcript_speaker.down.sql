@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transcription_lines_sketch_id;
+
+ALTER TABLE transcription_lines
+DROP COLUMN IF EXISTS speaker;
@@ -0,0 +1,5 @@
+ALTER TABLE transcription_lines
+ADD COLUMN IF NOT EXISTS speaker TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_transcription_lines_sketch_id
+ON transcription_lines (sketch_id, line_number);
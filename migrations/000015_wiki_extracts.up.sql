@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS wiki_extracts (
+    page TEXT PRIMARY KEY,
+    title TEXT,
+    extract TEXT,
+    redirected BOOLEAN NOT NULL DEFAULT false,
+    missing BOOLEAN NOT NULL DEFAULT false,
+    fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
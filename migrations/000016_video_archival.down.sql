@@ -0,0 +1,2 @@
+ALTER TABLE sketch_video
+DROP COLUMN IF EXISTS hot_restored_at;
@@ -0,0 +1,2 @@
+ALTER TABLE sketch_video
+ADD COLUMN IF NOT EXISTS hot_restored_at TIMESTAMPTZ;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS sketch_video_primary_idx;
+
+ALTER TABLE sketch_video
+DROP COLUMN IF EXISTS is_primary,
+DROP COLUMN IF EXISTS source,
+DROP COLUMN IF EXISTS width,
+DROP COLUMN IF EXISTS height;
@@ -0,0 +1,16 @@
+ALTER TABLE sketch_video
+ADD COLUMN IF NOT EXISTS is_primary BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN IF NOT EXISTS source TEXT,
+ADD COLUMN IF NOT EXISTS width INT,
+ADD COLUMN IF NOT EXISTS height INT;
+
+-- existing sketches only ever had one video, make the oldest the primary
+UPDATE sketch_video SET is_primary = true
+WHERE id IN (
+    SELECT DISTINCT ON (sketch_id) id
+    FROM sketch_video
+    ORDER BY sketch_id, id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS sketch_video_primary_idx
+ON sketch_video (sketch_id) WHERE is_primary;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS video_uploads (
+    id SERIAL PRIMARY KEY,
+    sketch_id INT NOT NULL REFERENCES sketch(id) ON DELETE CASCADE,
+    s3_key TEXT NOT NULL,
+    upload_id TEXT NOT NULL,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    file_size BIGINT NOT NULL,
+    part_size BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS video_uploads_sketch_id_idx ON video_uploads (sketch_id);
+CREATE INDEX IF NOT EXISTS video_uploads_updated_at_idx ON video_uploads (updated_at);
@@ -0,0 +1,8 @@
+ALTER TABLE sketch_video
+DROP COLUMN IF EXISTS size_bytes,
+DROP COLUMN IF EXISTS content_type,
+DROP COLUMN IF EXISTS container,
+DROP COLUMN IF EXISTS duration_ms,
+DROP COLUMN IF EXISTS video_codec,
+DROP COLUMN IF EXISTS audio_codec,
+DROP COLUMN IF EXISTS probed_at;
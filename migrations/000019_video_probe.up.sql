@@ -0,0 +1,8 @@
+ALTER TABLE sketch_video
+ADD COLUMN IF NOT EXISTS size_bytes BIGINT,
+ADD COLUMN IF NOT EXISTS content_type TEXT,
+ADD COLUMN IF NOT EXISTS container TEXT,
+ADD COLUMN IF NOT EXISTS duration_ms BIGINT,
+ADD COLUMN IF NOT EXISTS video_codec TEXT,
+ADD COLUMN IF NOT EXISTS audio_codec TEXT,
+ADD COLUMN IF NOT EXISTS probed_at TIMESTAMPTZ;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS home_slots (
+    id SERIAL PRIMARY KEY,
+    section TEXT NOT NULL CHECK (
+        section IN ('featured_sketches', 'spotlight_people', 'shows', 'recurring')
+    ),
+    entity_id INT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS home_slots_section_idx ON home_slots (section, position);
+
+-- carry over the sketches tagged 'Featured' and the people that were
+-- hard coded on the homepage
+INSERT INTO home_slots (section, entity_id, position)
+SELECT 'featured_sketches', v.id, ROW_NUMBER() OVER (ORDER BY v.title)
+FROM sketch as v
+JOIN sketch_tags as vt ON v.id = vt.sketch_id
+JOIN tags as t ON vt.tag_id = t.id
+WHERE t.name = 'Featured';
+
+INSERT INTO home_slots (section, entity_id, position)
+SELECT 'spotlight_people', p.id, ids.position
+FROM unnest(ARRAY[61, 52, 42, 1, 2, 3, 39, 54, 56]) WITH ORDINALITY as ids(id, position)
+JOIN person as p ON p.id = ids.id;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS browse_sections (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    filter JSONB NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- the sections that were previously defined in code
+INSERT INTO browse_sections (title, filter, position) VALUES
+    ('Featured Sketches', '{"page": 1, "pageSize": 10, "sortBy": "popular", "tagIds": [1]}', 1),
+    ('Popular', '{"page": 1, "pageSize": 10, "sortBy": "popular"}', 2);
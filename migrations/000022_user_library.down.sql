@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_likes_user_created;
+DROP INDEX IF EXISTS idx_sketch_rating_user_created;
+DROP INDEX IF EXISTS idx_quote_likes_user_created;
+
+ALTER TABLE quote_likes DROP COLUMN IF EXISTS created_at;
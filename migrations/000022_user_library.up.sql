@@ -0,0 +1,6 @@
+-- existing quote likes get the migration time as their like date
+ALTER TABLE quote_likes ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS idx_quote_likes_user_created ON quote_likes (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_sketch_rating_user_created ON sketch_rating (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_likes_user_created ON likes (user_id, created_at);
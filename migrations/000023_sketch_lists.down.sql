@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS sketch_list_items;
+DROP TABLE IF EXISTS sketch_lists;
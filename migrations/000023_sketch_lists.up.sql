@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS sketch_lists (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT,
+    visibility TEXT NOT NULL DEFAULT 'private' CHECK (
+        visibility IN ('public', 'private', 'unlisted')
+    ),
+    -- the slug has a random suffix so unlisted lists can't be guessed
+    slug TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sketch_lists_user_id ON sketch_lists (user_id);
+
+CREATE TABLE IF NOT EXISTS sketch_list_items (
+    list_id INT NOT NULL REFERENCES sketch_lists(id) ON DELETE CASCADE,
+    sketch_id INT NOT NULL REFERENCES sketch(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    note TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (list_id, sketch_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sketch_list_items_sketch_id ON sketch_list_items (sketch_id);
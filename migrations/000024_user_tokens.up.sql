@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS user_tokens (
+    hash BYTEA PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    scope TEXT NOT NULL CHECK (scope IN ('activation', 'password_reset')),
+    expiry TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_scope ON user_tokens (user_id, scope);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_token_user_id;
+
+ALTER TABLE token
+    DROP COLUMN IF EXISTS name,
+    DROP COLUMN IF EXISTS scopes,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS last_used_at;
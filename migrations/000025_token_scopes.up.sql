@@ -0,0 +1,12 @@
+ALTER TABLE token
+    ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMPTZ;
+
+-- tokens minted before scopes existed keep the full access they had
+UPDATE token
+SET name = 'admin token',
+    scopes = '{read,sketch:write,media:upload,user:write,admin}';
+
+CREATE INDEX IF NOT EXISTS idx_token_user_id ON token (user_id);
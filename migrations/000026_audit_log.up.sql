@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INT REFERENCES users(id) ON DELETE SET NULL,
+    entity_type TEXT NOT NULL,
+    entity_id INT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('create', 'update', 'delete', 'revert')),
+    before JSONB,
+    after JSONB,
+    changes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (entity_type, entity_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor_id, id DESC);
@@ -0,0 +1,12 @@
+-- people and characters merged into another row, their old ids redirect
+-- to the row they were merged into
+CREATE TABLE IF NOT EXISTS merge_redirects (
+    entity_type TEXT NOT NULL CHECK (entity_type IN ('person', 'character')),
+    from_id INT NOT NULL,
+    from_slug TEXT NOT NULL,
+    to_id INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (entity_type, from_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_merge_redirects_to ON merge_redirects (entity_type, to_id);
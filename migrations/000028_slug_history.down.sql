@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS sketch_slug_history ON sketch;
+DROP TRIGGER IF EXISTS person_slug_history ON person;
+DROP TRIGGER IF EXISTS character_slug_history ON character;
+DROP TRIGGER IF EXISTS creator_slug_history ON creator;
+DROP TRIGGER IF EXISTS show_slug_history ON show;
+DROP TRIGGER IF EXISTS season_slug_history ON season;
+DROP TRIGGER IF EXISTS episode_slug_history ON episode;
+DROP TRIGGER IF EXISTS series_slug_history ON series;
+DROP TRIGGER IF EXISTS recurring_slug_history ON recurring;
+DROP FUNCTION IF EXISTS record_slug_history();
+DROP TABLE IF EXISTS slug_history;
@@ -0,0 +1,60 @@
+-- slugs an entity had before it was renamed, so links using an old slug
+-- can still be resolved
+CREATE TABLE IF NOT EXISTS slug_history (
+    entity_type TEXT NOT NULL,
+    entity_id INT NOT NULL,
+    slug TEXT NOT NULL,
+    replaced_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (entity_type, entity_id, slug)
+);
+
+CREATE INDEX IF NOT EXISTS idx_slug_history_slug ON slug_history (entity_type, slug);
+
+-- entity_type is the table name, which is also the first segment of the
+-- entity's url
+CREATE OR REPLACE FUNCTION record_slug_history() RETURNS trigger AS $$
+BEGIN
+    IF COALESCE(OLD.slug, '') <> '' AND OLD.slug IS DISTINCT FROM NEW.slug THEN
+        INSERT INTO slug_history (entity_type, entity_id, slug)
+        VALUES (TG_TABLE_NAME, OLD.id, OLD.slug)
+        ON CONFLICT (entity_type, entity_id, slug) DO UPDATE SET replaced_at = now();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER sketch_slug_history
+AFTER UPDATE OF slug ON sketch
+FOR EACH ROW EXECUTE FUNCTION record_slug_history();
+
+CREATE OR REPLACE TRIGGER person_slug_history
+AFTER UPDATE OF slug ON person
+FOR EACH ROW EXECUTE FUNCTION record_slug_history();
+
+CREATE OR REPLACE TRIGGER character_slug_history
+AFTER UPDATE OF slug ON character
+FOR EACH ROW EXECUTE FUNCTION record_slug_history();
+
+CREATE OR REPLACE TRIGGER creator_slug_history
+AFTER UPDATE OF slug ON creator
+FOR EACH ROW EXECUTE FUNCTION record_slug_history();
+
+CREATE OR REPLACE TRIGGER show_slug_history
+AFTER UPDATE OF slug ON show
+FOR EACH ROW EXECUTE FUNCTION record_slug_history();
+
+CREATE OR REPLACE TRIGGER season_slug_history
+AFTER UPDATE OF slug ON season
+FOR EACH ROW EXECUTE FUNCTION record_slug_history();
+
+CREATE OR REPLACE TRIGGER episode_slug_history
+AFTER UPDATE OF slug ON episode
+FOR EACH ROW EXECUTE FUNCTION record_slug_history();
+
+CREATE OR REPLACE TRIGGER series_slug_history
+AFTER UPDATE OF slug ON series
+FOR EACH ROW EXECUTE FUNCTION record_slug_history();
+
+CREATE OR REPLACE TRIGGER recurring_slug_history
+AFTER UPDATE OF slug ON recurring
+FOR EACH ROW EXECUTE FUNCTION record_slug_history();
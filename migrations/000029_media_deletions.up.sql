@@ -0,0 +1,13 @@
+-- storage objects waiting to be deleted, queued in the same transaction
+-- as the rows that referenced them so none are left orphaned
+CREATE TABLE IF NOT EXISTS media_deletions (
+    id SERIAL PRIMARY KEY,
+    store TEXT NOT NULL CHECK (store IN ('media', 'archive')),
+    object_key TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    error TEXT,
+    run_after TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS media_deletions_run_after_idx ON media_deletions (run_after);
@@ -0,0 +1 @@
+ALTER TABLE media_deletions DROP COLUMN IF EXISTS upload_id;
@@ -0,0 +1,4 @@
+-- a deletion with an upload_id aborts that multipart upload before
+-- deleting its key, queued for the uploads of deleted sketches which
+-- would otherwise keep their parts forever
+ALTER TABLE media_deletions ADD COLUMN IF NOT EXISTS upload_id TEXT;
@@ -0,0 +1,8 @@
+-- logging in now needs an activated account, accounts that were never
+-- sent an activation email predate the flow and stay usable
+UPDATE users SET activated = true
+WHERE NOT activated
+AND NOT EXISTS (
+    SELECT 1 FROM user_tokens
+    WHERE user_tokens.user_id = users.id AND user_tokens.scope = 'activation'
+);